@@ -18,13 +18,16 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
@@ -32,10 +35,12 @@ import (
 	"github.com/kserve/kserve/pkg/agent/storage"
 	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
 	"github.com/kserve/kserve/pkg/batcher"
+	"github.com/kserve/kserve/pkg/constants"
 	kfslogger "github.com/kserve/kserve/pkg/logger"
 	"github.com/pkg/errors"
 	flag "github.com/spf13/pflag"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"knative.dev/networking/pkg/http/header"
 	proxy "knative.dev/networking/pkg/http/proxy"
@@ -51,23 +56,64 @@ import (
 var (
 	port          = flag.String("port", "9081", "Agent port")
 	componentPort = flag.Int("component-port", 8080, "Component port")
+	statusPort    = flag.String("status-port", constants.AgentModelStatusPortStr, "Port of the model status endpoint of the model puller, which is not proxied to the model server")
 	// model puller flags
 	enablePuller = flag.Bool("enable-puller", false, "Enable model puller")
 	configDir    = flag.String("config-dir", "/mnt/configs", "directory for model config files")
 	modelDir     = flag.String("model-dir", "/mnt/models", "directory for model files")
+	// model repository flags
+	modelRepository         = flag.String("model-repository", string(agent.RepositoryV2), "The repository API used to load the models: 'v2', 'torchserve', 'tfserving' or 'file'")
+	modelRepositoryEndpoint = flag.String("model-repository-endpoint", "", "The url of the model server repository API, http://localhost:<component-port> by default, or the TorchServe management API on port 8081")
+	modelRepositoryConfig   = flag.String("model-repository-config-file", "", "The TF Serving model config file, models.config in the model dir by default")
+	modelLoadTimeout        = flag.Duration("model-load-timeout", agent.DefaultTFServingLoadTimeout, "Max time waited for a model to become available on TF Serving")
+	// model cache flags
+	modelCacheDir  = flag.String("model-cache-dir", "", "Directory of the model cache shared by the models with the same artifact, e.g. <model-dir>/.cache. It must be on the volume shared with the model server. Only the artifacts pinned by a digest or a version are cached. The cache is disabled if it is empty")
+	modelCacheSize = flag.String("model-cache-size", "0", "Size budget of the model cache, e.g. 10Gi, over which the artifacts of unloaded models are evicted. It is unbounded if 0")
+	// model download flags
+	downloadConcurrency     = flag.Int("download-concurrency", 0, "Max number of models downloaded at once, unbounded if 0")
+	downloadOrder           = flag.String("download-order", string(agent.DownloadOrderFIFO), "The order in which the models waiting to be downloaded are downloaded: 'fifo', 'smallest-first' (by model memory) or 'priority' (by model priority)")
+	downloadBandwidthLimits = flag.String("download-bandwidth-limits", "", "Download throughput limits per storage provider in bytes per second, e.g. s3=100Mi,gs=50Mi,azure=50Mi,oci=50Mi,https=10Mi")
+	downloadPartSize        = flag.String("download-part-size", resource.NewQuantity(storage.DefaultDownloadOptions.PartSize, resource.BinarySI).String(), "Size of the ranges the S3 and GCS objects are downloaded in, the objects are downloaded whole if 0")
+	downloadParallelism     = flag.Int("download-parallelism", storage.DefaultDownloadOptions.Parallelism, "Number of ranges of a model downloaded at once from S3 and GCS")
+	archiveMaxSize          = flag.String("archive-max-size", resource.NewQuantity(storage.DefaultArchiveLimits.MaxSize, resource.BinarySI).String(), "Max total size of the files extracted from a model archive or modelcar image")
+	archiveMaxFiles         = flag.Int("archive-max-files", storage.DefaultArchiveLimits.MaxFiles, "Max number of files extracted from a model archive or modelcar image")
+	pvcMountRoot            = flag.String("pvc-mount-root", constants.ModelPvcMountRoot, "The directory the PVCs of the pvc:// models are mounted into by name")
+	fileRoot                = flag.String("file-root", "", "The directory the file:// models must be under, e.g. the mount path of a volume holding the models. file:// models are disabled if it is empty")
+	linkLocalModels         = flag.Bool("link-local-models", false, "Link the pvc:// and file:// models into the model dir rather than copying them, the volumes must be mounted at the same path into the model server")
+	storagePlugins          = flag.String("storage-plugins", "", `JSON list of the plugins providing custom storage protocols, e.g. [{"protocol": "artifactory://", "command": ["/plugins/artifactory"]}, {"protocol": "registry://", "endpoint": "http://localhost:9500"}]`)
+	// model load retry flags
+	modelMaxAttempts    = flag.Int("model-max-attempts", agent.DefaultRetryPolicy.MaxAttempts, "Number of times a model download and load is attempted before the model is marked as failed")
+	modelInitialBackoff = flag.Duration("model-initial-backoff", agent.DefaultRetryPolicy.InitialBackoff, "Initial backoff between model download and load attempts, doubled on every retry")
+	modelMaxBackoff     = flag.Duration("model-max-backoff", agent.DefaultRetryPolicy.MaxBackoff, "Max backoff between model download and load attempts")
 	// logger flags
 	logUrl           = flag.String("log-url", "", "The URL to send request/response logs to")
 	workers          = flag.Int("workers", 5, "Number of workers")
 	sourceUri        = flag.String("source-uri", "", "The source URI to use when publishing cloudevents")
-	logMode          = flag.String("log-mode", string(v1beta1.LogAll), "Whether to log 'request', 'response' or 'all'")
+	logMode          = flag.String("log-mode", string(v1beta1.LogAll), "Whether to log 'request', 'response', 'all' or 'combined'")
 	inferenceService = flag.String("inference-service", "", "The InferenceService name to add as header to log events")
 	namespace        = flag.String("namespace", "", "The namespace to add as header to log events")
 	endpoint         = flag.String("endpoint", "", "The endpoint name to add as header to log events")
 	component        = flag.String("component", "", "The component name (predictor, explainer, transformer) to add as header to log events")
+	logSink          = flag.String("log-sink", string(v1beta1.LoggerSinkCloudEvents), "The sink to deliver log events to: 'cloudevents', 'cloudevents-structured', 'http-batch', 'kafka' or 'file'")
+	logBatchSize     = flag.Int("log-batch-size", kfslogger.DefaultSinkOptions.BatchSize, "Max number of log events posted at once by the http-batch sink")
+	logBatchInterval = flag.Duration("log-batch-interval", kfslogger.DefaultSinkOptions.BatchInterval, "Max time a log event is buffered by the http-batch sink")
+	logMaxFileSize   = flag.Int64("log-max-file-size", kfslogger.DefaultSinkOptions.MaxFileSize, "Size in bytes at which the file sink rotates its file")
+	logMaxBackups    = flag.Int("log-max-file-backups", kfslogger.DefaultSinkOptions.MaxFileBackups, "Number of rotated files kept by the file sink")
+	logMaxRetries    = flag.Int("log-max-retries", kfslogger.DefaultRetryOptions.MaxRetries, "Number of times a failed log event delivery is retried")
+	logRetryBackoff  = flag.Duration("log-retry-backoff", kfslogger.DefaultRetryOptions.InitialBackoff, "Initial backoff between log event delivery retries, doubled on every retry")
+	logMaxBackoff    = flag.Duration("log-max-retry-backoff", kfslogger.DefaultRetryOptions.MaxBackoff, "Max backoff between log event delivery retries")
+	logSpoolDir      = flag.String("log-spool-dir", "", "Directory undeliverable log events are spooled to and replayed from, disabled if empty. The events only outlive the agent as long as the directory does")
+	logSpoolMaxSize  = flag.Int64("log-spool-max-size", kfslogger.DefaultRetryOptions.SpoolMaxSize, "Max size in bytes of the spooled log events, the oldest ones are evicted beyond it, unlimited if 0")
+	logFilter        = flag.String("log-filter", "", "JSON encoded LoggerFilterSpec selecting the requests to log, all requests are logged if empty")
+	logRedaction     = flag.String("log-redaction", "", "JSON encoded LoggerRedactionSpec applied to the logged payloads")
+	logOverflow      = flag.String("log-overflow-policy", string(kfslogger.OverflowBlock), "What to do with log events when the log queue is full: 'block', 'drop-oldest' or 'drop-newest'")
+	logFlushTimeout  = flag.Duration("log-flush-timeout", 5*time.Second, "Max time spent delivering the queued log events on shutdown")
+	logMaxPayload    = flag.Int64("log-max-payload-size", 0, "Max size in bytes of the logged request and response payloads, larger payloads are truncated, unlimited if 0")
 	// batcher flags
-	enableBatcher = flag.Bool("enable-batcher", false, "Enable request batcher")
-	maxBatchSize  = flag.String("max-batchsize", "32", "Max Batch Size")
-	maxLatency    = flag.String("max-latency", "5000", "Max Latency in milliseconds")
+	enableBatcher  = flag.Bool("enable-batcher", false, "Enable request batcher")
+	maxBatchSize   = flag.String("max-batchsize", "32", "Max Batch Size")
+	maxLatency     = flag.String("max-latency", "5000", "Max Latency in milliseconds")
+	partialFailure = flag.Bool("batch-partial-failure", false, "Return per-instance errors instead of failing the whole batch")
 	// probing flags
 	readinessProbeTimeout = flag.Duration("probe-period", -1, "run readiness probe with given timeout") //nolint: unused
 	// This creates an abstract socket instead of an actual file.
@@ -107,11 +153,16 @@ type loggerArgs struct {
 	namespace        string
 	endpoint         string
 	component        string
+	filter           *kfslogger.Filter
+	redactor         *kfslogger.Redactor
+	maxPayloadSize   int64
+	dispatcher       *kfslogger.Dispatcher
 }
 
 type batcherArgs struct {
-	maxBatchSize int
-	maxLatency   int
+	maxBatchSize   int
+	maxLatency     int
+	partialFailure bool
 }
 
 func main() {
@@ -130,9 +181,10 @@ func main() {
 		probe = buildProbe(logger, env.ServingReadinessProbe).ProbeContainer
 	}
 
+	var statusTracker *agent.StatusTracker
 	if *enablePuller {
 		logger.Infof("Initializing model agent with config-dir %s, model-dir %s", *configDir, *modelDir)
-		startModelPuller(logger)
+		statusTracker = startModelPuller(logger)
 	}
 
 	var loggerArgs *loggerArgs
@@ -152,6 +204,9 @@ func main() {
 	servers := map[string]*http.Server{
 		"main": mainServer,
 	}
+	if statusTracker != nil {
+		servers["status"] = buildStatusServer(*statusPort, statusTracker)
+	}
 	errCh := make(chan error)
 	listenCh := make(chan struct{})
 	for name, server := range servers {
@@ -226,6 +281,15 @@ func main() {
 				logger.Errorw("Failed to shutdown server", zap.String("server", serverName), zap.Error(err))
 			}
 		}
+		if loggerArgs != nil {
+			// The servers are shut down so no more log events are queued
+			logger.Infof("Flushing queued log events, waiting up to %v", *logFlushTimeout)
+			flushCtx, cancel := context.WithTimeout(context.Background(), *logFlushTimeout)
+			if err := loggerArgs.dispatcher.Shutdown(flushCtx); err != nil {
+				logger.Errorw("Failed to flush log events", zap.Error(err))
+			}
+			cancel()
+		}
 		logger.Info("Shutdown complete, exiting...")
 	}
 }
@@ -244,15 +308,16 @@ func startBatcher(logger *zap.SugaredLogger) *batcherArgs {
 	}
 
 	return &batcherArgs{
-		maxLatency:   maxLatencyInt,
-		maxBatchSize: maxBatchSizeInt,
+		maxLatency:     maxLatencyInt,
+		maxBatchSize:   maxBatchSizeInt,
+		partialFailure: *partialFailure,
 	}
 }
 
 func startLogger(workers int, logger *zap.SugaredLogger) *loggerArgs {
 	loggingMode := v1beta1.LoggerType(*logMode)
 	switch loggingMode {
-	case v1beta1.LogAll, v1beta1.LogRequest, v1beta1.LogResponse:
+	case v1beta1.LogAll, v1beta1.LogRequest, v1beta1.LogResponse, v1beta1.LogCombined:
 	default:
 		logger.Errorf("Malformed log-mode %s", *logMode)
 		os.Exit(-1)
@@ -264,6 +329,41 @@ func startLogger(workers int, logger *zap.SugaredLogger) *loggerArgs {
 		os.Exit(-1)
 	}
 
+	overflowPolicy := kfslogger.OverflowPolicy(*logOverflow)
+	switch overflowPolicy {
+	case kfslogger.OverflowBlock, kfslogger.OverflowDropOldest, kfslogger.OverflowDropNewest:
+		kfslogger.QueueOverflowPolicy = overflowPolicy
+	default:
+		logger.Errorf("Malformed log-overflow-policy %s", *logOverflow)
+		os.Exit(-1)
+	}
+
+	var filter *kfslogger.Filter
+	if *logFilter != "" {
+		filterSpec := &v1beta1.LoggerFilterSpec{}
+		if err := json.Unmarshal([]byte(*logFilter), filterSpec); err != nil {
+			logger.Errorf("Malformed log-filter %s: %v", *logFilter, err)
+			os.Exit(-1)
+		}
+		if filter, err = kfslogger.NewFilter(filterSpec); err != nil {
+			logger.Errorf("Invalid log-filter %s: %v", *logFilter, err)
+			os.Exit(-1)
+		}
+	}
+
+	var redactor *kfslogger.Redactor
+	if *logRedaction != "" {
+		redactionSpec := &v1beta1.LoggerRedactionSpec{}
+		if err := json.Unmarshal([]byte(*logRedaction), redactionSpec); err != nil {
+			logger.Errorf("Malformed log-redaction %s: %v", *logRedaction, err)
+			os.Exit(-1)
+		}
+		if redactor, err = kfslogger.NewRedactor(redactionSpec); err != nil {
+			logger.Errorf("Invalid log-redaction %s: %v", *logRedaction, err)
+			os.Exit(-1)
+		}
+	}
+
 	if *sourceUri == "" {
 		*sourceUri = fmt.Sprintf("http://localhost:%s/", *port)
 	}
@@ -273,8 +373,30 @@ func startLogger(workers int, logger *zap.SugaredLogger) *loggerArgs {
 		logger.Errorf("Malformed source_uri %s", *sourceUri)
 		os.Exit(-1)
 	}
+	sink, err := kfslogger.NewSink(v1beta1.LoggerSinkType(*logSink), logUrlParsed, kfslogger.SinkOptions{
+		BatchSize:      *logBatchSize,
+		BatchInterval:  *logBatchInterval,
+		MaxFileSize:    *logMaxFileSize,
+		MaxFileBackups: *logMaxBackups,
+	}, logger)
+	if err != nil {
+		logger.Errorf("Failed to create log sink %s: %v", *logSink, err)
+		os.Exit(-1)
+	}
+	sink, err = kfslogger.NewRetrySink(sink, kfslogger.RetryOptions{
+		MaxRetries:     *logMaxRetries,
+		InitialBackoff: *logRetryBackoff,
+		MaxBackoff:     *logMaxBackoff,
+		SpoolDir:       *logSpoolDir,
+		SpoolMaxSize:   *logSpoolMaxSize,
+		ReplayInterval: kfslogger.DefaultRetryOptions.ReplayInterval,
+	}, logger)
+	if err != nil {
+		logger.Errorf("Failed to create log spool %s: %v", *logSpoolDir, err)
+		os.Exit(-1)
+	}
 	logger.Info("Starting the log dispatcher")
-	kfslogger.StartDispatcher(workers, logger)
+	dispatcher := kfslogger.StartDispatcher(workers, sink, logger)
 	return &loggerArgs{
 		loggerType:       loggingMode,
 		logUrl:           logUrlParsed,
@@ -283,19 +405,137 @@ func startLogger(workers int, logger *zap.SugaredLogger) *loggerArgs {
 		endpoint:         *endpoint,
 		namespace:        *namespace,
 		component:        *component,
+		filter:           filter,
+		redactor:         redactor,
+		maxPayloadSize:   *logMaxPayload,
+		dispatcher:       dispatcher,
 	}
 }
 
-func startModelPuller(logger *zap.SugaredLogger) {
+func startModelPuller(logger *zap.SugaredLogger) *agent.StatusTracker {
 	downloader := agent.Downloader{
 		ModelDir:  *modelDir,
 		Providers: map[storage.Protocol]storage.Provider{},
 		Logger:    logger,
 	}
+	localProvider := &storage.LocalProvider{PvcMountRoot: *pvcMountRoot, FileRoot: *fileRoot, Link: *linkLocalModels}
+	downloader.Providers[storage.PVC] = localProvider
+	downloader.Providers[storage.File] = localProvider
+	if *storagePlugins != "" {
+		var plugins []storage.PluginConfig
+		if err := json.Unmarshal([]byte(*storagePlugins), &plugins); err != nil {
+			logger.Fatalw("Failed to parse the storage plugins", zap.Error(err))
+		}
+		if err := storage.RegisterPlugins(plugins); err != nil {
+			logger.Fatalw("Invalid storage plugins", zap.Error(err))
+		}
+	}
+	// the model registry uris are resolved through the MLflow tracking server of the storage secret
+	if resolver := storage.NewMLflowResolverFromEnv(); resolver != nil {
+		downloader.Resolver = resolver
+	}
+	if *modelCacheDir != "" {
+		cacheSize, err := resource.ParseQuantity(*modelCacheSize)
+		if err != nil {
+			logger.Fatalw("Failed to parse the model cache size", zap.Error(err))
+		}
+		if downloader.Cache, err = agent.NewModelCache(*modelCacheDir, cacheSize.Value(), logger); err != nil {
+			logger.Fatalw("Failed to open the model cache", zap.Error(err))
+		}
+	}
+	if *downloadConcurrency > 0 {
+		scheduler, err := agent.NewDownloadScheduler(*downloadConcurrency, agent.DownloadOrder(*downloadOrder))
+		if err != nil {
+			logger.Fatalw("Failed to create the download scheduler", zap.Error(err))
+		}
+		downloader.Scheduler = scheduler
+	}
+	partSize, err := resource.ParseQuantity(*downloadPartSize)
+	if err != nil {
+		logger.Fatalw("Failed to parse the download part size", zap.Error(err))
+	}
+	downloadOptions := storage.DownloadOptions{PartSize: partSize.Value(), Parallelism: *downloadParallelism}
+	if err := downloadOptions.Validate(); err != nil {
+		logger.Fatalw("Invalid download options", zap.Error(err))
+	}
+	storage.DefaultDownloadOptions = downloadOptions
+	archiveSize, err := resource.ParseQuantity(*archiveMaxSize)
+	if err != nil {
+		logger.Fatalw("Failed to parse the archive max size", zap.Error(err))
+	}
+	archiveLimits := storage.ArchiveLimits{MaxSize: archiveSize.Value(), MaxFiles: *archiveMaxFiles}
+	if err := archiveLimits.Validate(); err != nil {
+		logger.Fatalw("Invalid archive limits", zap.Error(err))
+	}
+	storage.DefaultArchiveLimits = archiveLimits
+	if *downloadBandwidthLimits != "" {
+		limits, err := parseBandwidthLimits(*downloadBandwidthLimits)
+		if err != nil {
+			logger.Fatalw("Failed to parse the download bandwidth limits", zap.Error(err))
+		}
+		for protocol, bytesPerSecond := range limits {
+			provider, err := storage.GetProvider(downloader.Providers, protocol)
+			if err != nil {
+				logger.Fatalw("Failed to create the storage provider", "protocol", protocol, zap.Error(err))
+			}
+			if err := storage.LimitBandwidth(provider, storage.NewBandwidthLimiter(bytesPerSecond)); err != nil {
+				logger.Fatalw("Failed to limit the download bandwidth", zap.Error(err))
+			}
+		}
+	}
 	watcher := agent.NewWatcher(*configDir, *modelDir, logger)
+	statusTracker := agent.NewStatusTracker()
 	logger.Info("Starting puller")
-	agent.StartPullerAndProcessModels(&downloader, watcher.ModelEvents, logger)
+	endpoint := *modelRepositoryEndpoint
+	if endpoint == "" {
+		repositoryPort := *componentPort
+		if agent.RepositoryType(*modelRepository) == agent.RepositoryTorchServe {
+			repositoryPort = agent.DefaultTorchServeManagementPort
+		}
+		endpoint = "http://" + net.JoinHostPort("localhost", strconv.Itoa(repositoryPort))
+	}
+	repository, err := agent.NewModelRepository(agent.RepositoryOptions{
+		Type:        agent.RepositoryType(*modelRepository),
+		Endpoint:    endpoint,
+		ConfigFile:  *modelRepositoryConfig,
+		LoadTimeout: *modelLoadTimeout,
+	}, *modelDir, nil)
+	if err != nil {
+		logger.Fatalw("Failed to create the model repository", zap.Error(err))
+	}
+	retryPolicy := agent.RetryPolicy{
+		MaxAttempts:    *modelMaxAttempts,
+		InitialBackoff: *modelInitialBackoff,
+		MaxBackoff:     *modelMaxBackoff,
+	}
+	agent.StartPullerAndProcessModels(&downloader, repository, watcher.ModelEvents, retryPolicy, statusTracker, logger)
 	go watcher.Start()
+	return statusTracker
+}
+
+// parseBandwidthLimits parses the limits in bytes per second keyed by the storage protocol, e.g.
+// s3=100Mi,gs=50Mi.
+func parseBandwidthLimits(value string) (map[storage.Protocol]int64, error) {
+	limits := make(map[storage.Protocol]int64)
+	for _, entry := range strings.Split(value, ",") {
+		name, limit, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid bandwidth limit %q, expected <protocol>=<bytes per second>", entry)
+		}
+		protocol := storage.Protocol(strings.TrimSuffix(name, "://") + "://")
+		if !slices.Contains(storage.SupportedProtocols, protocol) && protocol != storage.AzureBlob {
+			return nil, fmt.Errorf("unsupported protocol %q in bandwidth limit, supported protocols are %v and %s", name, storage.GetAllProtocol(), storage.AzureBlob)
+		}
+		quantity, err := resource.ParseQuantity(limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bandwidth limit %q: %w", entry, err)
+		}
+		if quantity.Value() <= 0 {
+			return nil, fmt.Errorf("the bandwidth limit of %s must be positive", name)
+		}
+		limits[protocol] = quantity.Value()
+	}
+	return limits, nil
 }
 
 func buildProbe(logger *zap.SugaredLogger, probeJSON string) *readiness.Probe {
@@ -333,13 +573,13 @@ func buildServer(ctx context.Context, port string, userPort int, loggerArgs *log
 	var composedHandler http.Handler = httpProxy
 
 	if batcherArgs != nil {
-		composedHandler = batcher.New(batcherArgs.maxBatchSize, batcherArgs.maxLatency, composedHandler, logging)
+		composedHandler = batcher.New(batcherArgs.maxBatchSize, batcherArgs.maxLatency, batcherArgs.partialFailure, composedHandler, logging)
 	}
 	if loggerArgs != nil {
 		composedHandler = kfslogger.New(loggerArgs.logUrl, loggerArgs.sourceUrl, loggerArgs.loggerType,
-			loggerArgs.inferenceService, loggerArgs.namespace, loggerArgs.endpoint, loggerArgs.component, composedHandler)
+			loggerArgs.inferenceService, loggerArgs.namespace, loggerArgs.endpoint, loggerArgs.component,
+			loggerArgs.filter, loggerArgs.redactor, loggerArgs.maxPayloadSize, composedHandler)
 	}
-
 	composedHandler = queue.ForwardedShimHandler(composedHandler)
 
 	drainer := &pkghandler.Drainer{
@@ -352,3 +592,12 @@ func buildServer(ctx context.Context, port string, userPort int, loggerArgs *log
 	composedHandler = drainer
 	return pkgnet.NewServer(":"+port, composedHandler), drainer.Drain
 }
+
+// buildStatusServer serves the model status endpoint on its own port, the agent port receives the
+// inference traffic which must not reach the model load errors nor retry the models.
+func buildStatusServer(port string, statusTracker *agent.StatusTracker) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(constants.AgentModelStatusPath, statusTracker)
+	mux.Handle(constants.AgentModelStatusPath+"/", statusTracker)
+	return pkgnet.NewServer(":"+port, mux)
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
 
@@ -26,6 +29,7 @@ import (
 	istio_networking "istio.io/api/networking/v1beta1"
 	istioclientv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -40,6 +44,7 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	"github.com/kserve/kserve/pkg/agent/storage"
 	"github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
 	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
 	"github.com/kserve/kserve/pkg/constants"
@@ -66,6 +71,7 @@ type Options struct {
 	webhookPort          int
 	enableLeaderElection bool
 	probeAddr            string
+	reportModelState     bool
 	zapOpts              zap.Options
 }
 
@@ -76,6 +82,7 @@ func DefaultOptions() Options {
 		webhookPort:          9443,
 		enableLeaderElection: false,
 		probeAddr:            ":8081",
+		reportModelState:     false,
 		zapOpts:              zap.Options{},
 	}
 }
@@ -89,6 +96,9 @@ func GetOptions() Options {
 		"Enable leader election for kserve controller manager. "+
 			"Enabling this will ensure there is only one active kserve controller manager.")
 	flag.StringVar(&opts.probeAddr, "health-probe-addr", opts.probeAddr, "The address the probe endpoint binds to.")
+	flag.BoolVar(&opts.reportModelState, "enable-model-state-reporting", opts.reportModelState,
+		"Poll the model agents of the multi-model predictor pods for the state of the TrainedModels, which are Ready once loaded. "+
+			"The controller must reach the pods on the agent status port over plain HTTP, which NetworkPolicies or a strict mTLS service mesh may prevent.")
 	opts.zapOpts.BindFlags(flag.CommandLine)
 	flag.Parse()
 	return opts
@@ -206,6 +216,7 @@ func main() {
 		Scheme:                mgr.GetScheme(),
 		Recorder:              eventBroadcaster.NewRecorder(mgr.GetScheme(), v1.EventSource{Component: "v1beta1Controllers"}),
 		ModelConfigReconciler: modelconfig.NewModelConfigReconciler(mgr.GetClient(), clientSet, mgr.GetScheme()),
+		ReportModelState:      options.reportModelState,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "v1beta1Controllers", "TrainedModel")
 		os.Exit(1)
@@ -244,6 +255,11 @@ func main() {
 		Handler: &servingruntime.ServingRuntimeValidator{Client: mgr.GetClient(), Decoder: admission.NewDecoder(mgr.GetScheme())},
 	})
 
+	// the TrainedModels of the protocols of the agent storage plugins are accepted by the webhook
+	if err := registerAgentStoragePlugins(clientSet); err != nil {
+		setupLog.Error(err, "unable to register the agent storage plugins")
+		os.Exit(1)
+	}
 	if err = ctrl.NewWebhookManagedBy(mgr).
 		For(&v1alpha1.TrainedModel{}).
 		Complete(); err != nil {
@@ -285,3 +301,18 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// registerAgentStoragePlugins registers the custom storage protocols of the agent config.
+func registerAgentStoragePlugins(clientSet kubernetes.Interface) error {
+	configMap, err := clientSet.CoreV1().ConfigMaps(constants.KServeNamespace).Get(context.TODO(), constants.InferenceServiceConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	agentConfig := &pod.AgentConfig{}
+	if agent, ok := configMap.Data[constants.AgentConfigMapKeyName]; ok {
+		if err := json.Unmarshal([]byte(agent), agentConfig); err != nil {
+			return fmt.Errorf("unable to parse agent config json: %w", err)
+		}
+	}
+	return storage.RegisterPlugins(agentConfig.StoragePlugins)
+}
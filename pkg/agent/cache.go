@@ -0,0 +1,364 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kserve/kserve/pkg/agent/storage"
+	"github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+	"go.uber.org/zap"
+)
+
+// DefaultModelCacheDirName is the cache directory in the model directory. Directories starting with
+// a dot are not synced as models.
+const DefaultModelCacheDirName = ".cache"
+
+// ModelCache is a content-addressed cache of the downloaded model artifacts keyed by storage URI
+// and digest, so that models with the same artifact share one download. The artifacts of the
+// models which are no longer loaded are evicted, least recently used first, once the cache grows
+// over MaxSize bytes. A cached artifact is never downloaded again, so only the artifacts pinned by
+// a digest or a version are cached, see cacheable.
+type ModelCache struct {
+	Dir string
+	// MaxSize is the size budget of the cache, it is unbounded if MaxSize is 0 or less
+	MaxSize int64
+	logger  *zap.SugaredLogger
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	// models maps the models to the key of their artifact
+	models map[string]string
+}
+
+// cacheEntry is the artifact downloaded into <cache dir>/<key>, its metadata is stored in
+// <cache dir>/<key>.json once the download completes.
+type cacheEntry struct {
+	StorageURI string    `json:"storageUri"`
+	Digest     string    `json:"digest,omitempty"`
+	Size       int64     `json:"size"`
+	LastUsed   time.Time `json:"lastUsed"`
+	key        string
+	complete   bool
+	refs       map[string]bool
+	// download is held while the artifact is downloaded
+	download sync.Mutex
+}
+
+// NewModelCache opens the cache in dir. Artifacts which were partially downloaded before a
+// restart are removed, unless their download can be resumed.
+func NewModelCache(dir string, maxSize int64, logger *zap.SugaredLogger) (*ModelCache, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil { // #nosec G301
+		return nil, err
+	}
+	c := &ModelCache{
+		Dir:     dir,
+		MaxSize: maxSize,
+		logger:  logger,
+		entries: make(map[string]*cacheEntry),
+		models:  make(map[string]string),
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		entry := &cacheEntry{}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err == nil {
+			err = json.Unmarshal(data, entry)
+		}
+		if err != nil {
+			logger.Errorf("Ignoring invalid cache metadata %s: %v", file.Name(), err)
+			continue
+		}
+		entry.key = strings.TrimSuffix(file.Name(), ".json")
+		entry.complete = true
+		entry.refs = make(map[string]bool)
+		c.entries[entry.key] = entry
+	}
+	for _, file := range files {
+		if _, ok := c.entries[file.Name()]; file.IsDir() && !ok {
+			if hasPartsJournal(filepath.Join(dir, file.Name())) {
+				logger.Infof("Keeping partially downloaded artifact %s in the model cache to resume its download", file.Name())
+				continue
+			}
+			logger.Infof("Removing partially downloaded artifact %s from the model cache", file.Name())
+			if err := os.RemoveAll(filepath.Join(dir, file.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	logger.Infof("Opened model cache %s with %d artifacts of %d bytes", dir, len(c.entries), c.size())
+	return c, nil
+}
+
+// cacheKey identifies the artifact of a model by its storage URI and expected digests.
+func cacheKey(modelSpec *v1alpha1.ModelSpec) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", modelSpec.StorageURI, modelSpec.Digest)
+	for _, fileName := range sortedFileNames(modelSpec.FileDigests) {
+		fmt.Fprintf(h, "%s=%s\n", fileName, modelSpec.FileDigests[fileName])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheable returns true if the artifact of the model is pinned by a digest, an object version or
+// an image digest. The artifacts which are not pinned may change at the same storage uri, they are
+// downloaded for every model instead of being served stale from the cache.
+func cacheable(modelSpec *v1alpha1.ModelSpec) bool {
+	if modelSpec.Digest != "" || len(modelSpec.FileDigests) > 0 {
+		return true
+	}
+	protocol, err := extractProtocol(modelSpec.StorageURI)
+	if err != nil {
+		return false
+	}
+	if protocol == storage.OCI {
+		return strings.Contains(modelSpec.StorageURI, "@"+storage.DigestAlgorithmSHA256+":")
+	}
+	_, params, err := storage.ParseStorageURI(protocol, modelSpec.StorageURI)
+	return err == nil && (params.VersionId != "" || params.Generation != 0)
+}
+
+func sortedFileNames(fileDigests map[string]string) []string {
+	fileNames := make([]string, 0, len(fileDigests))
+	for fileName := range fileDigests {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	return fileNames
+}
+
+// Acquire returns the directory of the artifact of the model, calling download to download it
+// into <cache dir>/<key> unless it is cached. The artifact is not evicted until the model is
+// released.
+func (c *ModelCache) Acquire(modelName string, modelSpec *v1alpha1.ModelSpec, download func(cacheDir string, key string) error) (string, error) {
+	key := cacheKey(modelSpec)
+	c.mu.Lock()
+	if previous, ok := c.models[modelName]; ok && previous != key {
+		c.releaseLocked(modelName)
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cacheEntry{
+			StorageURI: modelSpec.StorageURI,
+			Digest:     modelSpec.Digest,
+			key:        key,
+			refs:       make(map[string]bool),
+		}
+		c.entries[key] = entry
+	}
+	entry.refs[modelName] = true
+	c.models[modelName] = key
+	c.mu.Unlock()
+
+	entry.download.Lock()
+	defer entry.download.Unlock()
+	entryDir := filepath.Join(c.Dir, key)
+	if !entry.complete {
+		c.logger.Infof("Downloading %s into the model cache as %s", modelSpec.StorageURI, key)
+		if err := download(c.Dir, key); err != nil {
+			c.release(modelName)
+			// the next attempt resumes the download if it can
+			if !hasPartsJournal(entryDir) {
+				_ = os.RemoveAll(entryDir)
+			}
+			return "", err
+		}
+		size, err := dirSize(entryDir)
+		if err != nil {
+			c.release(modelName)
+			return "", err
+		}
+		entry.Size = size
+		entry.complete = true
+	} else {
+		c.logger.Infof("Using the cached artifact %s for model %s", key, modelName)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.LastUsed = time.Now()
+	if err := c.writeMetadata(entry); err != nil {
+		return "", err
+	}
+	c.evictLocked()
+	return entryDir, nil
+}
+
+// Release marks the artifact of the model as unused by it, the artifact may then be evicted.
+func (c *ModelCache) Release(modelName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.releaseLocked(modelName)
+	c.evictLocked()
+}
+
+// Rename moves the reference of a model to its artifact to another name, e.g. once the staging
+// directory of a model update is swapped in.
+func (c *ModelCache) Rename(from string, to string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, ok := c.models[from]
+	if !ok {
+		return
+	}
+	delete(c.models, from)
+	c.models[to] = key
+	if entry, ok := c.entries[key]; ok {
+		delete(entry.refs, from)
+		entry.refs[to] = true
+	}
+}
+
+// release drops the reference of the model to its artifact without evicting it.
+func (c *ModelCache) release(modelName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.releaseLocked(modelName)
+}
+
+func (c *ModelCache) releaseLocked(modelName string) {
+	key, ok := c.models[modelName]
+	if !ok {
+		return
+	}
+	delete(c.models, modelName)
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(entry.refs, modelName)
+	if entry.complete {
+		entry.LastUsed = time.Now()
+		if err := c.writeMetadata(entry); err != nil {
+			c.logger.Errorf("Failed to update the model cache metadata of %s: %v", key, err)
+		}
+	} else if len(entry.refs) == 0 {
+		delete(c.entries, key)
+	}
+}
+
+// evictLocked removes the least recently used artifacts which are not used by any model until
+// the cache fits its size budget.
+func (c *ModelCache) evictLocked() {
+	if c.MaxSize <= 0 {
+		return
+	}
+	size := c.size()
+	if size <= c.MaxSize {
+		return
+	}
+	var unused []*cacheEntry
+	for _, entry := range c.entries {
+		if entry.complete && len(entry.refs) == 0 {
+			unused = append(unused, entry)
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].LastUsed.Before(unused[j].LastUsed)
+	})
+	for _, entry := range unused {
+		if size <= c.MaxSize {
+			break
+		}
+		c.logger.Infof("Evicting %s (%s) of %d bytes from the model cache", entry.key, entry.StorageURI, entry.Size)
+		if err := os.Remove(filepath.Join(c.Dir, entry.key+".json")); err != nil && !os.IsNotExist(err) {
+			c.logger.Errorf("Failed to evict %s from the model cache: %v", entry.key, err)
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(c.Dir, entry.key)); err != nil {
+			c.logger.Errorf("Failed to evict %s from the model cache: %v", entry.key, err)
+		}
+		delete(c.entries, entry.key)
+		size -= entry.Size
+	}
+	if size > c.MaxSize {
+		c.logger.Infof("Model cache size %d bytes exceeds its budget of %d bytes, its artifacts are in use", size, c.MaxSize)
+	}
+}
+
+func (c *ModelCache) size() int64 {
+	var size int64
+	for _, entry := range c.entries {
+		size += entry.Size
+	}
+	return size
+}
+
+func (c *ModelCache) writeMetadata(entry *cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.Dir, entry.key+".json"), data, 0644) // #nosec G306
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// linkModel links the files of the cached artifact into the model directory, which keeps its own
+// success file. The links are relative so that they resolve in the model server container too.
+func linkModel(artifactDir string, modelPath string) error {
+	if err := os.MkdirAll(modelPath, 0777); err != nil { // #nosec G301
+		return err
+	}
+	files, err := os.ReadDir(artifactDir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		link := filepath.Join(modelPath, file.Name())
+		target, err := filepath.Rel(modelPath, filepath.Join(artifactDir, file.Name()))
+		if err != nil {
+			return err
+		}
+		if current, err := os.Readlink(link); err == nil && current == target {
+			continue
+		}
+		if err := os.RemoveAll(link); err != nil {
+			return err
+		}
+		if err := os.Symlink(target, link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/kserve/kserve/pkg/agent/storage"
+	"github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("ModelCache", func() {
+	const modelContents = "0123456789"
+	var modelDir string
+	var downloads atomic.Int32
+	var ts *httptest.Server
+	var sugar *zap.SugaredLogger
+	BeforeEach(func() {
+		modelDir = GinkgoT().TempDir()
+		downloads.Store(0)
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			downloads.Add(1)
+			fmt.Fprint(w, modelContents)
+		}))
+		zapLogger, _ := zap.NewProduction()
+		sugar = zapLogger.Sugar()
+	})
+	AfterEach(func() {
+		ts.Close()
+	})
+	newDownloader := func(maxSize int64) *Downloader {
+		cache, err := NewModelCache(filepath.Join(modelDir, DefaultModelCacheDirName), maxSize, sugar)
+		Expect(err).To(BeNil())
+		return &Downloader{
+			ModelDir: modelDir,
+			Providers: map[storage.Protocol]storage.Provider{
+				storage.HTTP: &storage.HTTPSProvider{Client: ts.Client()},
+			},
+			Cache:  cache,
+			Logger: sugar,
+		}
+	}
+	// the artifacts are pinned by their digest so that they are cached
+	modelDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(modelContents)))
+	modelSpec := func(fileName string) *v1alpha1.ModelSpec {
+		return &v1alpha1.ModelSpec{StorageURI: ts.URL + "/" + fileName, Framework: "sklearn", Digest: modelDigest}
+	}
+
+	It("should download an artifact shared by several models once", func() {
+		downloader := newDownloader(0)
+		Expect(downloader.DownloadModel("model1", modelSpec("model.joblib"))).To(Succeed())
+		Expect(downloader.DownloadModel("model2", modelSpec("model.joblib"))).To(Succeed())
+		Expect(downloads.Load()).To(Equal(int32(1)))
+		for _, modelName := range []string{"model1", "model2"} {
+			Expect(os.ReadFile(filepath.Join(modelDir, modelName, "model.joblib"))).To(BeEquivalentTo(modelContents))
+		}
+
+		// The model directories are synced but not the cache
+		models, err := SyncModelDir(modelDir, sugar)
+		Expect(err).To(BeNil())
+		Expect(models).To(HaveLen(2))
+		Expect(models).To(HaveKey("model1"))
+		Expect(models).To(HaveKey("model2"))
+	})
+
+	It("should download the artifacts which are not pinned for every model", func() {
+		downloader := newDownloader(0)
+		spec := &v1alpha1.ModelSpec{StorageURI: ts.URL + "/model.joblib", Framework: "sklearn"}
+		Expect(downloader.DownloadModel("model1", spec)).To(Succeed())
+		Expect(downloader.DownloadModel("model2", spec)).To(Succeed())
+		Expect(downloads.Load()).To(Equal(int32(2)))
+		Expect(downloader.Cache.entries).To(BeEmpty())
+		info, err := os.Lstat(filepath.Join(modelDir, "model1", "model.joblib"))
+		Expect(err).To(BeNil())
+		Expect(info.Mode().IsRegular()).To(BeTrue())
+
+		Expect(cacheable(&v1alpha1.ModelSpec{StorageURI: "s3://models/model.joblib?versionId=3HL4kqtJ"})).To(BeTrue())
+		Expect(cacheable(&v1alpha1.ModelSpec{StorageURI: "gs://models/model.joblib?generation=1700000000000000"})).To(BeTrue())
+		Expect(cacheable(&v1alpha1.ModelSpec{StorageURI: "oci://registry.example.com/models/sklearn@" + modelDigest})).To(BeTrue())
+		Expect(cacheable(&v1alpha1.ModelSpec{StorageURI: "oci://registry.example.com/models/sklearn:v1"})).To(BeFalse())
+		Expect(cacheable(&v1alpha1.ModelSpec{StorageURI: "s3://models/model.joblib"})).To(BeFalse())
+	})
+
+	It("should release the artifact of a model whose download can not be sized", func() {
+		downloader := newDownloader(0)
+		_, err := downloader.Cache.Acquire("model1", modelSpec("model.joblib"), func(string, string) error {
+			// nothing is downloaded
+			return nil
+		})
+		Expect(err).NotTo(BeNil())
+		Expect(downloader.Cache.models).To(BeEmpty())
+		Expect(downloader.Cache.entries).To(BeEmpty())
+	})
+
+	It("should evict the least recently used artifacts of unloaded models over the size budget", func() {
+		downloader := newDownloader(2 * int64(len(modelContents)))
+		Expect(downloader.DownloadModel("model1", modelSpec("model1.joblib"))).To(Succeed())
+		Expect(downloader.DownloadModel("model2", modelSpec("model2.joblib"))).To(Succeed())
+		Expect(downloader.RemoveModel("model1")).To(Succeed())
+		Expect(downloader.RemoveModel("model2")).To(Succeed())
+		Expect(downloader.Cache.entries).To(HaveLen(2))
+
+		// model1 is the least recently used artifact
+		Expect(downloader.DownloadModel("model3", modelSpec("model3.joblib"))).To(Succeed())
+		Expect(downloader.Cache.entries).To(HaveLen(2))
+		Expect(downloader.Cache.entries).NotTo(HaveKey(cacheKey(modelSpec("model1.joblib"))))
+		Expect(filepath.Join(downloader.Cache.Dir, cacheKey(modelSpec("model1.joblib")))).NotTo(BeAnExistingFile())
+
+		// model2 is cached and not downloaded again
+		Expect(downloader.DownloadModel("model2", modelSpec("model2.joblib"))).To(Succeed())
+		Expect(downloads.Load()).To(Equal(int32(3)))
+	})
+
+	It("should not evict the artifacts of loaded models", func() {
+		downloader := newDownloader(1)
+		Expect(downloader.DownloadModel("model1", modelSpec("model1.joblib"))).To(Succeed())
+		Expect(downloader.DownloadModel("model2", modelSpec("model2.joblib"))).To(Succeed())
+		Expect(downloader.Cache.entries).To(HaveLen(2))
+		Expect(os.ReadFile(filepath.Join(modelDir, "model1", "model1.joblib"))).To(BeEquivalentTo(modelContents))
+	})
+
+	It("should move the reference of an updated model to its new artifact", func() {
+		downloader := newDownloader(1)
+		Expect(downloader.DownloadModel("model1", modelSpec("v1.joblib"))).To(Succeed())
+		Expect(downloader.UpdateModel("model1", modelSpec("v2.joblib"))).To(Succeed())
+		Expect(os.ReadFile(filepath.Join(modelDir, "model1", "v2.joblib"))).To(BeEquivalentTo(modelContents))
+		Expect(filepath.Join(modelDir, "model1", "v1.joblib")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(modelDir, stagingDirName("model1"))).NotTo(BeAnExistingFile())
+		// The previous artifact is no longer referenced and evicted
+		Expect(downloader.Cache.entries).To(HaveLen(1))
+		Expect(downloader.Cache.models).To(Equal(map[string]string{"model1": cacheKey(modelSpec("v2.joblib"))}))
+	})
+
+	It("should reload the cache and remove the partial downloads which can not be resumed on restart", func() {
+		downloader := newDownloader(0)
+		Expect(downloader.DownloadModel("model1", modelSpec("model.joblib"))).To(Succeed())
+		partialDir := filepath.Join(downloader.Cache.Dir, "partial")
+		Expect(os.MkdirAll(partialDir, 0777)).To(Succeed())
+
+		resumableDir := filepath.Join(downloader.Cache.Dir, "resumable")
+		Expect(os.MkdirAll(resumableDir, 0777)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(resumableDir, "model.joblib"+storage.PartsFileSuffix), []byte("{}"), 0644)).To(Succeed())
+
+		downloader = newDownloader(0)
+		Expect(downloader.Cache.entries).To(HaveLen(1))
+		Expect(partialDir).NotTo(BeAnExistingFile())
+		// the download of the artifacts with a parts journal is resumed
+		Expect(resumableDir).To(BeADirectory())
+		Expect(downloader.DownloadModel("model2", modelSpec("model.joblib"))).To(Succeed())
+		Expect(downloads.Load()).To(Equal(int32(1)))
+	})
+})
@@ -32,46 +32,63 @@ import (
 	"go.uber.org/zap"
 )
 
+// successRecord is the content of the success file of a downloaded model. It is the model spec,
+// along with the digest the model was verified against and the model version its model registry
+// uri resolved to if any.
+type successRecord struct {
+	v1alpha1.ModelSpec
+	VerifiedDigest     string `json:"verifiedDigest,omitempty"`
+	ResolvedStorageURI string `json:"resolvedStorageUri,omitempty"`
+	ResolvedVersion    string `json:"resolvedVersion,omitempty"`
+}
+
 type Downloader struct {
 	ModelDir  string
 	mu        sync.Mutex
 	Providers map[storage.Protocol]storage.Provider
-	Logger    *zap.SugaredLogger
+	// Cache is optional, the models are downloaded into their directory if it is nil
+	Cache *ModelCache
+	// Scheduler is optional, the number of concurrent downloads is unbounded if it is nil
+	Scheduler *DownloadScheduler
+	// Resolver is optional, the models of model registry uris fail to download if it is nil
+	Resolver storage.ModelResolver
+	Logger   *zap.SugaredLogger
 }
 
 func (d *Downloader) DownloadModel(modelName string, modelSpec *v1alpha1.ModelSpec) error {
 	if modelSpec != nil {
-		sha256 := storage.AsSha256(modelSpec)
 		successFile := filepath.Join(d.ModelDir, modelName,
-			fmt.Sprintf("SUCCESS.%s", sha256))
+			fmt.Sprintf("SUCCESS.%s", specHash(modelSpec)))
 		d.Logger.Infof("Downloading %s to model dir %s", modelSpec.StorageURI, d.ModelDir)
 		// Download if the event there is a success file and the event is one which we wish to Download
 		_, err := os.Stat(successFile)
 		if os.IsNotExist(err) {
-			if err := d.download(modelName, modelSpec.StorageURI); err != nil {
-				return errors.Wrapf(err, "failed to download model")
-			}
-			file, createErr := storage.Create(successFile)
-			if createErr != nil {
-				return errors.Wrapf(createErr, "failed to create success file")
-			}
-			defer func(file *os.File) {
-				err := file.Close()
-				if err != nil {
-					d.Logger.Errorf("Failed to close created file %v", err)
-				}
-			}(file)
-			encodedJson, err := json.Marshal(modelSpec)
+			resolvedSpec, resolved, err := d.resolve(modelSpec)
 			if err != nil {
-				return errors.Wrapf(createErr, "failed to encode model spec")
+				return err
 			}
-			err = os.WriteFile(successFile, encodedJson, 0644) //#nosec
-			if err != nil {
-				return errors.Wrapf(createErr, "failed to write the success file")
+			checksum := modelChecksum(modelSpec)
+			if err := d.fetch(modelName, resolvedSpec, checksum); err != nil {
+				return errors.Wrapf(err, "failed to download model")
+			}
+			if err := d.writeSuccessFile(successFile, modelSpec, checksum, resolved); err != nil {
+				return err
 			}
 			d.Logger.Infof("Creating successFile %s", successFile)
 		} else if err == nil {
 			d.Logger.Infof("Model successFile exists already for %s", modelName)
+			if d.Cache != nil {
+				// keep the cached artifact of the model from being evicted, the model version its
+				// model registry uri resolved to is kept until the model spec changes
+				if resolved := d.ResolvedModel(modelName, modelSpec); resolved != nil {
+					modelSpec = withStorageURI(modelSpec, resolved.StorageURI)
+				}
+				if cacheable(modelSpec) {
+					if err := d.fetch(modelName, modelSpec, modelChecksum(modelSpec)); err != nil {
+						return errors.Wrapf(err, "failed to download model")
+					}
+				}
+			}
 		} else {
 			d.Logger.Errorf("Model successFile error %v", err)
 		}
@@ -79,7 +96,205 @@ func (d *Downloader) DownloadModel(modelName string, modelSpec *v1alpha1.ModelSp
 	return nil
 }
 
-func (d *Downloader) download(modelName string, storageUri string) error {
+// UpdateModel downloads the new version of an existing model into a staging directory next to the
+// model directory, and then swaps the two directories so that the model files are never missing
+// while the model is updated.
+func (d *Downloader) UpdateModel(modelName string, modelSpec *v1alpha1.ModelSpec) error {
+	modelPath := filepath.Join(d.ModelDir, modelName)
+	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
+		return d.DownloadModel(modelName, modelSpec)
+	}
+	successFile := filepath.Join(modelPath, fmt.Sprintf("SUCCESS.%s", specHash(modelSpec)))
+	if _, err := os.Stat(successFile); err == nil {
+		// The new version is in place already, e.g. when the reload of the model is retried
+		d.Logger.Infof("Model %s is up to date", modelName)
+		return nil
+	}
+	stagingName := stagingDirName(modelName)
+	stagingPath := filepath.Join(d.ModelDir, stagingName)
+	// A staging directory left by an interrupted update is stale
+	if err := os.RemoveAll(stagingPath); err != nil {
+		return errors.Wrapf(err, "failed to clean the staging dir")
+	}
+	resolvedSpec, resolved, err := d.resolve(modelSpec)
+	if err != nil {
+		return err
+	}
+	d.Logger.Infof("Downloading %s to staging dir %s", resolvedSpec.StorageURI, stagingPath)
+	checksum := modelChecksum(modelSpec)
+	if err := d.fetch(stagingName, resolvedSpec, checksum); err != nil {
+		d.removeStaging(stagingName)
+		return errors.Wrapf(err, "failed to download model")
+	}
+	stagingSuccessFile := filepath.Join(stagingPath, filepath.Base(successFile))
+	if err := d.writeSuccessFile(stagingSuccessFile, modelSpec, checksum, resolved); err != nil {
+		d.removeStaging(stagingName)
+		return err
+	}
+	if err := exchangeDirs(stagingPath, modelPath); err != nil {
+		d.removeStaging(stagingName)
+		return errors.Wrapf(err, "failed to swap in the new model version")
+	}
+	if d.Cache != nil {
+		d.Cache.Release(modelName)
+		d.Cache.Rename(stagingName, modelName)
+	}
+	d.Logger.Infof("Swapped in the new version of model %s", modelName)
+	// The staging directory holds the previous version now
+	if err := os.RemoveAll(stagingPath); err != nil {
+		d.Logger.Errorf("Failed to remove the previous version of model %s: %v", modelName, err)
+	}
+	return nil
+}
+
+// resolve returns the spec of the model version the model registry uri of the model resolves to,
+// the spec of the models of the other uris is returned unchanged.
+func (d *Downloader) resolve(modelSpec *v1alpha1.ModelSpec) (*v1alpha1.ModelSpec, *storage.ResolvedModel, error) {
+	if !storage.IsModelRegistryURI(modelSpec.StorageURI) {
+		return modelSpec, nil, nil
+	}
+	if d.Resolver == nil {
+		return nil, nil, fmt.Errorf("unable to resolve %s, no model registry is configured", modelSpec.StorageURI)
+	}
+	resolved, err := d.Resolver.Resolve(modelSpec.StorageURI)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to resolve the model registry uri")
+	}
+	d.Logger.Infof("Resolved %s to version %s at %s", modelSpec.StorageURI, resolved.Version, resolved.StorageURI)
+	return withStorageURI(modelSpec, resolved.StorageURI), resolved, nil
+}
+
+func withStorageURI(modelSpec *v1alpha1.ModelSpec, storageUri string) *v1alpha1.ModelSpec {
+	spec := *modelSpec
+	spec.StorageURI = storageUri
+	return &spec
+}
+
+// ResolvedModel returns the model version the model registry uri of the downloaded model resolved
+// to, or nil if the model is not downloaded or its uri is not a model registry uri.
+func (d *Downloader) ResolvedModel(modelName string, modelSpec *v1alpha1.ModelSpec) *storage.ResolvedModel {
+	successFile := filepath.Join(d.ModelDir, modelName, fmt.Sprintf("SUCCESS.%s", specHash(modelSpec)))
+	record, err := readSuccessRecord(successFile)
+	if err != nil || record.ResolvedStorageURI == "" {
+		return nil
+	}
+	return &storage.ResolvedModel{StorageURI: record.ResolvedStorageURI, Version: record.ResolvedVersion}
+}
+
+func readSuccessRecord(successFile string) (*successRecord, error) {
+	content, err := os.ReadFile(successFile)
+	if err != nil {
+		return nil, err
+	}
+	record := &successRecord{}
+	if err := json.Unmarshal(content, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// specHash identifies the version of the model in the name of its success file. The priority of
+// the model only affects the download order, so it is ignored.
+func specHash(modelSpec *v1alpha1.ModelSpec) string {
+	spec := *modelSpec
+	spec.Priority = 0
+	return storage.AsSha256(&spec)
+}
+
+// stagingDirName is the directory the new version of a model is downloaded into. It is hidden so
+// that it is not synced as a model, and it is next to the model directory so that the relative
+// links to the model cache stay valid once it is swapped in.
+func stagingDirName(modelName string) string {
+	return "." + modelName + stagingDirSuffix
+}
+
+const (
+	stagingDirSuffix = ".staging"
+	// exchangeDirSuffix is the suffix of the staging directory the model directory is moved to
+	// while the two are swapped, on the platforms where they are not exchanged atomically
+	exchangeDirSuffix = ".exchange"
+)
+
+func (d *Downloader) removeStaging(stagingName string) {
+	if d.Cache != nil {
+		d.Cache.Release(stagingName)
+	}
+	if err := os.RemoveAll(filepath.Join(d.ModelDir, stagingName)); err != nil {
+		d.Logger.Errorf("Failed to remove staging dir %s: %v", stagingName, err)
+	}
+}
+
+func (d *Downloader) writeSuccessFile(successFile string, modelSpec *v1alpha1.ModelSpec, checksum *storage.Checksum,
+	resolved *storage.ResolvedModel) error {
+	file, createErr := storage.Create(successFile)
+	if createErr != nil {
+		return errors.Wrapf(createErr, "failed to create success file")
+	}
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+			d.Logger.Errorf("Failed to close created file %v", err)
+		}
+	}(file)
+	record := successRecord{
+		ModelSpec:      *modelSpec,
+		VerifiedDigest: checksum.VerifiedDigest(),
+	}
+	if resolved != nil {
+		record.ResolvedStorageURI = resolved.StorageURI
+		record.ResolvedVersion = resolved.Version
+	}
+	encodedJson, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode model spec")
+	}
+	err = os.WriteFile(successFile, encodedJson, 0644) //#nosec
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the success file")
+	}
+	return nil
+}
+
+// modelChecksum returns the expected digests of the model, or nil if there are none.
+func modelChecksum(modelSpec *v1alpha1.ModelSpec) *storage.Checksum {
+	if modelSpec.Digest == "" && len(modelSpec.FileDigests) == 0 {
+		return nil
+	}
+	return &storage.Checksum{
+		Digest: modelSpec.Digest,
+		Files:  modelSpec.FileDigests,
+	}
+}
+
+// fetch downloads the model into dirName in the model directory, or links it from the cache if its
+// artifact is cacheable.
+func (d *Downloader) fetch(dirName string, modelSpec *v1alpha1.ModelSpec, checksum *storage.Checksum) error {
+	if d.Cache == nil || !cacheable(modelSpec) {
+		return d.download(d.ModelDir, dirName, modelSpec, checksum)
+	}
+	artifactDir, err := d.Cache.Acquire(dirName, modelSpec, func(cacheDir string, key string) error {
+		return d.download(cacheDir, key, modelSpec, checksum)
+	})
+	if err != nil {
+		return err
+	}
+	return linkModel(artifactDir, filepath.Join(d.ModelDir, dirName))
+}
+
+// RemoveModel removes the model directory and releases its cached artifact.
+func (d *Downloader) RemoveModel(modelName string) error {
+	// The directory is already gone when a failed unload is retried
+	if err := storage.RemoveDir(filepath.Join(d.ModelDir, modelName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if d.Cache != nil {
+		d.Cache.Release(modelName)
+	}
+	return nil
+}
+
+func (d *Downloader) download(modelDir string, modelName string, modelSpec *v1alpha1.ModelSpec, checksum *storage.Checksum) error {
+	storageUri := modelSpec.StorageURI
 	protocol, err := extractProtocol(storageUri)
 	if err != nil {
 		return errors.Wrapf(err, "unsupported protocol")
@@ -90,7 +305,11 @@ func (d *Downloader) download(modelName string, storageUri string) error {
 	if err != nil {
 		return errors.Wrapf(err, "unable to create or get provider for protocol %s", protocol)
 	}
-	if err := provider.DownloadModel(d.ModelDir, modelName, storageUri); err != nil {
+	if d.Scheduler != nil {
+		release := d.Scheduler.Acquire(modelSpec)
+		defer release()
+	}
+	if err := provider.DownloadModel(modelDir, modelName, storageUri, checksum); err != nil {
 		return errors.Wrapf(err, "failed to download model")
 	}
 	return nil
@@ -113,8 +332,18 @@ func extractProtocol(storageURI string) (storage.Protocol, error) {
 		return "", fmt.Errorf("there is no protocol specified for the storageUri")
 	}
 
-	for _, prefix := range storage.SupportedProtocols {
+	// Azure Blob Storage URIs are https URIs, so they are matched first. The presigned SAS URLs are
+	// left to the https provider.
+	if storage.AzureBlobURIRegex.MatchString(storageURI) {
+		return storage.AzureBlob, nil
+	}
+	for _, prefix := range storage.Protocols() {
 		if strings.HasPrefix(storageURI, string(prefix)) {
+			// the query parameters are parsed uniformly, so that the unsupported ones fail the model
+			// before it is downloaded
+			if _, _, err := storage.ParseStorageURI(prefix, storageURI); err != nil {
+				return "", err
+			}
 			return prefix, nil
 		}
 	}
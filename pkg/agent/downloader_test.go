@@ -17,8 +17,10 @@ limitations under the License.
 package agent
 
 import (
+	"fmt"
 	logger "log"
 	"os"
+	"path/filepath"
 
 	"github.com/kserve/kserve/pkg/agent/mocks"
 	"github.com/kserve/kserve/pkg/agent/storage"
@@ -71,6 +73,101 @@ var _ = Describe("Downloader", func() {
 		})
 	})
 
+	Context("When storage uri is an Azure Blob Storage uri", func() {
+		It("Should use the Azure Blob Storage provider", func() {
+			protocol, err := extractProtocol("https://account.blob.core.windows.net/models/model1")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.AzureBlob))
+			protocol, err = extractProtocol("https://example.com/models/model1")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.HTTPS))
+		})
+
+		It("Should use the https provider for the presigned SAS urls", func() {
+			protocol, err := extractProtocol("https://account.blob.core.windows.net/models/model1/model.joblib?sv=2022-11-02&se=2030-01-01&sr=b&sp=r&sig=c2lnbmF0dXJl")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.HTTPS))
+		})
+	})
+
+	Context("When storage uri is a pvc or file uri", func() {
+		It("Should use the local provider", func() {
+			protocol, err := extractProtocol("pvc://models/sklearn/iris")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.PVC))
+			protocol, err = extractProtocol("file:///mnt/models/sklearn/iris")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.File))
+		})
+	})
+
+	Context("When storage uri is an oci uri", func() {
+		It("Should use the OCI provider", func() {
+			protocol, err := extractProtocol("oci://registry.example.com/models/sklearn:v1")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.OCI))
+		})
+	})
+
+	Context("When storage uri is an hdfs or webhdfs uri", func() {
+		It("Should use the WebHDFS provider", func() {
+			protocol, err := extractProtocol("hdfs://models/sklearn/iris")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.HDFS))
+			protocol, err = extractProtocol("webhdfs://models/sklearn/iris")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.WebHDFS))
+		})
+	})
+
+	Context("When storage uri has query parameters", func() {
+		It("Should validate the parameters of the protocol", func() {
+			protocol, err := extractProtocol("s3://models/sklearn/iris/model.joblib?versionId=3HL4kqtJ&region=eu-west-1")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.S3))
+			protocol, err = extractProtocol("gs://models/sklearn/iris/model.joblib?generation=1700000000000000")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.GCS))
+			// the query of https uris is left to the provider
+			protocol, err = extractProtocol("https://example.com/models/model.joblib?signature=abc")
+			Expect(err).To(BeNil())
+			Expect(protocol).To(Equal(storage.HTTPS))
+			_, err = extractProtocol("s3://models/sklearn/iris?generation=1")
+			Expect(err).NotTo(BeNil())
+			_, err = extractProtocol("gs://models/sklearn/iris/?generation=1")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Context("When storage uri is a model registry uri", func() {
+		It("Should download the model version it resolves to", func() {
+			source := filepath.Join(modelDir, "artifacts")
+			Expect(os.MkdirAll(source, os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(source, "model.joblib"), []byte("model"), 0644)).To(Succeed())
+			modelSpec := &v1alpha1.ModelSpec{
+				StorageURI: "models:/fraud-detector/Production",
+				Framework:  "sklearn",
+			}
+			err := downloader.DownloadModel("model1", modelSpec)
+			Expect(err).To(MatchError(ContainSubstring("no model registry is configured")))
+
+			downloader.Providers[storage.File] = &storage.LocalProvider{FileRoot: modelDir}
+			downloader.Resolver = stubModelResolver{
+				"models:/fraud-detector/Production": {StorageURI: "file://" + source, Version: "3"},
+			}
+			Expect(downloader.DownloadModel("model1", modelSpec)).To(Succeed())
+			Expect(filepath.Join(downloader.ModelDir, "model1", "model.joblib")).To(BeARegularFile())
+			Expect(downloader.ResolvedModel("model1", modelSpec)).To(Equal(&storage.ResolvedModel{
+				StorageURI: "file://" + source,
+				Version:    "3",
+			}))
+			// the success file is named after the spec of the model registry uri
+			recovered, err := SyncModelDir(downloader.ModelDir, downloader.Logger)
+			Expect(err).To(BeNil())
+			Expect(recovered["model1"].Spec.StorageURI).To(Equal(modelSpec.StorageURI))
+		})
+	})
+
 	Context("When storage uri is empty", func() {
 		It("Should fail out and return error", func() {
 			modelConfig := modelconfig.ModelConfig{
@@ -99,3 +196,14 @@ var _ = Describe("Downloader", func() {
 		})
 	})
 })
+
+// stubModelResolver resolves the model registry uris it is keyed by.
+type stubModelResolver map[string]*storage.ResolvedModel
+
+func (s stubModelResolver) Resolve(storageUri string) (*storage.ResolvedModel, error) {
+	resolved, ok := s[storageUri]
+	if !ok {
+		return nil, fmt.Errorf("model %s is not registered", storageUri)
+	}
+	return resolved, nil
+}
@@ -97,14 +97,28 @@ type mockObjectHandle struct {
 	c          *mockGCSClient
 	bucketName string
 	name       string
+	generation int64
+}
+
+func (o mockObjectHandle) Generation(gen int64) stiface.ObjectHandle {
+	o.generation = gen
+	return o
+}
+
+// object returns the attributes of the object, only the latest generation of the objects is kept.
+func (o mockObjectHandle) object() (*gstorage.ObjectAttrs, bool) {
+	contents, ok := o.c.buckets[o.bucketName].objects[o.name]
+	if !ok || (o.generation != 0 && contents.Generation != o.generation) {
+		return nil, false
+	}
+	return contents, true
 }
 
 func (o mockObjectHandle) Attrs(context.Context) (*gstorage.ObjectAttrs, error) {
-	bkt, ok := o.c.buckets[o.bucketName]
-	if !ok {
+	if _, ok := o.c.buckets[o.bucketName]; !ok {
 		return nil, fmt.Errorf("bucket %q not found", o.bucketName)
 	}
-	contents, ok := bkt.objects[o.name]
+	contents, ok := o.object()
 	if !ok {
 		return nil, gstorage.ErrObjectNotExist
 	}
@@ -112,22 +126,39 @@ func (o mockObjectHandle) Attrs(context.Context) (*gstorage.ObjectAttrs, error)
 }
 
 func (o mockObjectHandle) NewReader(context.Context) (stiface.Reader, error) {
-	bkt, ok := o.c.buckets[o.bucketName]
-	if !ok {
+	if _, ok := o.c.buckets[o.bucketName]; !ok {
 		return nil, fmt.Errorf("bucket %q not found", o.bucketName)
 	}
-	contents, ok := bkt.objects[o.name]
+	contents, ok := o.object()
 	if !ok {
 		return nil, fmt.Errorf("object %q not found in bucket %q", o.name, o.bucketName)
 	}
 	return mockReader{r: bytes.NewReader(contents.MD5)}, nil
 }
 
+func (o mockObjectHandle) NewRangeReader(_ context.Context, offset int64, length int64) (stiface.Reader, error) {
+	if _, ok := o.c.buckets[o.bucketName]; !ok {
+		return nil, fmt.Errorf("bucket %q not found", o.bucketName)
+	}
+	contents, ok := o.object()
+	if !ok {
+		return nil, fmt.Errorf("object %q not found in bucket %q", o.name, o.bucketName)
+	}
+	if length < 0 {
+		length = int64(len(contents.MD5)) - offset
+	}
+	return mockReader{r: bytes.NewReader(contents.MD5[offset : offset+length])}, nil
+}
+
 func (o mockObjectHandle) NewWriter(context.Context) stiface.Writer {
 	attrs := &gstorage.ObjectAttrs{
-		Bucket: o.bucketName,
-		Name:   o.name,
-		MD5:    nil,
+		Bucket:     o.bucketName,
+		Name:       o.name,
+		MD5:        nil,
+		Generation: 1,
+	}
+	if previous, ok := o.c.buckets[o.bucketName].objects[o.name]; ok {
+		attrs.Generation = previous.Generation + 1
 	}
 	o.c.buckets[o.bucketName].objects[o.name] = attrs
 	return &mockWriter{o: o, obj: attrs}
@@ -156,5 +187,6 @@ type mockWriter struct {
 func (w *mockWriter) Write(data []byte) (int, error) {
 	int, err := w.buf.Write(data)
 	w.obj.MD5 = data
+	w.obj.Size = int64(len(data))
 	return int, err
 }
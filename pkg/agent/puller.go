@@ -17,15 +17,14 @@ limitations under the License.
 package agent
 
 import (
-	"bytes"
 	"fmt"
-	"io"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"sync"
 	"syscall"
+	"time"
 
-	"github.com/kserve/kserve/pkg/agent/storage"
 	v1 "github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
 	"go.uber.org/zap"
 )
@@ -35,15 +34,26 @@ type OpType string
 const (
 	Add    OpType = "Add"
 	Remove OpType = "Remove"
+	// Update replaces the files of a model whose spec changed and reloads it
+	Update OpType = "Update"
 )
 
 type Puller struct {
 	channelMap  map[string]*ModelChannel
 	completions chan *ModelOp
 	opStats     map[string]map[OpType]int
-	waitGroup   WaitGroupWrapper
-	Downloader  *Downloader
-	logger      *zap.SugaredLogger
+	// failedOps are the last ops of the models which failed, they are run again on a retry signal
+	failedOps  map[string]*ModelOp
+	retries    chan string
+	waitGroup  WaitGroupWrapper
+	Downloader *Downloader
+	// Repository is the repository API of the model server, the v2 repository extension on
+	// localhost:8080 if it is nil
+	Repository  ModelRepository
+	retryPolicy RetryPolicy
+	// statusTracker is optional, the state of the models is not reported if it is nil
+	statusTracker *StatusTracker
+	logger        *zap.SugaredLogger
 }
 
 type ModelOp struct {
@@ -51,20 +61,69 @@ type ModelOp struct {
 	ModelName string
 	Op        OpType
 	Spec      *v1.ModelSpec
+	// failed is set once the op failed all its attempts
+	failed bool
+}
+
+// RetryPolicy controls how failed model ops are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times an op is run before it is marked as failed, ops are not
+	// retried if it is 1 or less
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, it is doubled on every following retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between two retries
+	MaxBackoff time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+}
+
+func (r RetryPolicy) maxAttempts() int {
+	if r.MaxAttempts < 1 {
+		return 1
+	}
+	return r.MaxAttempts
+}
+
+// backoff returns the wait before retrying an op which failed attempt times.
+func (r RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := r.InitialBackoff
+	for i := 1; i < attempt && backoff < r.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if r.MaxBackoff > 0 && backoff > r.MaxBackoff {
+		backoff = r.MaxBackoff
+	}
+	return backoff
 }
 
 type WaitGroupWrapper struct {
 	wg sync.WaitGroup
 }
 
-func StartPullerAndProcessModels(downloader *Downloader, commands <-chan ModelOp, logger *zap.SugaredLogger) {
+// StartPullerAndProcessModels processes the model ops and returns once the models configured on
+// startup are processed. The failed models can be retried through the status tracker.
+func StartPullerAndProcessModels(downloader *Downloader, repository ModelRepository, commands <-chan ModelOp,
+	retryPolicy RetryPolicy, statusTracker *StatusTracker, logger *zap.SugaredLogger) {
 	puller := Puller{
-		channelMap:  make(map[string]*ModelChannel),
-		completions: make(chan *ModelOp, 4),
-		opStats:     make(map[string]map[OpType]int),
-		waitGroup:   WaitGroupWrapper{sync.WaitGroup{}},
-		Downloader:  downloader,
-		logger:      logger,
+		channelMap:    make(map[string]*ModelChannel),
+		completions:   make(chan *ModelOp, 4),
+		opStats:       make(map[string]map[OpType]int),
+		failedOps:     make(map[string]*ModelOp),
+		retries:       make(chan string, 8),
+		waitGroup:     WaitGroupWrapper{sync.WaitGroup{}},
+		Downloader:    downloader,
+		Repository:    repository,
+		retryPolicy:   retryPolicy,
+		statusTracker: statusTracker,
+		logger:        logger,
+	}
+	if statusTracker != nil {
+		statusTracker.setRetry(puller.Retry)
 	}
 
 	// Change umask to ensure we have control over the downloaded file
@@ -72,11 +131,37 @@ func StartPullerAndProcessModels(downloader *Downloader, commands <-chan ModelOp
 	// https://stackoverflow.com/a/61645606/5015573
 	syscall.Umask(0)
 
-	puller.waitGroup.wg.Add(len(commands))
-	go puller.processCommands(commands)
+	// The ops of the models configured on startup are enqueued in the download order, so that the
+	// first download slots are granted in that order too
+	backlog := make([]ModelOp, len(commands))
+	for i := range backlog {
+		backlog[i] = <-commands
+	}
+	if downloader.Scheduler != nil {
+		sortBacklog(backlog, downloader.Scheduler.Order)
+	}
+	puller.waitGroup.wg.Add(len(backlog))
+	go func() {
+		for i := range backlog {
+			puller.enqueueModelOp(&backlog[i])
+		}
+		puller.processCommands(commands)
+	}()
 	puller.waitGroup.wg.Wait()
 }
 
+// sortBacklog sorts the ops in the download order, the ops without a spec such as the removals
+// come first as they free up space.
+func sortBacklog(backlog []ModelOp, order DownloadOrder) {
+	sort.SliceStable(backlog, func(i, j int) bool {
+		a, b := backlog[i].Spec, backlog[j].Spec
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return order.before(a, b)
+	})
+}
+
 func (p *Puller) processCommands(commands <-chan ModelOp) {
 	// channelMap accessed only by this goroutine
 	for {
@@ -89,10 +174,33 @@ func (p *Puller) processCommands(commands <-chan ModelOp) {
 			}
 		case completed := <-p.completions:
 			p.modelOpComplete(completed, commands == nil)
+		case modelName := <-p.retries:
+			p.retryModelOp(modelName)
 		}
 	}
 }
 
+// Retry runs the failed op of the model again.
+func (p *Puller) Retry(modelName string) {
+	p.retries <- modelName
+}
+
+func (p *Puller) retryModelOp(modelName string) {
+	modelOp, ok := p.failedOps[modelName]
+	if !ok {
+		p.logger.Infof("Ignoring retry of model %s which has no failed op", modelName)
+		return
+	}
+	delete(p.failedOps, modelName)
+	p.logger.Infof("Retrying failed %s op of model %s", modelOp.Op, modelName)
+	retryOp := &ModelOp{
+		ModelName: modelOp.ModelName,
+		Op:        modelOp.Op,
+		Spec:      modelOp.Spec,
+	}
+	p.enqueueModelOp(retryOp)
+}
+
 type ModelChannel struct {
 	modelOps    chan *ModelOp
 	opsInFlight int
@@ -116,6 +224,15 @@ func (p *Puller) modelOpComplete(modelOp *ModelOp, closed bool) {
 	if modelOp.OnStartup {
 		defer p.waitGroup.wg.Done()
 	}
+	// A later op of the model, such as one for a config update, replaces its failed op
+	if modelOp.failed {
+		if p.failedOps == nil {
+			p.failedOps = make(map[string]*ModelOp)
+		}
+		p.failedOps[modelOp.ModelName] = modelOp
+	} else {
+		delete(p.failedOps, modelOp.ModelName)
+	}
 	if opMap, ok := p.opStats[modelOp.ModelName]; ok {
 		opMap[modelOp.Op] += 1
 	} else {
@@ -139,82 +256,153 @@ func (p *Puller) modelOpComplete(modelOp *ModelOp, closed bool) {
 	}
 }
 
+func (p *Puller) repository() ModelRepository {
+	if p.Repository == nil {
+		return &V2ModelRepository{Endpoint: "http://localhost:8080", Client: http.DefaultClient}
+	}
+	return p.Repository
+}
+
+func (p *Puller) setState(modelName string, state v1.TrainedModelState, err error) {
+	if p.statusTracker != nil {
+		p.statusTracker.SetState(modelName, state, err)
+	}
+}
+
+func (p *Puller) setResolvedModel(modelName string, spec *v1.ModelSpec) {
+	if p.statusTracker != nil {
+		p.statusTracker.SetResolvedModel(modelName, p.Downloader.ResolvedModel(modelName, spec))
+	}
+}
+
+func (p *Puller) removeState(modelName string) {
+	if p.statusTracker != nil {
+		p.statusTracker.Remove(modelName)
+	}
+}
+
 func (p *Puller) modelProcessor(modelName string, ops <-chan *ModelOp) {
 	p.logger.Infof("Worker is started for %s", modelName)
 	// TODO: Instead of going through each event, one-by-one, we need to drain and combine
 	// this is important for handling Load --> Unload requests sent in tandem
 	// Load --> Unload = 0 (cancel first load)
 	// Load --> Unload --> Load = 1 Load (cancel second load?)
-	var processOp = func(modelOp *ModelOp) {
-		switch modelOp.Op {
-		case Add:
-			p.logger.Infof("Downloading model from %s", modelOp.Spec.StorageURI)
-			err := p.Downloader.DownloadModel(modelName, modelOp.Spec)
-			if err != nil {
-				// If there is an error, we will NOT send a request. As such, to know about errors, you will
-				// need to call the error endpoint of the puller
-				p.logger.Errorf("Failed to download model %s with err %v", modelName, err)
-				break
-			}
-			// Load the model onto the model server
-			resp, err := http.Post(fmt.Sprintf("http://localhost:8080/v2/repository/models/%s/load", modelName),
-				"application/json",
-				bytes.NewBufferString("{}"))
-			if err != nil {
-				// handle error
-				p.logger.Errorf("Failed to Load model %s", modelName)
-			} else {
-				defer func(Body io.ReadCloser) {
-					closeErr := Body.Close()
-					if closeErr != nil {
-						p.logger.Error(closeErr, "failed to close body")
-					}
-				}(resp.Body)
-				if resp.StatusCode == 200 {
-					p.logger.Infof("Successfully loaded model %s", modelName)
-				} else {
-					body, err := io.ReadAll(resp.Body)
-					if err == nil {
-						p.logger.Infof("Failed to load model %s with status [%d] and resp:%v", modelName, resp.StatusCode, body)
-					}
-				}
-			}
-		case Remove:
-			p.logger.Infof("unloading model %s", modelName)
-			// If there is an error, we will NOT do a delete... that could be problematic
-			if err := storage.RemoveDir(filepath.Join(p.Downloader.ModelDir, modelName)); err != nil {
-				p.logger.Error(err, "failing to delete model directory")
-				break
-			}
-			// unload model from model server
-			resp, err := http.Post(fmt.Sprintf("http://localhost:8080/v2/repository/models/%s/unload", modelName),
-				"application/json",
-				bytes.NewBufferString("{}"))
-			if err != nil {
-				// handle error
-				p.logger.Errorf("Failed to Unload model %s", modelName)
-			} else {
-				if resp != nil {
-					defer func(Body io.ReadCloser) {
-						closeErr := Body.Close()
-						if closeErr != nil {
-							p.logger.Error(closeErr, "failed to close body")
-						}
-					}(resp.Body)
-				}
-				if resp.StatusCode == 200 {
-					p.logger.Infof("Successfully unloaded model %s", modelName)
-				} else {
-					body, err := io.ReadAll(resp.Body)
-					if err == nil {
-						p.logger.Infof("Failed to unload model %s with status [%d] and resp:%v", modelName, resp.StatusCode, body)
-					}
-				}
+	var next *ModelOp
+	for {
+		modelOp := next
+		if modelOp == nil {
+			var ok bool
+			if modelOp, ok = <-ops; !ok {
+				return
 			}
 		}
+		next = p.processOp(modelName, modelOp, ops)
+	}
+}
+
+// processOp runs the op until it succeeds or it fails RetryPolicy.MaxAttempts times, in which case
+// it is marked as failed. A newer op for the model received while backing off supersedes the
+// retried op and is returned to be processed next.
+func (p *Puller) processOp(modelName string, modelOp *ModelOp, ops <-chan *ModelOp) *ModelOp {
+	defer func() {
 		p.completions <- modelOp
+	}()
+	maxAttempts := p.retryPolicy.maxAttempts()
+	for attempt := 1; ; attempt++ {
+		state, err := p.runOp(modelName, modelOp)
+		if err == nil {
+			return nil
+		}
+		if attempt >= maxAttempts {
+			p.logger.Errorf("Giving up on %s op of model %s after %d attempts: %v", modelOp.Op, modelName, attempt, err)
+			modelOp.failed = true
+			if modelOp.Op != Remove {
+				p.setState(modelName, v1.FailedToLoad, err)
+			}
+			return nil
+		}
+		backoff := p.retryPolicy.backoff(attempt)
+		p.logger.Infof("Retrying %s op of model %s in %v after attempt %d of %d failed: %v",
+			modelOp.Op, modelName, backoff, attempt, maxAttempts, err)
+		if modelOp.Op != Remove {
+			p.setState(modelName, state, fmt.Errorf("attempt %d of %d failed: %w", attempt, maxAttempts, err))
+		}
+		select {
+		case <-time.After(backoff):
+		case next := <-ops:
+			p.logger.Infof("%s op of model %s is superseded by a %s op", modelOp.Op, modelName, next.Op)
+			return next
+		}
+	}
+}
+
+// runOp runs one attempt of the op. It returns the state the model was in when it failed.
+func (p *Puller) runOp(modelName string, modelOp *ModelOp) (v1.TrainedModelState, error) {
+	switch modelOp.Op {
+	case Add:
+		return p.loadModel(modelName, modelOp.Spec)
+	case Update:
+		return p.updateModel(modelName, modelOp.Spec)
+	case Remove:
+		return "", p.unloadModel(modelName)
+	}
+	return "", nil
+}
+
+func (p *Puller) loadModel(modelName string, spec *v1.ModelSpec) (v1.TrainedModelState, error) {
+	p.logger.Infof("Downloading model from %s", spec.StorageURI)
+	p.setState(modelName, v1.Downloading, nil)
+	if err := p.Downloader.DownloadModel(modelName, spec); err != nil {
+		// If there is an error, we will NOT send a request. The error is reported on the model
+		// status endpoint of the agent
+		p.logger.Errorf("Failed to download model %s with err %v", modelName, err)
+		return v1.Downloading, err
+	}
+	p.setResolvedModel(modelName, spec)
+	// Load the model onto the model server
+	p.setState(modelName, v1.Loading, nil)
+	if err := p.repository().Load(modelName, filepath.Join(p.Downloader.ModelDir, modelName)); err != nil {
+		p.logger.Errorf("Failed to load model %s with err %v", modelName, err)
+		return v1.Loading, err
+	}
+	p.logger.Infof("Successfully loaded model %s", modelName)
+	p.setState(modelName, v1.Loaded, nil)
+	return v1.Loaded, nil
+}
+
+// updateModel swaps the new version of the model into its directory and then reloads it, so that
+// the model server keeps serving the previous version in the meantime.
+func (p *Puller) updateModel(modelName string, spec *v1.ModelSpec) (v1.TrainedModelState, error) {
+	p.logger.Infof("Updating model %s from %s", modelName, spec.StorageURI)
+	p.setState(modelName, v1.Downloading, nil)
+	if err := p.Downloader.UpdateModel(modelName, spec); err != nil {
+		p.logger.Errorf("Failed to download the new version of model %s with err %v", modelName, err)
+		return v1.Downloading, err
+	}
+	p.setResolvedModel(modelName, spec)
+	p.setState(modelName, v1.Loading, nil)
+	if err := p.repository().Reload(modelName, filepath.Join(p.Downloader.ModelDir, modelName)); err != nil {
+		p.logger.Errorf("Failed to reload model %s with err %v", modelName, err)
+		return v1.Loading, err
+	}
+	p.logger.Infof("Successfully updated model %s", modelName)
+	p.setState(modelName, v1.Loaded, nil)
+	return v1.Loaded, nil
+}
+
+func (p *Puller) unloadModel(modelName string) error {
+	p.logger.Infof("unloading model %s", modelName)
+	p.removeState(modelName)
+	// If there is an error, we will NOT do a delete... that could be problematic
+	if err := p.Downloader.RemoveModel(modelName); err != nil {
+		p.logger.Error(err, "failing to delete model directory")
+		return err
 	}
-	for modelOp := range ops {
-		processOp(modelOp)
+	// unload model from model server
+	if err := p.repository().Unload(modelName); err != nil {
+		p.logger.Errorf("Failed to unload model %s with err %v", modelName, err)
+		return err
 	}
+	p.logger.Infof("Successfully unloaded model %s", modelName)
+	return nil
 }
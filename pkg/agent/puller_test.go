@@ -0,0 +1,212 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kserve/kserve/pkg/agent/storage"
+	"github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+	"github.com/kserve/kserve/pkg/constants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Puller", func() {
+	Context("Computing the retry backoff", func() {
+		It("should double the backoff up to the max backoff", func() {
+			policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+			Expect(policy.backoff(1)).To(Equal(time.Second))
+			Expect(policy.backoff(2)).To(Equal(2 * time.Second))
+			Expect(policy.backoff(3)).To(Equal(4 * time.Second))
+			Expect(policy.backoff(4)).To(Equal(5 * time.Second))
+			Expect(policy.backoff(40)).To(Equal(5 * time.Second))
+			Expect(RetryPolicy{}.maxAttempts()).To(Equal(1))
+		})
+	})
+
+	Context("Retrying failed model ops", func() {
+		var modelDir string
+		var downloads atomic.Int32
+		var ts *httptest.Server
+		var tracker *StatusTracker
+		var puller *Puller
+		var commands chan ModelOp
+		BeforeEach(func() {
+			var err error
+			modelDir, err = os.MkdirTemp("", "puller")
+			Expect(err).To(BeNil())
+			downloads.Store(0)
+			ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				downloads.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			zapLogger, _ := zap.NewProduction()
+			tracker = NewStatusTracker()
+			puller = &Puller{
+				channelMap:  make(map[string]*ModelChannel),
+				completions: make(chan *ModelOp, 4),
+				opStats:     make(map[string]map[OpType]int),
+				failedOps:   make(map[string]*ModelOp),
+				retries:     make(chan string, 8),
+				Downloader: &Downloader{
+					ModelDir: modelDir,
+					Providers: map[storage.Protocol]storage.Provider{
+						storage.HTTP: &storage.HTTPSProvider{Client: ts.Client()},
+					},
+					Logger: zapLogger.Sugar(),
+				},
+				retryPolicy:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+				statusTracker: tracker,
+				logger:        zapLogger.Sugar(),
+			}
+			tracker.setRetry(puller.Retry)
+			commands = make(chan ModelOp, 2)
+			go puller.processCommands(commands)
+		})
+		AfterEach(func() {
+			ts.Close()
+			os.RemoveAll(modelDir)
+		})
+		addOp := func() ModelOp {
+			return ModelOp{
+				ModelName: "model1",
+				Op:        Add,
+				Spec: &v1alpha1.ModelSpec{
+					StorageURI: ts.URL + "/model.joblib",
+					Framework:  "sklearn",
+				},
+			}
+		}
+		modelState := func() v1alpha1.TrainedModelState {
+			status, _ := tracker.Get("model1")
+			return status.State
+		}
+
+		It("should mark the model as failed once its attempts are exhausted and retry it on signal", func() {
+			commands <- addOp()
+			Eventually(modelState).Should(Equal(v1alpha1.FailedToLoad))
+			Expect(downloads.Load()).To(Equal(int32(3)))
+
+			recorder := httptest.NewRecorder()
+			tracker.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, constants.AgentModelStatusPath+"/model1/retry", nil))
+			Expect(recorder.Code).To(Equal(http.StatusAccepted))
+			Eventually(downloads.Load).Should(Equal(int32(6)))
+			Eventually(modelState).Should(Equal(v1alpha1.FailedToLoad))
+		})
+
+		It("should only retry failed models", func() {
+			tracker.SetState("model1", v1alpha1.Loaded, nil)
+			recorder := httptest.NewRecorder()
+			tracker.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, constants.AgentModelStatusPath+"/model1/retry", nil))
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+		})
+
+		It("should stop retrying an op superseded by a newer op", func() {
+			puller.retryPolicy = RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour}
+			commands <- addOp()
+			Eventually(downloads.Load).Should(Equal(int32(1)))
+			commands <- ModelOp{ModelName: "model1", Op: Remove}
+			Eventually(func() bool {
+				_, ok := tracker.Get("model1")
+				return ok
+			}).Should(BeFalse())
+			Consistently(downloads.Load, 100*time.Millisecond).Should(Equal(int32(1)))
+			Expect(downloads.Load()).To(Equal(int32(1)))
+		})
+	})
+
+	Context("Updating a model", func() {
+		It("should swap in the new version and reload it", func() {
+			modelDir := GinkgoT().TempDir()
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, r.URL.Path)
+			}))
+			defer ts.Close()
+			zapLogger, _ := zap.NewProduction()
+			tracker := NewStatusTracker()
+			repository := &recordingRepository{}
+			puller := &Puller{
+				channelMap:  make(map[string]*ModelChannel),
+				completions: make(chan *ModelOp, 4),
+				opStats:     make(map[string]map[OpType]int),
+				Downloader: &Downloader{
+					ModelDir: modelDir,
+					Providers: map[storage.Protocol]storage.Provider{
+						storage.HTTP: &storage.HTTPSProvider{Client: ts.Client()},
+					},
+					Logger: zapLogger.Sugar(),
+				},
+				Repository:    repository,
+				statusTracker: tracker,
+				logger:        zapLogger.Sugar(),
+			}
+			commands := make(chan ModelOp, 2)
+			go puller.processCommands(commands)
+			spec := func(fileName string) *v1alpha1.ModelSpec {
+				return &v1alpha1.ModelSpec{StorageURI: ts.URL + "/" + fileName, Framework: "sklearn"}
+			}
+			commands <- ModelOp{ModelName: "model1", Op: Add, Spec: spec("v1.joblib")}
+			commands <- ModelOp{ModelName: "model1", Op: Update, Spec: spec("v2.joblib")}
+			Eventually(repository.Calls).Should(Equal([]string{"Load model1", "Reload model1"}))
+			status, _ := tracker.Get("model1")
+			Expect(status.State).To(Equal(v1alpha1.Loaded))
+			Expect(os.ReadFile(filepath.Join(modelDir, "model1", "v2.joblib"))).To(BeEquivalentTo("/v2.joblib"))
+			Expect(filepath.Join(modelDir, "model1", "v1.joblib")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(modelDir, stagingDirName("model1"))).NotTo(BeAnExistingFile())
+		})
+	})
+})
+
+// recordingRepository records the calls made to the model server.
+type recordingRepository struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recordingRepository) record(call string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+	return nil
+}
+
+func (r *recordingRepository) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.calls...)
+}
+
+func (r *recordingRepository) Load(modelName string, _ string) error {
+	return r.record("Load " + modelName)
+}
+
+func (r *recordingRepository) Reload(modelName string, _ string) error {
+	return r.record("Reload " + modelName)
+}
+
+func (r *recordingRepository) Unload(modelName string) error {
+	return r.record("Unload " + modelName)
+}
@@ -0,0 +1,325 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kserve/kserve/pkg/agent/storage"
+	"github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+	"go.uber.org/zap"
+)
+
+const successFilePrefix = "SUCCESS."
+
+// ReconcileSummary is what ReconcileModelDir found in the model directory and did about it.
+type ReconcileSummary struct {
+	// Recovered is the number of downloaded models kept in the model directory
+	Recovered int
+	// RepairedSuccessFiles is the number of success files renamed after the hash of the configured
+	// spec they record, or removed because they are unreadable or superseded
+	RepairedSuccessFiles int
+	// Resumable is the number of partial downloads of configured models kept to be resumed
+	Resumable int
+	// RemovedPartial is the number of partial or broken downloads removed
+	RemovedPartial int
+	// RemovedOrphaned is the number of downloaded models removed because they are not configured
+	RemovedOrphaned int
+	// RestoredSwaps is the number of interrupted swaps of a new model version completed
+	RestoredSwaps int
+	// RemovedStaging is the number of staging directories of interrupted updates removed
+	RemovedStaging int
+	// RemovedTempFiles is the number of temporary files of the download journals removed
+	RemovedTempFiles int
+	// Skipped is the number of directories left alone because they were not written by the agent
+	Skipped int
+}
+
+func (s *ReconcileSummary) String() string {
+	return fmt.Sprintf("%d models recovered, %d success files repaired, %d partial downloads kept to be resumed, "+
+		"%d partial downloads removed, %d orphaned models removed, %d interrupted swaps completed, "+
+		"%d staging dirs removed, %d temporary files removed, %d foreign dirs skipped", s.Recovered, s.RepairedSuccessFiles,
+		s.Resumable, s.RemovedPartial, s.RemovedOrphaned, s.RestoredSwaps, s.RemovedStaging, s.RemovedTempFiles, s.Skipped)
+}
+
+// ReconcileModelDir cleans up what a crash or a previous configuration left in the model directory
+// before the models are synced from it, see SyncModelDir. desired holds the spec of the configured
+// models by name, it is nil if the configuration is unknown, in which case the models which are
+// not configured are left to the watcher.
+//   - A directory which is neither named after a configured model nor written by the agent, see
+//     writtenByAgent, is skipped, e.g. the lost+found directory of a volume or the files of the
+//     model server.
+//   - A success file recording the configured spec of the model under the hash of another spec is
+//     renamed, an unreadable one is removed. Only the latest success file of a model directory is kept.
+//   - A model directory without a success file is a partial download. It is kept if it is resumable,
+//     see storage.PartsFileSuffix, and the model is still configured, else it is removed.
+//   - A downloaded model whose links to the model cache are dangling is removed.
+//   - A downloaded model which is not configured is removed.
+//   - The swap of a new model version which was interrupted is completed, and the staging
+//     directories of the interrupted updates are removed.
+func ReconcileModelDir(modelDir string, desired map[string]*v1alpha1.ModelSpec, logger *zap.SugaredLogger) (*ReconcileSummary, error) {
+	summary := &ReconcileSummary{}
+	if _, err := os.Stat(modelDir); os.IsNotExist(err) {
+		return summary, nil
+	}
+	entries, err := os.ReadDir(modelDir)
+	if err != nil {
+		return nil, err
+	}
+	// the interrupted swaps are completed first, so that the swapped in models are reconciled below
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, ".") || !strings.HasSuffix(name, stagingDirSuffix+exchangeDirSuffix) {
+			continue
+		}
+		modelName := strings.TrimSuffix(strings.TrimPrefix(name, "."), stagingDirSuffix+exchangeDirSuffix)
+		modelPath := filepath.Join(modelDir, modelName)
+		stagingPath := filepath.Join(modelDir, stagingDirName(modelName))
+		if !pathExists(modelPath) && pathExists(stagingPath) {
+			logger.Infof("Completing the interrupted swap of the new version of model %s", modelName)
+			if err := os.Rename(stagingPath, modelPath); err != nil {
+				return nil, err
+			}
+			summary.RestoredSwaps++
+		}
+		if err := os.RemoveAll(filepath.Join(modelDir, name)); err != nil {
+			return nil, err
+		}
+	}
+	if entries, err = os.ReadDir(modelDir); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(modelDir, name)
+		switch {
+		case !entry.IsDir():
+			continue
+		case strings.HasPrefix(name, "."):
+			// hidden directories such as the model cache do not hold models
+			if strings.HasSuffix(name, stagingDirSuffix) {
+				logger.Infof("Removing the staging dir %s of an interrupted update", name)
+				if err := os.RemoveAll(path); err != nil {
+					return nil, err
+				}
+				summary.RemovedStaging++
+			}
+			continue
+		}
+		if err := reconcileModel(path, name, desired, summary, logger); err != nil {
+			return nil, fmt.Errorf("failed to reconcile model %s: %w", name, err)
+		}
+	}
+	logger.Infof("Reconciled model dir %s: %s", modelDir, summary)
+	return summary, nil
+}
+
+func reconcileModel(modelPath string, modelName string, desired map[string]*v1alpha1.ModelSpec, summary *ReconcileSummary,
+	logger *zap.SugaredLogger) error {
+	if desired[modelName] == nil && !writtenByAgent(modelPath) {
+		logger.Infof("Skipping %s in the model dir, it was not written by the agent", modelName)
+		summary.Skipped++
+		return nil
+	}
+	removed, err := removeTempFiles(modelPath)
+	if err != nil {
+		return err
+	}
+	summary.RemovedTempFiles += removed
+	successFile, err := repairSuccessFiles(modelPath, desired[modelName], summary, logger)
+	if err != nil {
+		return err
+	}
+	configured := desired == nil || desired[modelName] != nil
+	switch {
+	case successFile == "" && configured && hasPartsJournal(modelPath):
+		logger.Infof("Keeping the partial download of model %s to be resumed", modelName)
+		summary.Resumable++
+		return nil
+	case successFile == "":
+		logger.Infof("Removing the partial download of model %s", modelName)
+		summary.RemovedPartial++
+	case hasDanglingLinks(modelPath):
+		logger.Infof("Removing model %s whose cached artifact is missing", modelName)
+		summary.RemovedPartial++
+	case !configured:
+		logger.Infof("Removing model %s which is no longer configured", modelName)
+		summary.RemovedOrphaned++
+	default:
+		summary.Recovered++
+		return nil
+	}
+	return storage.RemoveDir(modelPath)
+}
+
+// repairSuccessFiles names the success file recording the configured spec of the model after the
+// hash of that spec, which is the one the Downloader looks for, and removes the unreadable success
+// files and all but the latest one. It returns the remaining success file, or an empty string if
+// there is none. The hash is not computed from the recorded spec, which is not hashed the same
+// once decoded.
+func repairSuccessFiles(modelPath string, configured *v1alpha1.ModelSpec, summary *ReconcileSummary,
+	logger *zap.SugaredLogger) (string, error) {
+	files, err := os.ReadDir(modelPath)
+	if err != nil {
+		return "", err
+	}
+	modTimes := make(map[string]time.Time)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasPrefix(file.Name(), successFilePrefix) {
+			continue
+		}
+		path := filepath.Join(modelPath, file.Name())
+		record, err := readSuccessRecord(path)
+		if err != nil {
+			logger.Infof("Removing the unreadable success file %s: %v", path, err)
+			if err := os.Remove(path); err != nil {
+				return "", err
+			}
+			summary.RepairedSuccessFiles++
+			continue
+		}
+		if configured != nil && cmp.Equal(record.ModelSpec, *configured, ignorePriority) {
+			expected := filepath.Join(modelPath, successFilePrefix+specHash(configured))
+			if path != expected {
+				logger.Infof("Renaming the success file %s after the hash of the spec it records", path)
+				if err := os.Rename(path, expected); err != nil {
+					return "", err
+				}
+				summary.RepairedSuccessFiles++
+				path = expected
+			}
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		modTimes[path] = info.ModTime()
+	}
+	// the files of the model are the ones of its latest download
+	latest := ""
+	for path, modTime := range modTimes {
+		if latest == "" || modTime.After(modTimes[latest]) {
+			latest = path
+		}
+	}
+	for path := range modTimes {
+		if path != latest {
+			logger.Infof("Removing the superseded success file %s", path)
+			if err := os.Remove(path); err != nil {
+				return "", err
+			}
+			summary.RepairedSuccessFiles++
+		}
+	}
+	return latest, nil
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// removeTempFiles removes the temporary files of the download journals of the model.
+func removeTempFiles(modelPath string) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(modelPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), storage.PartsFileSuffix+".tmp") {
+			removed++
+			return os.Remove(path)
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// writtenByAgent returns true if the directory holds a success file, a download journal or links
+// to the model cache, which only the agent writes.
+func writtenByAgent(modelPath string) bool {
+	files, err := os.ReadDir(modelPath)
+	if err != nil {
+		return false
+	}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasPrefix(file.Name(), successFilePrefix) {
+			return true
+		}
+		if file.Type()&fs.ModeSymlink != 0 && isCacheLink(modelPath, file.Name()) {
+			return true
+		}
+	}
+	return hasFileWithSuffix(modelPath, storage.PartsFileSuffix, storage.PartsFileSuffix+".tmp")
+}
+
+// isCacheLink returns true if the file of the model links to the model cache, a hidden directory of
+// the model directory, see linkModel.
+func isCacheLink(modelPath string, fileName string) bool {
+	target, err := os.Readlink(filepath.Join(modelPath, fileName))
+	if err != nil || filepath.IsAbs(target) {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Dir(modelPath), filepath.Join(modelPath, target))
+	return err == nil && strings.HasPrefix(rel, ".") && !strings.HasPrefix(rel, "..")
+}
+
+// hasPartsJournal returns true if the download of a file of the model can be resumed.
+func hasPartsJournal(modelPath string) bool {
+	return hasFileWithSuffix(modelPath, storage.PartsFileSuffix)
+}
+
+// hasFileWithSuffix returns true if a file under dir has one of the suffixes.
+func hasFileWithSuffix(dir string, suffixes ...string) bool {
+	found := false
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(entry.Name(), suffix) {
+				found = true
+				return filepath.SkipAll
+			}
+		}
+		return nil
+	})
+	return found
+}
+
+// hasDanglingLinks returns true if a file of the model links to a missing artifact of the cache.
+func hasDanglingLinks(modelPath string) bool {
+	files, err := os.ReadDir(modelPath)
+	if err != nil {
+		return false
+	}
+	for _, file := range files {
+		if file.Type()&fs.ModeSymlink == 0 {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(modelPath, file.Name())); os.IsNotExist(err) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("ReconcileModelDir", func() {
+	var modelDir string
+	var sugar *zap.SugaredLogger
+	BeforeEach(func() {
+		dir, err := os.MkdirTemp("", "reconcile")
+		Expect(err).To(BeNil())
+		modelDir = dir
+		zapLogger, _ := zap.NewProduction()
+		sugar = zapLogger.Sugar()
+	})
+	AfterEach(func() {
+		os.RemoveAll(modelDir)
+	})
+
+	spec := func(storageUri string) *v1alpha1.ModelSpec {
+		return &v1alpha1.ModelSpec{StorageURI: storageUri, Framework: "sklearn"}
+	}
+	writeFile := func(path string, content string) {
+		Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+	// writeSuccessFile writes the success file of the spec, named after the hash of the spec unless
+	// fileName is set
+	writeSuccessFile := func(modelName string, modelSpec *v1alpha1.ModelSpec, fileName string) string {
+		if fileName == "" {
+			fileName = successFilePrefix + specHash(modelSpec)
+		}
+		content, err := json.Marshal(successRecord{ModelSpec: *modelSpec})
+		Expect(err).To(BeNil())
+		path := filepath.Join(modelDir, modelName, fileName)
+		writeFile(path, string(content))
+		return path
+	}
+	successFiles := func(modelName string) []string {
+		files, err := filepath.Glob(filepath.Join(modelDir, modelName, successFilePrefix+"*"))
+		Expect(err).To(BeNil())
+		return files
+	}
+
+	Context("When the configuration is known", func() {
+		It("should repair the downloaded models and remove the partial and orphaned ones", func() {
+			desired := map[string]*v1alpha1.ModelSpec{
+				"recovered":  spec("s3://models/recovered"),
+				"renamed":    spec("s3://models/renamed"),
+				"superseded": spec("s3://models/superseded/v2"),
+				"resumable":  spec("s3://models/resumable"),
+				"unreadable": spec("s3://models/unreadable"),
+				"dangling":   spec("s3://models/dangling"),
+				"swapped":    spec("s3://models/swapped/v2"),
+			}
+			writeSuccessFile("recovered", desired["recovered"], "")
+			writeFile(filepath.Join(modelDir, "recovered", "model.joblib"), "model")
+			writeFile(filepath.Join(modelDir, "recovered", "model.joblib.parts.tmp"), "{")
+			writeSuccessFile("renamed", desired["renamed"], "SUCCESS.outdated")
+			old := writeSuccessFile("superseded", spec("s3://models/superseded/v1"), "")
+			Expect(os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))).To(Succeed())
+			writeSuccessFile("superseded", desired["superseded"], "")
+			writeFile(filepath.Join(modelDir, "resumable", "model.bin"), "mod")
+			writeFile(filepath.Join(modelDir, "resumable", "model.bin.parts"), `{"version": "1"}`)
+			writeFile(filepath.Join(modelDir, "unreadable", "SUCCESS.abc"), "{")
+			writeFile(filepath.Join(modelDir, "partial", "model.bin"), "mod")
+			writeFile(filepath.Join(modelDir, "partial", "model.bin.parts"), `{"version": "1"}`)
+			writeSuccessFile("orphaned", spec("s3://models/orphaned"), "")
+			writeSuccessFile("dangling", desired["dangling"], "")
+			Expect(os.Symlink("../.cache/missing/model.bin", filepath.Join(modelDir, "dangling", "model.bin"))).To(Succeed())
+			// the swap of the new version of model swapped was interrupted after the previous version was moved
+			writeSuccessFile(stagingDirName("swapped"), desired["swapped"], "")
+			writeSuccessFile(stagingDirName("swapped")+exchangeDirSuffix, spec("s3://models/swapped/v1"), "")
+			writeFile(filepath.Join(modelDir, stagingDirName("updated"), "model.bin"), "mod")
+			writeFile(filepath.Join(modelDir, DefaultModelCacheDirName, "key", "model.bin"), "model")
+			// the dirs which were not written by the agent are left alone
+			Expect(os.MkdirAll(filepath.Join(modelDir, "lost+found"), os.ModePerm)).To(Succeed())
+			writeFile(filepath.Join(modelDir, "server-data", "model.bin"), "mod")
+			writeFile(filepath.Join(modelDir, DefaultModelCacheDirName, "shared", "model.bin"), "model")
+			Expect(os.MkdirAll(filepath.Join(modelDir, "linked"), os.ModePerm)).To(Succeed())
+			Expect(os.Symlink("../.cache/shared/model.bin", filepath.Join(modelDir, "linked", "model.bin"))).To(Succeed())
+
+			summary, err := ReconcileModelDir(modelDir, desired, sugar)
+			Expect(err).To(BeNil())
+			Expect(summary).To(Equal(&ReconcileSummary{
+				Recovered:            4,
+				RepairedSuccessFiles: 3,
+				Resumable:            1,
+				RemovedPartial:       4,
+				RemovedOrphaned:      1,
+				RestoredSwaps:        1,
+				RemovedStaging:       1,
+				RemovedTempFiles:     1,
+				Skipped:              2,
+			}))
+
+			Expect(successFiles("recovered")).To(HaveLen(1))
+			Expect(filepath.Join(modelDir, "recovered", "model.joblib")).To(BeARegularFile())
+			Expect(filepath.Join(modelDir, "recovered", "model.joblib.parts.tmp")).NotTo(BeAnExistingFile())
+			Expect(successFiles("renamed")).To(Equal([]string{
+				filepath.Join(modelDir, "renamed", successFilePrefix+specHash(desired["renamed"])),
+			}))
+			Expect(successFiles("superseded")).To(Equal([]string{
+				filepath.Join(modelDir, "superseded", successFilePrefix+specHash(desired["superseded"])),
+			}))
+			Expect(successFiles("swapped")).To(Equal([]string{
+				filepath.Join(modelDir, "swapped", successFilePrefix+specHash(desired["swapped"])),
+			}))
+			Expect(filepath.Join(modelDir, "resumable", "model.bin.parts")).To(BeARegularFile())
+			Expect(filepath.Join(modelDir, "lost+found")).To(BeADirectory())
+			Expect(filepath.Join(modelDir, "server-data", "model.bin")).To(BeARegularFile())
+			for _, removed := range []string{"unreadable", "partial", "orphaned", "dangling", "linked", stagingDirName("updated"),
+				stagingDirName("swapped"), stagingDirName("swapped") + exchangeDirSuffix} {
+				Expect(filepath.Join(modelDir, removed)).NotTo(BeAnExistingFile())
+			}
+			Expect(filepath.Join(modelDir, DefaultModelCacheDirName, "key", "model.bin")).To(BeARegularFile())
+
+			// the repaired models are synced
+			modelTracker, err := SyncModelDir(modelDir, sugar)
+			Expect(err).To(BeNil())
+			Expect(modelTracker).To(HaveLen(4))
+			Expect(modelTracker["superseded"].Spec.StorageURI).To(Equal(desired["superseded"].StorageURI))
+		})
+	})
+
+	Context("When the configuration is unknown", func() {
+		It("should keep the models which are not configured and skip the foreign dirs", func() {
+			writeSuccessFile("model1", spec("s3://models/model1"), "")
+			writeFile(filepath.Join(modelDir, "model2", "model.bin"), "mod")
+			writeFile(filepath.Join(modelDir, "model2", "model.bin.parts"), `{"version": "1"}`)
+			writeFile(filepath.Join(modelDir, "model3", "model.bin"), "mod")
+
+			summary, err := ReconcileModelDir(modelDir, nil, sugar)
+			Expect(err).To(BeNil())
+			Expect(summary).To(Equal(&ReconcileSummary{Recovered: 1, Resumable: 1, Skipped: 1}))
+			Expect(filepath.Join(modelDir, "model1")).To(BeADirectory())
+			Expect(filepath.Join(modelDir, "model2")).To(BeADirectory())
+			Expect(filepath.Join(modelDir, "model3", "model.bin")).To(BeARegularFile())
+		})
+
+		It("should ignore a missing model dir", func() {
+			summary, err := ReconcileModelDir(filepath.Join(modelDir, "missing"), nil, sugar)
+			Expect(err).To(BeNil())
+			Expect(summary).To(Equal(&ReconcileSummary{}))
+		})
+	})
+})
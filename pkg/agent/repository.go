@@ -0,0 +1,466 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RepositoryType string
+
+const (
+	// RepositoryV2 loads the models through the v2 repository extension of KServe and Triton
+	RepositoryV2 RepositoryType = "v2"
+	// RepositoryTorchServe registers the models through the TorchServe management API
+	RepositoryTorchServe RepositoryType = "torchserve"
+	// RepositoryTFServing lists the models in the model config file polled by TF Serving
+	RepositoryTFServing RepositoryType = "tfserving"
+	// RepositoryFile leaves the models in the model directory polled by the model server
+	RepositoryFile RepositoryType = "file"
+)
+
+const (
+	DefaultTFServingConfigFile  = "models.config"
+	DefaultTFServingLoadTimeout = 2 * time.Minute
+	tfServingStatusInterval     = time.Second
+	// DefaultTorchServeManagementPort is the port of the TorchServe management API, which is not
+	// served on the inference port
+	DefaultTorchServeManagementPort = 8081
+)
+
+// ModelRepository loads the downloaded models onto the model server and unloads them.
+type ModelRepository interface {
+	// Load loads the model downloaded into modelPath
+	Load(modelName string, modelPath string) error
+	// Reload loads the new version of the model swapped into modelPath
+	Reload(modelName string, modelPath string) error
+	Unload(modelName string) error
+}
+
+// RepositoryOptions configures the model repository of the model server.
+type RepositoryOptions struct {
+	Type RepositoryType
+	// Endpoint is the url of the repository API of the model server, e.g. http://localhost:8080.
+	// For TF Serving it is the REST API used to wait for the models to become available, which is
+	// skipped if it is empty.
+	Endpoint string
+	// ConfigFile is the TF Serving model config file, DefaultTFServingConfigFile in the model
+	// directory if it is empty
+	ConfigFile string
+	// LoadTimeout bounds the wait for a model to become available on TF Serving,
+	// DefaultTFServingLoadTimeout if it is 0
+	LoadTimeout time.Duration
+}
+
+func NewModelRepository(opts RepositoryOptions, modelDir string, client *http.Client) (ModelRepository, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	endpoint := strings.TrimSuffix(opts.Endpoint, "/")
+	switch opts.Type {
+	case RepositoryV2, "":
+		return &V2ModelRepository{Endpoint: endpoint, Client: client}, nil
+	case RepositoryTorchServe:
+		return &TorchServeModelRepository{Endpoint: endpoint, Client: client}, nil
+	case RepositoryTFServing:
+		configFile := opts.ConfigFile
+		if configFile == "" {
+			configFile = filepath.Join(modelDir, DefaultTFServingConfigFile)
+		}
+		return NewTFServingModelRepository(configFile, endpoint, opts.LoadTimeout, client)
+	case RepositoryFile:
+		return FileModelRepository{}, nil
+	}
+	return nil, fmt.Errorf("unsupported model repository type %q", opts.Type)
+}
+
+// V2ModelRepository uses the v2 repository extension,
+// https://github.com/triton-inference-server/server/blob/main/docs/protocol/extension_model_repository.md
+type V2ModelRepository struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+func (r *V2ModelRepository) Load(modelName string, _ string) error {
+	return repositoryRequest(r.Client, http.MethodPost,
+		fmt.Sprintf("%s/v2/repository/models/%s/load", r.Endpoint, url.PathEscape(modelName)), bytes.NewBufferString("{}"))
+}
+
+// Reload loads the model again, the model server keeps serving the previous version until the new
+// one is loaded.
+func (r *V2ModelRepository) Reload(modelName string, modelPath string) error {
+	return r.Load(modelName, modelPath)
+}
+
+func (r *V2ModelRepository) Unload(modelName string) error {
+	return repositoryRequest(r.Client, http.MethodPost,
+		fmt.Sprintf("%s/v2/repository/models/%s/unload", r.Endpoint, url.PathEscape(modelName)), bytes.NewBufferString("{}"))
+}
+
+// TorchServeModelRepository uses the TorchServe management API. The model store of TorchServe is
+// the agent model directory, so the models are registered by their directory name.
+type TorchServeModelRepository struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+func (r *TorchServeModelRepository) Load(modelName string, _ string) error {
+	query := url.Values{}
+	query.Set("url", modelName)
+	query.Set("model_name", modelName)
+	query.Set("initial_workers", "1")
+	query.Set("synchronous", "true")
+	return repositoryRequest(r.Client, http.MethodPost, fmt.Sprintf("%s/models?%s", r.Endpoint, query.Encode()), nil)
+}
+
+// Reload registers the new version of the model next to the registered ones and makes it the
+// default version before the previous versions are unregistered, so that the model keeps being
+// served. The version is the one of the model archive manifest: the reload fails, and the
+// registered version keeps being served, if the new archive does not bump it.
+func (r *TorchServeModelRepository) Reload(modelName string, modelPath string) error {
+	previous, err := r.versions(modelName)
+	if err != nil {
+		return err
+	}
+	if err := r.Load(modelName, modelPath); err != nil {
+		var statusErr *repositoryStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusConflict {
+			return fmt.Errorf("the version of model %s is already registered, the version of the model archive must be bumped to reload it: %w",
+				modelName, err)
+		}
+		return err
+	}
+	current, err := r.versions(modelName)
+	if err != nil {
+		return err
+	}
+	for _, version := range current {
+		if slices.Contains(previous, version) {
+			continue
+		}
+		if err := repositoryRequest(r.Client, http.MethodPut, fmt.Sprintf("%s/models/%s/%s/set-default", r.Endpoint,
+			url.PathEscape(modelName), url.PathEscape(version)), nil); err != nil {
+			return err
+		}
+	}
+	for _, version := range previous {
+		if err := repositoryRequest(r.Client, http.MethodDelete, fmt.Sprintf("%s/models/%s/%s", r.Endpoint,
+			url.PathEscape(modelName), url.PathEscape(version)), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// versions returns the registered versions of the model.
+func (r *TorchServeModelRepository) versions(modelName string) ([]string, error) {
+	resp, err := r.Client.Get(fmt.Sprintf("%s/models/%s/all", r.Endpoint, url.PathEscape(modelName)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newRepositoryStatusError(resp)
+	}
+	var descriptions []struct {
+		ModelVersion string `json:"modelVersion"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&descriptions); err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0, len(descriptions))
+	for _, description := range descriptions {
+		versions = append(versions, description.ModelVersion)
+	}
+	return versions, nil
+}
+
+func (r *TorchServeModelRepository) Unload(modelName string) error {
+	return repositoryRequest(r.Client, http.MethodDelete, fmt.Sprintf("%s/models/%s", r.Endpoint, url.PathEscape(modelName)), nil)
+}
+
+// TFServingModelRepository writes the loaded models to the model config file which TF Serving
+// reloads every --model_config_file_poll_wait_seconds.
+type TFServingModelRepository struct {
+	ConfigFile  string
+	Endpoint    string
+	LoadTimeout time.Duration
+	Client      *http.Client
+	mu          sync.Mutex
+	models      map[string]string
+}
+
+func NewTFServingModelRepository(configFile string, endpoint string, loadTimeout time.Duration, client *http.Client) (*TFServingModelRepository, error) {
+	if loadTimeout <= 0 {
+		loadTimeout = DefaultTFServingLoadTimeout
+	}
+	// The models of the config file written before an agent restart are kept, so that TF Serving
+	// keeps serving them, unless their directory is gone
+	models, err := readTFServingConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+	r := &TFServingModelRepository{
+		ConfigFile:  configFile,
+		Endpoint:    endpoint,
+		LoadTimeout: loadTimeout,
+		Client:      client,
+		models:      models,
+	}
+	if err := r.writeConfig(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *TFServingModelRepository) Load(modelName string, modelPath string) error {
+	if err := r.setModel(modelName, modelPath); err != nil {
+		return err
+	}
+	if r.Endpoint == "" {
+		return nil
+	}
+	return r.waitAvailable(modelName, 0)
+}
+
+// Reload waits for the latest version of the model in modelPath to become available, TF Serving
+// picks it up from the base path of the model by itself and unloads the previous version once it
+// is. TF Serving does not reload a version it already serves, so the new version must have a new
+// version number.
+func (r *TFServingModelRepository) Reload(modelName string, modelPath string) error {
+	version, err := latestTFServingVersion(modelPath)
+	if err != nil {
+		return err
+	}
+	if r.Endpoint != "" {
+		if available, err := r.available(modelName, version); err == nil && available {
+			return fmt.Errorf("version %d of model %s is already loaded, the new version must have a new version number",
+				version, modelName)
+		}
+	}
+	if err := r.setModel(modelName, modelPath); err != nil {
+		return err
+	}
+	if r.Endpoint == "" {
+		return nil
+	}
+	return r.waitAvailable(modelName, version)
+}
+
+func (r *TFServingModelRepository) setModel(modelName string, modelPath string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.models[modelName] = modelPath
+	return r.writeConfig()
+}
+
+func (r *TFServingModelRepository) Unload(modelName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.models, modelName)
+	return r.writeConfig()
+}
+
+// writeConfig replaces the model config file, it is written to a temporary file first so that
+// TF Serving never reads a partial config.
+func (r *TFServingModelRepository) writeConfig() error {
+	names := make([]string, 0, len(r.models))
+	for name := range r.models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var config strings.Builder
+	config.WriteString("model_config_list {\n")
+	for _, name := range names {
+		fmt.Fprintf(&config, "  config {\n    name: %q\n    base_path: %q\n    model_platform: \"tensorflow\"\n  }\n",
+			name, r.models[name])
+	}
+	config.WriteString("}\n")
+	tmpFile := r.ConfigFile + ".tmp"
+	if err := os.WriteFile(tmpFile, []byte(config.String()), 0644); err != nil { // #nosec G306
+		return fmt.Errorf("failed to write the model config file: %w", err)
+	}
+	return os.Rename(tmpFile, r.ConfigFile)
+}
+
+// tfServingConfigEntry matches the models of the config files written by writeConfig
+var tfServingConfigEntry = regexp.MustCompile(`name: ("(?:[^"\\]|\\.)*")\s+base_path: ("(?:[^"\\]|\\.)*")`)
+
+// readTFServingConfig returns the base path of the models by name of the config file, leaving out
+// the models whose base path does not exist.
+func readTFServingConfig(configFile string) (map[string]string, error) {
+	models := make(map[string]string)
+	config, err := os.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		return models, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the model config file: %w", err)
+	}
+	for _, match := range tfServingConfigEntry.FindAllStringSubmatch(string(config), -1) {
+		name, nameErr := strconv.Unquote(match[1])
+		basePath, pathErr := strconv.Unquote(match[2])
+		if nameErr != nil || pathErr != nil {
+			continue
+		}
+		if _, err := os.Stat(basePath); err == nil {
+			models[name] = basePath
+		}
+	}
+	return models, nil
+}
+
+// latestTFServingVersion returns the highest version number of the model, which is the one served
+// by TF Serving.
+func latestTFServingVersion(modelPath string) (int64, error) {
+	entries, err := os.ReadDir(modelPath)
+	if err != nil {
+		return 0, err
+	}
+	latest := int64(-1)
+	for _, entry := range entries {
+		if version, err := strconv.ParseInt(entry.Name(), 10, 64); err == nil && entry.IsDir() {
+			latest = max(latest, version)
+		}
+	}
+	if latest < 0 {
+		return 0, fmt.Errorf("model %s has no version directory", modelPath)
+	}
+	return latest, nil
+}
+
+type tfServingModelStatus struct {
+	ModelVersionStatus []struct {
+		Version string `json:"version"`
+		State   string `json:"state"`
+		Status  struct {
+			ErrorCode    string `json:"error_code"`
+			ErrorMessage string `json:"error_message"`
+		} `json:"status"`
+	} `json:"model_version_status"`
+}
+
+// waitAvailable polls the model status until the version of the model is available, or any version
+// if it is 0.
+func (r *TFServingModelRepository) waitAvailable(modelName string, version int64) error {
+	deadline := time.Now().Add(r.LoadTimeout)
+	for {
+		available, err := r.available(modelName, version)
+		if available || err != nil {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("model %s is not available after %v", modelName, r.LoadTimeout)
+		}
+		time.Sleep(tfServingStatusInterval)
+	}
+}
+
+func (r *TFServingModelRepository) available(modelName string, version int64) (bool, error) {
+	resp, err := r.Client.Get(fmt.Sprintf("%s/v1/models/%s", r.Endpoint, url.PathEscape(modelName)))
+	if err != nil {
+		// the model server may not be up yet
+		return false, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// the config file is not reloaded yet
+		return false, nil
+	}
+	status := tfServingModelStatus{}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return false, err
+	}
+	for _, versionStatus := range status.ModelVersionStatus {
+		if version != 0 && versionStatus.Version != strconv.FormatInt(version, 10) {
+			continue
+		}
+		if versionStatus.State == "AVAILABLE" {
+			return true, nil
+		}
+		if versionStatus.Status.ErrorCode != "" && versionStatus.Status.ErrorCode != "OK" {
+			return false, fmt.Errorf("model server failed to load the model: %s", versionStatus.Status.ErrorMessage)
+		}
+	}
+	return false, nil
+}
+
+// FileModelRepository is used with model servers which poll the model directory themselves.
+type FileModelRepository struct{}
+
+func (FileModelRepository) Load(string, string) error {
+	return nil
+}
+
+func (FileModelRepository) Reload(string, string) error {
+	return nil
+}
+
+func (FileModelRepository) Unload(string) error {
+	return nil
+}
+
+func repositoryRequest(client *http.Client, method string, url string, body io.Reader) error {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newRepositoryStatusError(resp)
+	}
+	return nil
+}
+
+// repositoryStatusError is returned when the model server rejects a repository request.
+type repositoryStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func newRepositoryStatusError(resp *http.Response) *repositoryStatusError {
+	respBody, _ := io.ReadAll(resp.Body)
+	return &repositoryStatusError{StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(respBody))}
+}
+
+func (e *repositoryStatusError) Error() string {
+	return fmt.Sprintf("model server responded with status [%d]: %s", e.StatusCode, e.Body)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ModelRepository", func() {
+	var requests []string
+	var mu sync.Mutex
+	var statusCode int
+	var ts *httptest.Server
+	BeforeEach(func() {
+		requests = nil
+		statusCode = http.StatusOK
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests = append(requests, r.Method+" "+r.URL.RequestURI())
+			mu.Unlock()
+			w.WriteHeader(statusCode)
+			fmt.Fprint(w, `{"model_version_status":[{"state":"AVAILABLE"}]}`)
+		}))
+	})
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	Context("Using the v2 repository extension", func() {
+		It("should load and unload the models on the configured endpoint", func() {
+			repository, err := NewModelRepository(RepositoryOptions{Type: RepositoryV2, Endpoint: ts.URL + "/"}, "/mnt/models", ts.Client())
+			Expect(err).To(BeNil())
+			Expect(repository.Load("model1", "/mnt/models/model1")).To(Succeed())
+			Expect(repository.Unload("model1")).To(Succeed())
+			Expect(requests).To(Equal([]string{
+				"POST /v2/repository/models/model1/load",
+				"POST /v2/repository/models/model1/unload",
+			}))
+		})
+
+		It("should fail if the model server does not load the model", func() {
+			statusCode = http.StatusBadRequest
+			repository, err := NewModelRepository(RepositoryOptions{Endpoint: ts.URL}, "/mnt/models", ts.Client())
+			Expect(err).To(BeNil())
+			Expect(repository.Load("model1", "/mnt/models/model1")).To(MatchError(ContainSubstring("status [400]")))
+		})
+	})
+
+	Context("Using the TorchServe management API", func() {
+		It("should register and unregister the models", func() {
+			repository, err := NewModelRepository(RepositoryOptions{Type: RepositoryTorchServe, Endpoint: ts.URL}, "/mnt/models", ts.Client())
+			Expect(err).To(BeNil())
+			Expect(repository.Load("model1", "/mnt/models/model1")).To(Succeed())
+			Expect(repository.Unload("model1")).To(Succeed())
+			Expect(requests).To(Equal([]string{
+				"POST /models?initial_workers=1&model_name=model1&synchronous=true&url=model1",
+				"DELETE /models/model1",
+			}))
+		})
+
+		It("should register the new version before unregistering the previous one", func() {
+			versions := []string{"1.0"}
+			var torchServeRequests []string
+			torchServe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				torchServeRequests = append(torchServeRequests, r.Method+" "+r.URL.Path)
+				switch {
+				case r.Method == http.MethodGet:
+					descriptions := make([]string, 0, len(versions))
+					for _, version := range versions {
+						descriptions = append(descriptions, fmt.Sprintf(`{"modelVersion":%q}`, version))
+					}
+					fmt.Fprintf(w, "[%s]", strings.Join(descriptions, ","))
+				case r.Method == http.MethodPost:
+					versions = append(versions, "2.0")
+				case r.Method == http.MethodDelete:
+					versions = slices.DeleteFunc(versions, func(version string) bool {
+						return strings.HasSuffix(r.URL.Path, "/"+version)
+					})
+				}
+			}))
+			defer torchServe.Close()
+
+			repository, err := NewModelRepository(RepositoryOptions{Type: RepositoryTorchServe, Endpoint: torchServe.URL}, "/mnt/models", torchServe.Client())
+			Expect(err).To(BeNil())
+			Expect(repository.Reload("model1", "/mnt/models/model1")).To(Succeed())
+			Expect(torchServeRequests).To(Equal([]string{
+				"GET /models/model1/all",
+				"POST /models",
+				"GET /models/model1/all",
+				"PUT /models/model1/2.0/set-default",
+				"DELETE /models/model1/1.0",
+			}))
+			Expect(versions).To(Equal([]string{"2.0"}))
+		})
+
+		It("should fail to reload the model if its version is already registered", func() {
+			registered := true
+			var torchServeRequests []string
+			torchServe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				torchServeRequests = append(torchServeRequests, r.Method+" "+r.URL.Path)
+				switch {
+				case r.Method == http.MethodGet:
+					fmt.Fprint(w, `[{"modelVersion":"1.0"}]`)
+				case r.Method == http.MethodPost && registered:
+					w.WriteHeader(http.StatusConflict)
+				case r.Method == http.MethodPost:
+					registered = true
+				case r.Method == http.MethodDelete:
+					registered = false
+				}
+			}))
+			defer torchServe.Close()
+
+			repository, err := NewModelRepository(RepositoryOptions{Type: RepositoryTorchServe, Endpoint: torchServe.URL}, "/mnt/models", torchServe.Client())
+			Expect(err).To(BeNil())
+			err = repository.Reload("model1", "/mnt/models/model1")
+			Expect(err).To(MatchError(ContainSubstring("must be bumped")))
+			// the registered version is not unregistered
+			Expect(registered).To(BeTrue())
+			Expect(torchServeRequests).To(Equal([]string{
+				"GET /models/model1/all",
+				"POST /models",
+			}))
+		})
+	})
+
+	Context("Using the TF Serving model config file", func() {
+		It("should write the loaded models to the config file", func() {
+			modelDir := GinkgoT().TempDir()
+			repository, err := NewModelRepository(RepositoryOptions{Type: RepositoryTFServing, Endpoint: ts.URL}, modelDir, ts.Client())
+			Expect(err).To(BeNil())
+			configFile := filepath.Join(modelDir, DefaultTFServingConfigFile)
+			Expect(os.ReadFile(configFile)).To(BeEquivalentTo("model_config_list {\n}\n"))
+
+			Expect(repository.Load("model2", filepath.Join(modelDir, "model2"))).To(Succeed())
+			Expect(repository.Load("model1", filepath.Join(modelDir, "model1"))).To(Succeed())
+			Expect(requests).To(Equal([]string{"GET /v1/models/model2", "GET /v1/models/model1"}))
+			config, err := os.ReadFile(configFile)
+			Expect(err).To(BeNil())
+			Expect(string(config)).To(Equal(fmt.Sprintf("model_config_list {\n"+
+				"  config {\n    name: \"model1\"\n    base_path: %q\n    model_platform: \"tensorflow\"\n  }\n"+
+				"  config {\n    name: \"model2\"\n    base_path: %q\n    model_platform: \"tensorflow\"\n  }\n"+
+				"}\n", filepath.Join(modelDir, "model1"), filepath.Join(modelDir, "model2"))))
+
+			Expect(repository.Unload("model2")).To(Succeed())
+			config, err = os.ReadFile(configFile)
+			Expect(err).To(BeNil())
+			Expect(string(config)).NotTo(ContainSubstring("model2"))
+
+			// the models of the config file are kept on restart unless their directory is gone
+			Expect(os.MkdirAll(filepath.Join(modelDir, "model1"), 0777)).To(Succeed())
+			Expect(repository.Load("model3", filepath.Join(modelDir, "model3"))).To(Succeed())
+			_, err = NewModelRepository(RepositoryOptions{Type: RepositoryTFServing}, modelDir, ts.Client())
+			Expect(err).To(BeNil())
+			config, err = os.ReadFile(configFile)
+			Expect(err).To(BeNil())
+			Expect(string(config)).To(ContainSubstring(`name: "model1"`))
+			Expect(string(config)).NotTo(ContainSubstring("model3"))
+		})
+
+		It("should wait for the new version of a reloaded model", func() {
+			modelDir := GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(modelDir, "model1", "2"), 0777)).To(Succeed())
+			served := `{"model_version_status":[{"version":"1","state":"AVAILABLE"}]}`
+			polls := 0
+			tfServing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				polls++
+				if polls == 3 {
+					// the new version is picked up from the base path
+					served = `{"model_version_status":[{"version":"2","state":"AVAILABLE"},{"version":"1","state":"END"}]}`
+				}
+				fmt.Fprint(w, served)
+			}))
+			defer tfServing.Close()
+
+			repository, err := NewModelRepository(RepositoryOptions{Type: RepositoryTFServing, Endpoint: tfServing.URL}, modelDir, tfServing.Client())
+			Expect(err).To(BeNil())
+			Expect(repository.Reload("model1", filepath.Join(modelDir, "model1"))).To(Succeed())
+			Expect(polls).To(Equal(3))
+
+			// the version is already served
+			Expect(repository.Reload("model1", filepath.Join(modelDir, "model1"))).To(MatchError(ContainSubstring("already loaded")))
+		})
+	})
+
+	Context("Using an unsupported repository", func() {
+		It("should fail", func() {
+			_, err := NewModelRepository(RepositoryOptions{Type: "unknown"}, "/mnt/models", nil)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
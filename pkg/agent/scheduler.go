@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+
+	"github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+)
+
+// DownloadOrder is the order in which the models waiting for a download slot are downloaded.
+type DownloadOrder string
+
+const (
+	// DownloadOrderFIFO downloads the models in the order they are received
+	DownloadOrderFIFO DownloadOrder = "fifo"
+	// DownloadOrderSmallestFirst downloads the models with the least memory first
+	DownloadOrderSmallestFirst DownloadOrder = "smallest-first"
+	// DownloadOrderPriority downloads the models with the highest priority first
+	DownloadOrderPriority DownloadOrder = "priority"
+)
+
+// before returns true if the model of spec a is downloaded before the model of spec b.
+func (o DownloadOrder) before(a *v1alpha1.ModelSpec, b *v1alpha1.ModelSpec) bool {
+	switch o {
+	case DownloadOrderSmallestFirst:
+		return a.Memory.Cmp(b.Memory) < 0
+	case DownloadOrderPriority:
+		return a.Priority > b.Priority
+	}
+	return false
+}
+
+// DownloadScheduler bounds the number of concurrent model downloads. The download slots are
+// granted to the waiting models in the download order.
+type DownloadScheduler struct {
+	Order         DownloadOrder
+	MaxConcurrent int
+	mu            sync.Mutex
+	active        int
+	waiting       downloadQueue
+	seq           int
+}
+
+func NewDownloadScheduler(maxConcurrent int, order DownloadOrder) (*DownloadScheduler, error) {
+	switch order {
+	case DownloadOrderFIFO, DownloadOrderSmallestFirst, DownloadOrderPriority:
+	case "":
+		order = DownloadOrderFIFO
+	default:
+		return nil, fmt.Errorf("unsupported download order %q", order)
+	}
+	if maxConcurrent < 1 {
+		return nil, fmt.Errorf("the max number of concurrent downloads must be at least 1, got %d", maxConcurrent)
+	}
+	s := &DownloadScheduler{Order: order, MaxConcurrent: maxConcurrent}
+	s.waiting.order = order
+	return s, nil
+}
+
+// Acquire blocks until a download slot is granted to the model and returns the func releasing it.
+func (s *DownloadScheduler) Acquire(spec *v1alpha1.ModelSpec) func() {
+	s.mu.Lock()
+	if s.active < s.MaxConcurrent {
+		s.active++
+		s.mu.Unlock()
+		return s.release
+	}
+	s.seq++
+	waiter := &downloadWaiter{spec: spec, seq: s.seq, granted: make(chan struct{})}
+	heap.Push(&s.waiting, waiter)
+	s.mu.Unlock()
+	<-waiter.granted
+	return s.release
+}
+
+// release hands the slot over to the next waiting model.
+func (s *DownloadScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.waiting.Len() == 0 {
+		s.active--
+		return
+	}
+	waiter := heap.Pop(&s.waiting).(*downloadWaiter)
+	close(waiter.granted)
+}
+
+func (s *DownloadScheduler) waitingCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waiting.Len()
+}
+
+type downloadWaiter struct {
+	spec    *v1alpha1.ModelSpec
+	seq     int
+	granted chan struct{}
+}
+
+// downloadQueue is a heap of the waiting models, the models in the same position of the download
+// order are downloaded in the order they started waiting.
+type downloadQueue struct {
+	order   DownloadOrder
+	waiters []*downloadWaiter
+}
+
+func (q *downloadQueue) Len() int {
+	return len(q.waiters)
+}
+
+func (q *downloadQueue) Less(i, j int) bool {
+	a, b := q.waiters[i], q.waiters[j]
+	if q.order.before(a.spec, b.spec) {
+		return true
+	}
+	if q.order.before(b.spec, a.spec) {
+		return false
+	}
+	return a.seq < b.seq
+}
+
+func (q *downloadQueue) Swap(i, j int) {
+	q.waiters[i], q.waiters[j] = q.waiters[j], q.waiters[i]
+}
+
+func (q *downloadQueue) Push(x interface{}) {
+	q.waiters = append(q.waiters, x.(*downloadWaiter))
+}
+
+func (q *downloadQueue) Pop() interface{} {
+	last := q.waiters[len(q.waiters)-1]
+	q.waiters = q.waiters[:len(q.waiters)-1]
+	return last
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kserve/kserve/pkg/agent/storage"
+	"github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("DownloadScheduler", func() {
+	It("should grant the download slots in priority order", func() {
+		scheduler, err := NewDownloadScheduler(1, DownloadOrderPriority)
+		Expect(err).To(BeNil())
+		release := scheduler.Acquire(&v1alpha1.ModelSpec{})
+
+		var mu sync.Mutex
+		var granted []int32
+		var wg sync.WaitGroup
+		for _, priority := range []int32{1, 3, 2} {
+			wg.Add(1)
+			go func(priority int32) {
+				defer wg.Done()
+				release := scheduler.Acquire(&v1alpha1.ModelSpec{Priority: priority})
+				mu.Lock()
+				granted = append(granted, priority)
+				mu.Unlock()
+				release()
+			}(priority)
+		}
+		Eventually(scheduler.waitingCount).Should(Equal(3))
+		release()
+		wg.Wait()
+		Expect(granted).To(Equal([]int32{3, 2, 1}))
+	})
+
+	It("should sort the startup backlog with the smallest models first", func() {
+		op := func(name string, memory string) ModelOp {
+			return ModelOp{ModelName: name, Op: Add, Spec: &v1alpha1.ModelSpec{Memory: resource.MustParse(memory)}}
+		}
+		backlog := []ModelOp{op("large", "2Gi"), op("small", "100Mi"), {ModelName: "removed", Op: Remove}, op("medium", "1Gi")}
+		sortBacklog(backlog, DownloadOrderSmallestFirst)
+		var names []string
+		for _, op := range backlog {
+			names = append(names, op.ModelName)
+		}
+		Expect(names).To(Equal([]string{"removed", "small", "medium", "large"}))
+	})
+
+	It("should bound the number of concurrent downloads", func() {
+		var active, maxActive atomic.Int32
+		unblock := make(chan struct{})
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := active.Add(1)
+			defer active.Add(-1)
+			for {
+				observed := maxActive.Load()
+				if current <= observed || maxActive.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+			<-unblock
+			fmt.Fprint(w, "model")
+		}))
+		defer ts.Close()
+		scheduler, err := NewDownloadScheduler(2, DownloadOrderFIFO)
+		Expect(err).To(BeNil())
+		zapLogger, _ := zap.NewProduction()
+		downloader := &Downloader{
+			ModelDir: GinkgoT().TempDir(),
+			Providers: map[storage.Protocol]storage.Provider{
+				storage.HTTP: &storage.HTTPSProvider{Client: ts.Client()},
+			},
+			Scheduler: scheduler,
+			Logger:    zapLogger.Sugar(),
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(name string) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				spec := &v1alpha1.ModelSpec{StorageURI: ts.URL + "/" + name + ".joblib", Framework: "sklearn"}
+				Expect(downloader.DownloadModel(name, spec)).To(Succeed())
+			}("model" + strconv.Itoa(i))
+		}
+		Eventually(scheduler.waitingCount).Should(Equal(3))
+		Expect(active.Load()).To(Equal(int32(2)))
+		close(unblock)
+		wg.Wait()
+		Expect(maxActive.Load()).To(Equal(int32(2)))
+	})
+
+	It("should reject an unsupported download order", func() {
+		_, err := NewDownloadScheduler(1, "largest-first")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kserve/kserve/pkg/agent/storage"
+	v1 "github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+	"github.com/kserve/kserve/pkg/constants"
+)
+
+// ModelStatus is the state of a model managed by the agent, as served on constants.AgentModelStatusPath.
+type ModelStatus struct {
+	Name               string               `json:"name"`
+	State              v1.TrainedModelState `json:"state"`
+	Error              string               `json:"error,omitempty"`
+	LastTransitionTime time.Time            `json:"lastTransitionTime"`
+	// ResolvedStorageURI and ResolvedVersion are the model version the model registry uri of the
+	// model resolved to, see storage.ModelRegistryPrefixes
+	ResolvedStorageURI string `json:"resolvedStorageUri,omitempty"`
+	ResolvedVersion    string `json:"resolvedVersion,omitempty"`
+}
+
+// StatusTracker keeps the state of the models processed by the puller. It serves the state of all
+// the models on constants.AgentModelStatusPath and the state of a single model on
+// constants.AgentModelStatusPath/<name>. A model which failed to load is retried by a POST on
+// constants.AgentModelStatusPath/<name>/retry. It is served on constants.AgentModelStatusPort,
+// not on the agent port which receives the inference traffic.
+type StatusTracker struct {
+	mu     sync.RWMutex
+	models map[string]ModelStatus
+	retry  func(modelName string)
+}
+
+func NewStatusTracker() *StatusTracker {
+	return &StatusTracker{models: make(map[string]ModelStatus)}
+}
+
+// SetState records the state of the model, along with the error which made it fail to load.
+func (s *StatusTracker) SetState(modelName string, state v1.TrainedModelState, err error) {
+	status := ModelStatus{
+		Name:               modelName,
+		State:              state,
+		LastTransitionTime: time.Now(),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.models[modelName]; ok {
+		if current.State == status.State {
+			// the error of a model which keeps failing is updated without a state transition
+			status.LastTransitionTime = current.LastTransitionTime
+		}
+		status.ResolvedStorageURI, status.ResolvedVersion = current.ResolvedStorageURI, current.ResolvedVersion
+	}
+	s.models[modelName] = status
+}
+
+// SetResolvedModel records the model version the model registry uri of the model resolved to, it
+// is cleared if resolved is nil.
+func (s *StatusTracker) SetResolvedModel(modelName string, resolved *storage.ResolvedModel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.models[modelName]
+	if !ok {
+		return
+	}
+	status.ResolvedStorageURI, status.ResolvedVersion = "", ""
+	if resolved != nil {
+		status.ResolvedStorageURI, status.ResolvedVersion = resolved.StorageURI, resolved.Version
+	}
+	s.models[modelName] = status
+}
+
+// Remove forgets the model once it is unloaded.
+func (s *StatusTracker) Remove(modelName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.models, modelName)
+}
+
+func (s *StatusTracker) Get(modelName string) (ModelStatus, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status, ok := s.models[modelName]
+	return status, ok
+}
+
+// List returns the state of all the models sorted by name.
+func (s *StatusTracker) List() []ModelStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	statuses := make([]ModelStatus, 0, len(s.models))
+	for _, status := range s.models {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+func (s *StatusTracker) setRetry(retry func(modelName string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retry = retry
+}
+
+func (s *StatusTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	modelName := strings.Trim(strings.TrimPrefix(r.URL.Path, constants.AgentModelStatusPath), "/")
+	if r.Method == http.MethodPost && strings.HasSuffix(modelName, "/retry") {
+		s.serveRetry(w, strings.TrimSuffix(modelName, "/retry"))
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body interface{}
+	if modelName == "" {
+		body = s.List()
+	} else {
+		status, ok := s.Get(modelName)
+		if !ok {
+			http.Error(w, "model "+modelName+" not found", http.StatusNotFound)
+			return
+		}
+		body = status
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *StatusTracker) serveRetry(w http.ResponseWriter, modelName string) {
+	status, ok := s.Get(modelName)
+	if !ok {
+		http.Error(w, "model "+modelName+" not found", http.StatusNotFound)
+		return
+	}
+	if status.State != v1.FailedToLoad {
+		http.Error(w, "model "+modelName+" is "+string(status.State)+", only failed models are retried", http.StatusConflict)
+		return
+	}
+	s.mu.RLock()
+	retry := s.retry
+	s.mu.RUnlock()
+	if retry == nil {
+		http.Error(w, "models are not retried", http.StatusNotImplemented)
+		return
+	}
+	retry(modelName)
+	w.WriteHeader(http.StatusAccepted)
+}
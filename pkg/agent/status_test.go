@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/kserve/kserve/pkg/agent/storage"
+	"github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+	"github.com/kserve/kserve/pkg/constants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("StatusTracker", func() {
+	var tracker *StatusTracker
+	BeforeEach(func() {
+		tracker = NewStatusTracker()
+	})
+
+	Context("Serving the model states", func() {
+		It("should serve the state of all the models and of a single model", func() {
+			tracker.SetState("model2", v1alpha1.Loading, nil)
+			tracker.SetState("model1", v1alpha1.FailedToLoad, errors.New("out of memory"))
+
+			recorder := httptest.NewRecorder()
+			tracker.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, constants.AgentModelStatusPath, nil))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var statuses []ModelStatus
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &statuses)).To(Succeed())
+			Expect(statuses).To(HaveLen(2))
+			Expect(statuses[0].Name).To(Equal("model1"))
+			Expect(statuses[0].State).To(Equal(v1alpha1.FailedToLoad))
+			Expect(statuses[0].Error).To(Equal("out of memory"))
+			Expect(statuses[1].Name).To(Equal("model2"))
+
+			recorder = httptest.NewRecorder()
+			tracker.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, constants.AgentModelStatusPath+"/model2", nil))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var status ModelStatus
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &status)).To(Succeed())
+			Expect(status.State).To(Equal(v1alpha1.Loading))
+		})
+
+		It("should not find removed models", func() {
+			tracker.SetState("model1", v1alpha1.Loaded, nil)
+			tracker.Remove("model1")
+
+			recorder := httptest.NewRecorder()
+			tracker.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, constants.AgentModelStatusPath+"/model1", nil))
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("should keep the transition time while the state does not change", func() {
+			tracker.SetState("model1", v1alpha1.Downloading, nil)
+			first, _ := tracker.Get("model1")
+			tracker.SetState("model1", v1alpha1.Downloading, nil)
+			second, _ := tracker.Get("model1")
+			Expect(second.LastTransitionTime).To(Equal(first.LastTransitionTime))
+		})
+
+		It("should keep the resolved model version across state transitions", func() {
+			tracker.SetState("model1", v1alpha1.Downloading, nil)
+			tracker.SetResolvedModel("model1", &storage.ResolvedModel{StorageURI: "s3://mlflow/1/abc/artifacts/model", Version: "3"})
+			tracker.SetState("model1", v1alpha1.Loaded, nil)
+			status, _ := tracker.Get("model1")
+			Expect(status.ResolvedStorageURI).To(Equal("s3://mlflow/1/abc/artifacts/model"))
+			Expect(status.ResolvedVersion).To(Equal("3"))
+
+			tracker.SetResolvedModel("model1", nil)
+			status, _ = tracker.Get("model1")
+			Expect(status.ResolvedStorageURI).To(BeEmpty())
+			Expect(status.ResolvedVersion).To(BeEmpty())
+		})
+	})
+
+	Context("Reporting the puller results", func() {
+		It("should report the models which failed to download", func() {
+			modelDir, err := os.MkdirTemp("", "status")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(modelDir)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer ts.Close()
+			zapLogger, _ := zap.NewProduction()
+			puller := Puller{
+				channelMap:  make(map[string]*ModelChannel),
+				completions: make(chan *ModelOp, 4),
+				opStats:     make(map[string]map[OpType]int),
+				Downloader: &Downloader{
+					ModelDir: modelDir,
+					Providers: map[storage.Protocol]storage.Provider{
+						storage.HTTP: &storage.HTTPSProvider{Client: ts.Client()},
+					},
+					Logger: zapLogger.Sugar(),
+				},
+				statusTracker: tracker,
+				logger:        zapLogger.Sugar(),
+			}
+			commands := make(chan ModelOp, 1)
+			commands <- ModelOp{
+				ModelName: "model1",
+				Op:        Add,
+				Spec: &v1alpha1.ModelSpec{
+					StorageURI: ts.URL + "/model.joblib",
+					Framework:  "sklearn",
+				},
+			}
+			go puller.processCommands(commands)
+			Eventually(func() v1alpha1.TrainedModelState {
+				status, _ := tracker.Get("model1")
+				return status.State
+			}).Should(Equal(v1alpha1.FailedToLoad))
+			status, _ := tracker.Get("model1")
+			Expect(status.Error).To(ContainSubstring("failed to download model"))
+		})
+	})
+})
@@ -0,0 +1,450 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bodgit/sevenzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// ArchiveFormat is the format of a model archive.
+type ArchiveFormat string
+
+const (
+	Zip      ArchiveFormat = "zip"
+	Tar      ArchiveFormat = "tar"
+	TarGzip  ArchiveFormat = "tar.gz"
+	TarZstd  ArchiveFormat = "tar.zst"
+	TarXz    ArchiveFormat = "tar.xz"
+	SevenZip ArchiveFormat = "7z"
+)
+
+// ArchiveLimits bound the content extracted from an archive, so that a malicious or corrupted
+// archive can not fill up the model volume.
+type ArchiveLimits struct {
+	// MaxSize is the max total size of the extracted files
+	MaxSize int64
+	// MaxFiles is the max number of extracted files and directories
+	MaxFiles int
+}
+
+// DefaultArchiveLimits are the limits of the extracted archives.
+var DefaultArchiveLimits = ArchiveLimits{
+	MaxSize:  DEFAULT_MAX_DECOMPRESSION_SIZE,
+	MaxFiles: 100000,
+}
+
+func (l ArchiveLimits) Validate() error {
+	if l.MaxSize < 1 || l.MaxFiles < 1 {
+		return fmt.Errorf("the archive size and file count limits must be positive")
+	}
+	return nil
+}
+
+// archiveExtensions are matched in order, so that the extensions of compressed tarballs take
+// precedence over the extensions of their compression.
+var archiveExtensions = []struct {
+	extension string
+	format    ArchiveFormat
+}{
+	{".tar.gz", TarGzip},
+	{".tgz", TarGzip},
+	{".tar.zst", TarZstd},
+	{".tzst", TarZstd},
+	{".tar.xz", TarXz},
+	{".txz", TarXz},
+	{".tar", Tar},
+	{".zip", Zip},
+	{".7z", SevenZip},
+}
+
+var archiveContentTypes = map[string]ArchiveFormat{
+	"application/zip":              Zip,
+	"application/x-zip-compressed": Zip,
+	"application/x-tar":            Tar,
+	"application/x-gtar":           TarGzip,
+	"application/x-gzip":           TarGzip,
+	"application/gzip":             TarGzip,
+	"application/zstd":             TarZstd,
+	"application/x-zstd":           TarZstd,
+	"application/x-xz":             TarXz,
+	"application/x-7z-compressed":  SevenZip,
+}
+
+// genericContentTypes do not tell the type of the content, the file extension is used instead.
+var genericContentTypes = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+}
+
+// ArchiveFormatOf returns the archive format of a file from its content type, or from its name
+// when the content type is generic. It returns false for files which are not archives.
+func ArchiveFormatOf(contentType string, fileName string) (ArchiveFormat, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	if format, ok := archiveContentTypes[mediaType]; ok {
+		return format, true
+	}
+	if !genericContentTypes[mediaType] {
+		return "", false
+	}
+	lowerName := strings.ToLower(fileName)
+	for _, archive := range archiveExtensions {
+		if strings.HasSuffix(lowerName, archive.extension) {
+			return archive.format, true
+		}
+	}
+	return "", false
+}
+
+// Magic numbers of the compressions of tarballs
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	xzMagic   = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+)
+
+// extractArchive extracts the archive read from reader into dest.
+func extractArchive(reader io.Reader, format ArchiveFormat, dest string, limits ArchiveLimits) error {
+	e, err := newExtractor(dest, limits)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case Tar, TarGzip, TarZstd, TarXz:
+		// tarballs are often served with the content type of another compression, so it is
+		// detected from the content
+		return e.extractTarball(bufio.NewReader(reader))
+	case Zip, SevenZip:
+		// the file lists of zip and 7z archives are at their end, so they are spooled to disk
+		spool, size, err := spoolArchive(reader, e.dest)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = spool.Close()
+			_ = os.Remove(spool.Name())
+		}()
+		if format == Zip {
+			return e.extractZip(spool, size)
+		}
+		return e.extractSevenZip(spool, size)
+	}
+	return fmt.Errorf("unsupported archive format %s", format)
+}
+
+func (e *extractor) extractTarball(reader *bufio.Reader) error {
+	magic, _ := reader.Peek(len(xzMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzr, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gzr.Close()
+		return e.extractTar(gzr)
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		return e.extractTar(zr)
+	case bytes.HasPrefix(magic, xzMagic):
+		xzr, err := xz.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		return e.extractTar(xzr)
+	}
+	return e.extractTar(reader)
+}
+
+// spoolArchive writes the archive to a hidden temporary file next to dest, on the same volume.
+func spoolArchive(reader io.Reader, dest string) (*os.File, int64, error) {
+	spool, err := os.CreateTemp(filepath.Dir(filepath.Clean(dest)), ".archive-")
+	if err != nil {
+		return nil, 0, err
+	}
+	size, err := io.Copy(spool, reader)
+	if err != nil {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+		return nil, 0, fmt.Errorf("unable to read the archive: %w", err)
+	}
+	return spool, size, nil
+}
+
+// extractor writes the entries of an archive into dest, rejecting the entries which would be
+// written outside of dest, including through links, and the archives exceeding the limits.
+type extractor struct {
+	dest    string
+	limits  ArchiveLimits
+	files   int
+	written int64
+}
+
+// newExtractor creates dest and returns an extractor into it. dest is resolved so that the
+// targets of the links can be compared with it.
+func newExtractor(dest string, limits ArchiveLimits) (*extractor, error) {
+	if err := os.MkdirAll(dest, os.ModePerm); err != nil {
+		return nil, err
+	}
+	resolved, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return nil, err
+	}
+	return &extractor{dest: resolved, limits: limits}, nil
+}
+
+// target returns the path of an archive entry in dest.
+func (e *extractor) target(name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("%s: illegal file path", name)
+	}
+	target := filepath.Join(e.dest, name) // #nosec G305
+	if target == e.dest {
+		return target, nil
+	}
+	if !strings.HasPrefix(target, e.dest+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%s: illegal file path", name)
+	}
+	// the parent directories may be links extracted before
+	parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+	if err == nil && parent != e.dest && !strings.HasPrefix(parent, e.dest+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%s: illegal file path through a link", name)
+	}
+	return target, nil
+}
+
+func (e *extractor) count() error {
+	e.files++
+	if e.files > e.limits.MaxFiles {
+		return fmt.Errorf("the archive has more than %d files", e.limits.MaxFiles)
+	}
+	return nil
+}
+
+func (e *extractor) mkdir(name string) error {
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	if err := e.count(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return fmt.Errorf("unable to create new directory %s", target)
+	}
+	return nil
+}
+
+func (e *extractor) writeFile(name string, reader io.Reader) error {
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	if err := e.count(); err != nil {
+		return err
+	}
+	file, err := createNewFile(target)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		closeErr := file.Close()
+		if closeErr != nil {
+			log.Error(closeErr, "failed to close file")
+		}
+	}(file)
+	remaining := e.limits.MaxSize - e.written
+	n, err := io.CopyN(file, reader, remaining+1)
+	e.written += n
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("unable to copy contents to %s: %w", name, err)
+	}
+	if e.written > e.limits.MaxSize {
+		return fmt.Errorf("the archive content exceeds %d bytes", e.limits.MaxSize)
+	}
+	return nil
+}
+
+// symlink creates a link which must resolve inside dest, to neither dest nor a directory
+// containing the link.
+func (e *extractor) symlink(name string, linkTarget string) error {
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	if filepath.IsAbs(linkTarget) {
+		return fmt.Errorf("%s: illegal link to %s", name, linkTarget)
+	}
+	// the parent directories and the link target may go through links extracted before
+	parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+	if err != nil {
+		parent = filepath.Dir(target)
+	}
+	resolved, err := filepath.EvalSymlinks(parent + string(os.PathSeparator) + linkTarget)
+	if err != nil {
+		resolved = filepath.Join(parent, linkTarget)
+	}
+	if !strings.HasPrefix(resolved, e.dest+string(os.PathSeparator)) ||
+		parent == resolved || strings.HasPrefix(parent, resolved+string(os.PathSeparator)) {
+		return fmt.Errorf("%s: illegal link to %s", name, linkTarget)
+	}
+	if err := e.count(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("file is unable to be deleted: %w", err)
+	}
+	return os.Symlink(linkTarget, target)
+}
+
+func (e *extractor) extractTar(reader io.Reader) error {
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("unable to access next tar file: %w", err)
+		}
+		if err := e.extractTarEntry(header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// extractTarEntry extracts a tar entry, whose content is read from reader.
+func (e *extractor) extractTarEntry(header *tar.Header, reader io.Reader) error {
+	var err error
+	switch header.Typeflag {
+	case tar.TypeDir:
+		err = e.mkdir(header.Name)
+	case tar.TypeReg:
+		err = e.writeFile(header.Name, reader)
+	case tar.TypeSymlink:
+		err = e.symlink(header.Name, header.Linkname)
+	case tar.TypeLink:
+		// hard links are extracted as links to the file they refer to in the archive
+		var linkTarget string
+		if linkTarget, err = e.target(header.Linkname); err == nil {
+			var target string
+			if target, err = e.target(header.Name); err == nil {
+				err = e.symlink(header.Name, relativeLink(target, linkTarget))
+			}
+		}
+	case tar.TypeXGlobalHeader:
+	default:
+		log.Info("Skipping the archive entry which is not a file, a directory or a link", "name", header.Name)
+	}
+	return err
+}
+
+// remove removes an entry extracted before, along with its content if it is a directory.
+func (e *extractor) remove(name string) error {
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	if target == e.dest {
+		return fmt.Errorf("%s: illegal file path", name)
+	}
+	return os.RemoveAll(target)
+}
+
+func relativeLink(from string, to string) string {
+	relative, err := filepath.Rel(filepath.Dir(from), to)
+	if err != nil {
+		return to
+	}
+	return relative
+}
+
+func (e *extractor) extractZip(reader io.ReaderAt, size int64) error {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return fmt.Errorf("unable to create new reader: %w", err)
+	}
+	for _, zipFile := range zipReader.File {
+		if err := e.extractEntry(zipFile.Name, zipFile.Mode(), zipFile.Open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *extractor) extractSevenZip(reader io.ReaderAt, size int64) error {
+	sevenZipReader, err := sevenzip.NewReader(reader, size)
+	if err != nil {
+		return fmt.Errorf("unable to create new reader: %w", err)
+	}
+	for _, sevenZipFile := range sevenZipReader.File {
+		if err := e.extractEntry(sevenZipFile.Name, sevenZipFile.FileInfo().Mode(), sevenZipFile.Open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractEntry extracts an entry of a zip or 7z archive, the target of their links is their content.
+func (e *extractor) extractEntry(name string, mode fs.FileMode, open func() (io.ReadCloser, error)) error {
+	if mode.IsDir() {
+		return e.mkdir(name)
+	}
+	if !mode.IsRegular() && mode&fs.ModeSymlink == 0 {
+		log.Info("Skipping the archive entry which is not a file, a directory or a link", "name", name)
+		return nil
+	}
+	rc, err := open()
+	if err != nil {
+		return fmt.Errorf("unable to open file: %w", err)
+	}
+	defer func(rc io.ReadCloser) {
+		closeErr := rc.Close()
+		if closeErr != nil {
+			log.Error(closeErr, "failed to close reader")
+		}
+	}(rc)
+	if mode&fs.ModeSymlink != 0 {
+		linkTarget, err := io.ReadAll(io.LimitReader(rc, 4096))
+		if err != nil {
+			return err
+		}
+		return e.symlink(name, string(linkTarget))
+	}
+	return e.writeFile(name, rc)
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/kserve/kserve/pkg/credentials/https"
+	"github.com/onsi/gomega"
+	"github.com/ulikunitz/xz"
+)
+
+// sevenZipArchive holds the files bar and foo, whose contents are their names and a newline.
+const sevenZipArchive = "N3q8ryccAASgR6WICAAAAAAAAABmAAAAAAAAAN2R8/FiYXIKZm9vCgEEBgACCQQEAAcLAgABAQABAQAMBAQACAoB6bOiBKhlMn4AAAUCGQUAAAAAABERAGIAYQByAAAAZgBvAG8AAAAZAgAAFBIBAACFM3PyY9YBAFgCcvJj1gEVCgEAIICkgSCApIEAAA=="
+
+type archiveEntry struct {
+	name     string
+	content  string
+	linkname string
+	typeflag byte
+}
+
+func tarArchive(t *testing.T, entries ...archiveEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		typeflag := entry.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		header := &tar.Header{Name: entry.name, Linkname: entry.linkname, Typeflag: typeflag, Mode: 0644, Size: int64(len(entry.content))}
+		if typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil && typeflag == tar.TypeReg {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func compress(t *testing.T, data []byte, newWriter func(io.Writer) (io.WriteCloser, error)) []byte {
+	var buf bytes.Buffer
+	w, err := newWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, entries ...archiveEntry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := zw.Create(entry.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveFormatOf(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	scenarios := map[string]struct {
+		contentType string
+		fileName    string
+		format      ArchiveFormat
+		archive     bool
+	}{
+		"ZipContentType":       {contentType: "application/zip", fileName: "model", format: Zip, archive: true},
+		"GzipContentType":      {contentType: "application/gzip; charset=binary", fileName: "model", format: TarGzip, archive: true},
+		"SevenZipContentType":  {contentType: "application/x-7z-compressed", fileName: "model", format: SevenZip, archive: true},
+		"TarGzipExtension":     {contentType: "application/octet-stream", fileName: "model.tar.gz", format: TarGzip, archive: true},
+		"TarZstdExtension":     {contentType: "", fileName: "model.TZST", format: TarZstd, archive: true},
+		"TarXzExtension":       {contentType: "binary/octet-stream", fileName: "model.tar.xz", format: TarXz, archive: true},
+		"TarExtension":         {contentType: "application/octet-stream", fileName: "model.tar", format: Tar, archive: true},
+		"SpecificContentType":  {contentType: "text/plain", fileName: "model.zip"},
+		"GenericContentType":   {contentType: "application/octet-stream", fileName: "model.joblib"},
+		"ContentTypeFirst":     {contentType: "application/x-xz", fileName: "model.zip", format: TarXz, archive: true},
+		"UppercaseContentType": {contentType: "Application/ZIP", fileName: "model", format: Zip, archive: true},
+	}
+	for name, scenario := range scenarios {
+		format, archive := ArchiveFormatOf(scenario.contentType, scenario.fileName)
+		g.Expect(archive).To(gomega.Equal(scenario.archive), name)
+		g.Expect(format).To(gomega.Equal(scenario.format), name)
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	tarball := tarArchive(t,
+		archiveEntry{name: "1/", typeflag: tar.TypeDir},
+		archiveEntry{name: "1/model.joblib", content: "model"},
+		archiveEntry{name: "latest", linkname: "1", typeflag: tar.TypeSymlink},
+		archiveEntry{name: "model.joblib", linkname: "1/model.joblib", typeflag: tar.TypeLink},
+	)
+	sevenZip, err := base64.StdEncoding.DecodeString(sevenZipArchive)
+	g.Expect(err).To(gomega.BeNil())
+
+	scenarios := map[string]struct {
+		format  ArchiveFormat
+		archive []byte
+		files   map[string]string
+	}{
+		"Tar": {
+			format:  Tar,
+			archive: tarball,
+			files:   map[string]string{"1/model.joblib": "model", "latest/model.joblib": "model", "model.joblib": "model"},
+		},
+		"TarGzip": {
+			format: TarGzip,
+			archive: compress(t, tarball, func(w io.Writer) (io.WriteCloser, error) {
+				return gzip.NewWriter(w), nil
+			}),
+			files: map[string]string{"1/model.joblib": "model"},
+		},
+		"TarZstd": {
+			format: TarZstd,
+			archive: compress(t, tarball, func(w io.Writer) (io.WriteCloser, error) {
+				return zstd.NewWriter(w)
+			}),
+			files: map[string]string{"1/model.joblib": "model"},
+		},
+		"TarXz": {
+			format: TarXz,
+			archive: compress(t, tarball, func(w io.Writer) (io.WriteCloser, error) {
+				return xz.NewWriter(w)
+			}),
+			files: map[string]string{"1/model.joblib": "model"},
+		},
+		"GzipServedAsTar": {
+			format: Tar,
+			archive: compress(t, tarball, func(w io.Writer) (io.WriteCloser, error) {
+				return gzip.NewWriter(w), nil
+			}),
+			files: map[string]string{"1/model.joblib": "model"},
+		},
+		"Zip": {
+			format:  Zip,
+			archive: zipArchive(t, archiveEntry{name: "1/model.joblib", content: "model"}, archiveEntry{name: "config.json", content: "{}"}),
+			files:   map[string]string{"1/model.joblib": "model", "config.json": "{}"},
+		},
+		"SevenZip": {
+			format:  SevenZip,
+			archive: sevenZip,
+			files:   map[string]string{"bar": "bar\n", "foo": "foo\n"},
+		},
+	}
+	for name, scenario := range scenarios {
+		dest := filepath.Join(t.TempDir(), "model")
+		g.Expect(extractArchive(bytes.NewReader(scenario.archive), scenario.format, dest, DefaultArchiveLimits)).To(gomega.Succeed(), name)
+		for file, content := range scenario.files {
+			g.Expect(os.ReadFile(filepath.Join(dest, file))).To(gomega.Equal([]byte(content)), name)
+		}
+		// the zip and 7z archives are not left behind
+		entries, err := os.ReadDir(filepath.Dir(dest))
+		g.Expect(err).To(gomega.BeNil())
+		g.Expect(entries).To(gomega.HaveLen(1), name)
+	}
+}
+
+func TestExtractArchiveSafety(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	scenarios := map[string]struct {
+		format  ArchiveFormat
+		archive []byte
+		limits  ArchiveLimits
+	}{
+		"TarTraversal": {
+			format:  Tar,
+			archive: tarArchive(t, archiveEntry{name: "../escape", content: "escape"}),
+		},
+		"TarAbsolutePath": {
+			format:  Tar,
+			archive: tarArchive(t, archiveEntry{name: "/escape", content: "escape"}),
+		},
+		"ZipTraversal": {
+			format:  Zip,
+			archive: zipArchive(t, archiveEntry{name: "1/../../escape", content: "escape"}),
+		},
+		"SymlinkEscape": {
+			format:  Tar,
+			archive: tarArchive(t, archiveEntry{name: "escape", linkname: "../", typeflag: tar.TypeSymlink}),
+		},
+		"AbsoluteSymlink": {
+			format:  Tar,
+			archive: tarArchive(t, archiveEntry{name: "passwd", linkname: "/etc/passwd", typeflag: tar.TypeSymlink}),
+		},
+		"HardLinkEscape": {
+			format:  Tar,
+			archive: tarArchive(t, archiveEntry{name: "passwd", linkname: "../../etc/passwd", typeflag: tar.TypeLink}),
+		},
+		"WriteThroughSymlink": {
+			format: Tar,
+			archive: tarArchive(t,
+				archiveEntry{name: "dir", linkname: ".", typeflag: tar.TypeSymlink},
+				archiveEntry{name: "outside", linkname: "..", typeflag: tar.TypeSymlink},
+				archiveEntry{name: "outside/escape", content: "escape"},
+			),
+		},
+		"SymlinkThroughSymlink": {
+			format: Tar,
+			archive: tarArchive(t,
+				archiveEntry{name: "s", linkname: ".", typeflag: tar.TypeSymlink},
+				archiveEntry{name: "s/l", linkname: "../escape", typeflag: tar.TypeSymlink},
+				archiveEntry{name: "s/l", content: "escape"},
+			),
+		},
+		"SymlinkToParent": {
+			format: Tar,
+			archive: tarArchive(t,
+				archiveEntry{name: "a/b", linkname: "..", typeflag: tar.TypeSymlink},
+				archiveEntry{name: "a/b/c/escape", content: "escape"},
+			),
+		},
+		"SymlinkTargetThroughSymlink": {
+			format: Tar,
+			archive: tarArchive(t,
+				archiveEntry{name: "a/c", linkname: "../b", typeflag: tar.TypeSymlink},
+				archiveEntry{name: "b/d", linkname: "../a/c/..", typeflag: tar.TypeSymlink},
+				archiveEntry{name: "b/d/l", linkname: "../../escape", typeflag: tar.TypeSymlink},
+				archiveEntry{name: "b/d/l", content: "escape"},
+			),
+		},
+		"MaxSize": {
+			format:  Tar,
+			archive: tarArchive(t, archiveEntry{name: "a", content: "12345"}, archiveEntry{name: "b", content: "67890"}),
+			limits:  ArchiveLimits{MaxSize: 8, MaxFiles: 10},
+		},
+		"MaxSizeZip": {
+			format:  Zip,
+			archive: zipArchive(t, archiveEntry{name: "a", content: "1234567890"}),
+			limits:  ArchiveLimits{MaxSize: 8, MaxFiles: 10},
+		},
+		"MaxFiles": {
+			format:  Tar,
+			archive: tarArchive(t, archiveEntry{name: "a", content: "a"}, archiveEntry{name: "b", content: "b"}),
+			limits:  ArchiveLimits{MaxSize: 10, MaxFiles: 1},
+		},
+	}
+	for name, scenario := range scenarios {
+		root := t.TempDir()
+		dest := filepath.Join(root, "models", "model")
+		limits := scenario.limits
+		if limits.MaxFiles == 0 {
+			limits = DefaultArchiveLimits
+		}
+		g.Expect(extractArchive(bytes.NewReader(scenario.archive), scenario.format, dest, limits)).NotTo(gomega.Succeed(), name)
+		g.Expect(filepath.Join(root, "escape")).NotTo(gomega.BeAnExistingFile(), name)
+		g.Expect(filepath.Join(root, "models", "escape")).NotTo(gomega.BeAnExistingFile(), name)
+	}
+
+	// the limits are inclusive
+	dest := filepath.Join(t.TempDir(), "model")
+	archive := tarArchive(t, archiveEntry{name: "a", content: "12345"}, archiveEntry{name: "b", content: "678"})
+	g.Expect(extractArchive(bytes.NewReader(archive), Tar, dest, ArchiveLimits{MaxSize: 8, MaxFiles: 2})).To(gomega.Succeed())
+	g.Expect(ArchiveLimits{MaxSize: 0, MaxFiles: 1}.Validate()).NotTo(gomega.Succeed())
+}
+
+func TestHTTPSProviderArchives(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	tarball := compress(t, tarArchive(t, archiveEntry{name: "model.joblib", content: "model"}), func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w)
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(tarball)
+	}))
+	defer server.Close()
+
+	// the headers are read from the secret of the host
+	secretsDir := t.TempDir()
+	for secret, host := range map[string]string{"other": "example.com", "models": "127.0.0.1"} {
+		g.Expect(os.MkdirAll(filepath.Join(secretsDir, secret), 0755)).To(gomega.Succeed())
+		g.Expect(os.WriteFile(filepath.Join(secretsDir, secret, https.HTTPSHost), []byte(host), 0644)).To(gomega.Succeed())
+		headers := fmt.Sprintf("{\nAuthorization: Bearer %s\n}", secret)
+		if secret == "models" {
+			headers = `{"Authorization": "Bearer token"}`
+		}
+		g.Expect(os.WriteFile(filepath.Join(secretsDir, secret, https.HEADERS), []byte(headers), 0644)).To(gomega.Succeed())
+	}
+	modelDir := t.TempDir()
+	provider := &HTTPSProvider{Client: server.Client(), SecretsDir: secretsDir}
+	g.Expect(provider.DownloadModel(modelDir, "model", server.URL+"/model.tar.zst", nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "model", "model.joblib"))).To(gomega.Equal([]byte("model")))
+
+	// the limits of the provider take precedence over the default limits
+	provider.ArchiveLimits = &ArchiveLimits{MaxSize: 4, MaxFiles: 1}
+	g.Expect(provider.DownloadModel(modelDir, "limited", server.URL+"/model.tar.zst", nil)).NotTo(gomega.Succeed())
+
+	// the deprecated environment variables are still read
+	provider = &HTTPSProvider{Client: server.Client(), SecretsDir: filepath.Join(secretsDir, "missing")}
+	g.Expect(provider.DownloadModel(modelDir, "unauthorized", server.URL+"/model.tar.zst", nil)).NotTo(gomega.Succeed())
+	t.Setenv("127.0.0.1"+HEADER_SUFFIX, "{\nAuthorization: Bearer token\n}")
+	g.Expect(provider.DownloadModel(modelDir, "env", server.URL+"/model.tar.zst", nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "env", "model.joblib"))).To(gomega.Equal([]byte("model")))
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	azurecredential "github.com/kserve/kserve/pkg/credentials/azure"
+)
+
+// AzureBlobURIRegex matches the Azure Blob Storage URIs, https://{account}.blob.core.windows.net/{container}/{path}.
+// The URIs with a query, such as the presigned SAS URLs, are not matched: they are downloaded as https URIs.
+var AzureBlobURIRegex = regexp.MustCompile(`^https://([a-z0-9]+)\.blob\.core\.windows\.net/([^/?]+)/?([^?]*)$`)
+
+// AzureProvider downloads the blobs under a path of an Azure Blob Storage container. It uses the
+// storage account access key if AZURE_STORAGE_ACCESS_KEY is set, else the service principal if
+// AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET are set, else anonymous access.
+type AzureProvider struct {
+	// ServiceURL is the blob service URL of the storage account, which is substituted for {account}
+	// if it is present, e.g. http://127.0.0.1:10000/{account} for Azurite. It is
+	// https://{account}.blob.core.windows.net if empty.
+	ServiceURL string
+	// Client sends the requests to the blob service, http.DefaultClient if nil
+	Client *http.Client
+	// Limiter limits the download throughput, it is unlimited if nil
+	Limiter *BandwidthLimiter
+}
+
+var _ Provider = (*AzureProvider)(nil)
+
+func (p *AzureProvider) DownloadModel(modelDir string, modelName string, storageUri string, checksum *Checksum) error {
+	log.Info("Download model ", "modelName", modelName, "storageUri", storageUri, "modelDir", modelDir)
+	match := AzureBlobURIRegex.FindStringSubmatch(storageUri)
+	if match == nil {
+		return fmt.Errorf("invalid Azure Blob Storage uri %s", storageUri)
+	}
+	account, container, prefix := match[1], match[2], match[3]
+	client, err := p.newClient(account)
+	if err != nil {
+		return fmt.Errorf("unable to create the Azure Blob Storage client: %w", err)
+	}
+	downloader := &AzureBlobDownloader{
+		Context:    context.Background(),
+		StorageUri: storageUri,
+		ModelDir:   modelDir,
+		ModelName:  modelName,
+		Container:  container,
+		Prefix:     prefix,
+		limiter:    p.Limiter,
+	}
+	if err := downloader.Download(client); err != nil {
+		return err
+	}
+	downloadDigest := ""
+	if checksum != nil && checksum.Digest != "" && len(downloader.fileNames) == 1 {
+		if downloadDigest, err = FileDigest(downloader.fileNames[0]); err != nil {
+			return err
+		}
+	}
+	return verifyDownload(filepath.Join(modelDir, modelName), checksum, downloadDigest)
+}
+
+func (p *AzureProvider) newClient(account string) (*azblob.Client, error) {
+	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	if p.ServiceURL != "" {
+		serviceURL = strings.ReplaceAll(p.ServiceURL, "{account}", account)
+	}
+	options := &azblob.ClientOptions{}
+	if p.Client != nil {
+		options.Transport = p.Client
+	}
+	if accessKey := os.Getenv(azurecredential.AzureStorageAccessKey); accessKey != "" {
+		credential, err := azblob.NewSharedKeyCredential(account, accessKey)
+		if err != nil {
+			return nil, err
+		}
+		return azblob.NewClientWithSharedKeyCredential(serviceURL, credential, options)
+	}
+	if credential, err := servicePrincipalCredential(); err != nil {
+		return nil, err
+	} else if credential != nil {
+		return azblob.NewClient(serviceURL, credential, options)
+	}
+	return azblob.NewClientWithNoCredential(serviceURL, options)
+}
+
+// servicePrincipalCredential returns nil if the service principal env vars are not set.
+func servicePrincipalCredential() (azcore.TokenCredential, error) {
+	tenantId := os.Getenv(azurecredential.AzureTenantId)
+	clientId := os.Getenv(azurecredential.AzureClientId)
+	clientSecret := os.Getenv(azurecredential.AzureClientSecret)
+	if tenantId == "" || clientId == "" || clientSecret == "" {
+		return nil, nil
+	}
+	return azidentity.NewClientSecretCredential(tenantId, clientId, clientSecret, nil)
+}
+
+type AzureBlobDownloader struct {
+	Context    context.Context
+	StorageUri string
+	ModelDir   string
+	ModelName  string
+	Container  string
+	Prefix     string
+	// fileNames are the files the blobs were downloaded to
+	fileNames []string
+	limiter   *BandwidthLimiter
+}
+
+// Download downloads the blobs under the prefix, keeping their path relative to the prefix. A blob
+// whose name is the prefix is downloaded to a file of the same base name.
+func (a *AzureBlobDownloader) Download(client *azblob.Client) error {
+	pager := client.NewListBlobsFlatPager(a.Container, &azblob.ListBlobsFlatOptions{Prefix: &a.Prefix})
+	foundBlob := false
+	for pager.More() {
+		page, err := pager.NextPage(a.Context)
+		if err != nil {
+			return fmt.Errorf("unable to list the blobs of %s: %w", a.StorageUri, err)
+		}
+		for _, blob := range page.Segment.BlobItems {
+			if blob.Name == nil || strings.HasSuffix(*blob.Name, "/") {
+				continue
+			}
+			foundBlob = true
+			if err := a.downloadBlob(client, *blob.Name); err != nil {
+				return err
+			}
+		}
+	}
+	if !foundBlob {
+		return fmt.Errorf("%s has no blobs or does not exist", a.StorageUri)
+	}
+	return nil
+}
+
+func (a *AzureBlobDownloader) fileName(blobName string) string {
+	relativePath := strings.TrimPrefix(blobName, a.Prefix)
+	if relativePath == "" {
+		relativePath = path.Base(blobName)
+	}
+	return filepath.Join(a.ModelDir, a.ModelName, filepath.FromSlash(relativePath))
+}
+
+func (a *AzureBlobDownloader) downloadBlob(client *azblob.Client, blobName string) error {
+	fileName := a.fileName(blobName)
+	if FileExists(fileName) {
+		if err := os.Remove(fileName); err != nil {
+			return fmt.Errorf("file is unable to be deleted: %w", err)
+		}
+	}
+	file, err := Create(fileName)
+	if err != nil {
+		return fmt.Errorf("file is already created: %w", err)
+	}
+	defer func(file *os.File) {
+		closeErr := file.Close()
+		if closeErr != nil {
+			log.Error(closeErr, "failed to close file")
+		}
+	}(file)
+	a.fileNames = append(a.fileNames, fileName)
+	resp, err := client.DownloadStream(a.Context, a.Container, blobName, nil)
+	if err != nil {
+		return fmt.Errorf("failed to download blob %s of container %s: %w", blobName, a.Container, err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(file, a.limiter.Reader(resp.Body)); err != nil {
+		return fmt.Errorf("failed to write blob %s to file %s: %w", blobName, fileName, err)
+	}
+	return nil
+}
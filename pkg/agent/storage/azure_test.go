@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+// newAzuriteServer serves the blobs of a container like Azurite, with path style urls
+// /{account}/{container}/{blob} and one blob per page of the blob listing.
+func newAzuriteServer(account string, container string, blobs map[string]string) *httptest.Server {
+	names := make([]string, 0, len(blobs))
+	for name := range blobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		containerPath := "/" + account + "/" + container
+		if r.URL.Path == containerPath && r.URL.Query().Get("comp") == "list" {
+			var matching []string
+			for _, name := range names {
+				if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+					matching = append(matching, name)
+				}
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("marker"))
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="%s"><Blobs>`, container)
+			if page < len(matching) {
+				fmt.Fprintf(w, `<Blob><Name>%s</Name><Properties><BlobType>BlockBlob</BlobType></Properties></Blob>`, matching[page])
+			}
+			nextMarker := ""
+			if page+1 < len(matching) {
+				nextMarker = strconv.Itoa(page + 1)
+			}
+			fmt.Fprintf(w, `</Blobs><NextMarker>%s</NextMarker></EnumerationResults>`, nextMarker)
+			return
+		}
+		content, ok := blobs[strings.TrimPrefix(r.URL.Path, containerPath+"/")]
+		if !ok || r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			_ = xml.NewEncoder(w).Encode(struct {
+				XMLName xml.Name `xml:"Error"`
+				Code    string   `xml:"Code"`
+			}{Code: "BlobNotFound"})
+			return
+		}
+		w.Header().Set("x-ms-blob-type", "BlockBlob")
+		fmt.Fprint(w, content)
+	}))
+}
+
+func TestAzureProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	t.Setenv("AZURE_STORAGE_ACCESS_KEY", "")
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+	ts := newAzuriteServer("devstoreaccount1", "models", map[string]string{
+		"sklearn/iris/model.joblib":      "model",
+		"sklearn/iris/config/model.json": "{}",
+		"sklearn/iris/":                  "",
+		"sklearn/other/model.joblib":     "other",
+	})
+	defer ts.Close()
+	provider := &AzureProvider{ServiceURL: ts.URL + "/{account}", Client: ts.Client()}
+	modelDir := t.TempDir()
+
+	err := provider.DownloadModel(modelDir, "iris", "https://devstoreaccount1.blob.core.windows.net/models/sklearn/iris/", nil)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "iris", "model.joblib"))).To(gomega.BeEquivalentTo("model"))
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "iris", "config", "model.json"))).To(gomega.BeEquivalentTo("{}"))
+	g.Expect(filepath.Join(modelDir, "iris", "other")).NotTo(gomega.BeAnExistingFile())
+
+	// A single blob is downloaded to a file of the same name, and verified against the digest
+	checksum := &Checksum{Digest: "sha256:" + strings.Repeat("0", 64)}
+	err = provider.DownloadModel(modelDir, "other", "https://devstoreaccount1.blob.core.windows.net/models/sklearn/other/model.joblib", checksum)
+	g.Expect(err).To(gomega.HaveOccurred())
+	checksum.Digest, err = FileDigest(filepath.Join(modelDir, "iris", "model.joblib"))
+	g.Expect(err).To(gomega.BeNil())
+	err = provider.DownloadModel(modelDir, "iris2", "https://devstoreaccount1.blob.core.windows.net/models/sklearn/iris/model.joblib", checksum)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "iris2", "model.joblib"))).To(gomega.BeEquivalentTo("model"))
+
+	err = provider.DownloadModel(modelDir, "missing", "https://devstoreaccount1.blob.core.windows.net/models/missing", nil)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestAzureBlobURIRegex(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	match := AzureBlobURIRegex.FindStringSubmatch("https://account.blob.core.windows.net/container/path/to/model")
+	g.Expect(match).To(gomega.Equal([]string{"https://account.blob.core.windows.net/container/path/to/model", "account", "container", "path/to/model"}))
+	g.Expect(AzureBlobURIRegex.MatchString("https://example.com/container/model")).To(gomega.BeFalse())
+	g.Expect(AzureBlobURIRegex.MatchString("https://account.blob.core.windows.net/container/model?sv=2022-11-02&sig=c2lnbmF0dXJl")).To(gomega.BeFalse())
+}
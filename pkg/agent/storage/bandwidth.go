@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// BandwidthLimiter limits the download throughput of a provider, it is shared by all the
+// downloads of the provider.
+type BandwidthLimiter struct {
+	limiter *rate.Limiter
+}
+
+// NewBandwidthLimiter limits the throughput to bytesPerSecond, which must be positive.
+func NewBandwidthLimiter(bytesPerSecond int64) *BandwidthLimiter {
+	burst := bytesPerSecond
+	if burst > math.MaxInt32 {
+		burst = math.MaxInt32
+	}
+	return &BandwidthLimiter{limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), int(burst))}
+}
+
+// wait blocks until n more bytes can be transferred.
+func (l *BandwidthLimiter) wait(n int) error {
+	for n > 0 {
+		chunk := min(n, l.limiter.Burst())
+		if err := l.limiter.WaitN(context.Background(), chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// Reader limits the throughput of r, r is returned as it is if the limiter is nil.
+func (l *BandwidthLimiter) Reader(r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &limitedReader{reader: r, limiter: l}
+}
+
+// WriterAt limits the throughput of w, w is returned as it is if the limiter is nil.
+func (l *BandwidthLimiter) WriterAt(w io.WriterAt) io.WriterAt {
+	if l == nil {
+		return w
+	}
+	return &limitedWriterAt{writer: w, limiter: l}
+}
+
+type limitedReader struct {
+	reader  io.Reader
+	limiter *BandwidthLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.wait(n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+type limitedWriterAt struct {
+	writer  io.WriterAt
+	limiter *BandwidthLimiter
+}
+
+func (w *limitedWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if err := w.limiter.wait(len(p)); err != nil {
+		return 0, err
+	}
+	return w.writer.WriteAt(p, off)
+}
+
+// LimitBandwidth limits the download throughput of the provider.
+func LimitBandwidth(provider Provider, limiter *BandwidthLimiter) error {
+	switch p := provider.(type) {
+	case *S3Provider:
+		p.Limiter = limiter
+	case *GCSProvider:
+		p.Limiter = limiter
+	case *HTTPSProvider:
+		p.Limiter = limiter
+	case *AzureProvider:
+		p.Limiter = limiter
+	case *LocalProvider:
+		p.Limiter = limiter
+	case *OCIProvider:
+		p.Limiter = limiter
+	case *WebHDFSProvider:
+		p.Limiter = limiter
+	case *SidecarProvider:
+		p.Limiter = limiter
+	default:
+		return fmt.Errorf("the bandwidth of provider %T can not be limited", provider)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestBandwidthLimiter(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	content := bytes.Repeat([]byte("a"), 100000)
+
+	var unlimited *BandwidthLimiter
+	reader := bytes.NewReader(content)
+	g.Expect(unlimited.Reader(reader)).To(gomega.BeIdenticalTo(reader))
+
+	// The first second of content is let through at once, the rest at the limit
+	limiter := NewBandwidthLimiter(50000)
+	start := time.Now()
+	read, err := io.ReadAll(limiter.Reader(bytes.NewReader(content)))
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(read).To(gomega.Equal(content))
+	g.Expect(time.Since(start)).To(gomega.BeNumerically(">=", 900*time.Millisecond))
+}
+
+func TestLimitBandwidth(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	limiter := NewBandwidthLimiter(1000)
+	provider := &HTTPSProvider{}
+	g.Expect(LimitBandwidth(provider, limiter)).To(gomega.Succeed())
+	g.Expect(provider.Limiter).To(gomega.BeIdenticalTo(limiter))
+	g.Expect(LimitBandwidth(nil, limiter)).NotTo(gomega.Succeed())
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const DigestAlgorithmSHA256 = "sha256"
+
+var digestRegexp = regexp.MustCompile(`^sha256:[a-fA-F0-9]{64}$`)
+
+// ErrChecksumMismatch is returned when a downloaded model does not match its expected digests.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Checksum holds the expected digests of a downloaded model.
+type Checksum struct {
+	// Digest is the digest of the model as it is downloaded, that is of the archive or the single model file
+	Digest string
+	// Files are the digests of the model files keyed by their path relative to the model directory
+	Files map[string]string
+}
+
+// ValidateDigest checks that digest is of the form "sha256:<hex>".
+func ValidateDigest(digest string) error {
+	if !digestRegexp.MatchString(digest) {
+		return fmt.Errorf("invalid digest %q, expected %s:<hex>", digest, DigestAlgorithmSHA256)
+	}
+	return nil
+}
+
+func newDigester() hash.Hash {
+	return sha256.New()
+}
+
+func formatDigest(h hash.Hash) string {
+	return DigestAlgorithmSHA256 + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// FileDigest returns the digest of the file in the form "sha256:<hex>".
+func FileDigest(fileName string) (string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return "", err
+	}
+	defer func(file *os.File) {
+		closeErr := file.Close()
+		if closeErr != nil {
+			log.Error(closeErr, "failed to close file")
+		}
+	}(file)
+	h := newDigester()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("unable to read file %s: %w", fileName, err)
+	}
+	return formatDigest(h), nil
+}
+
+// Verify checks the model downloaded to modelPath against the expected digests. downloadDigest is
+// the digest of the model as it was downloaded, or empty if the provider could not compute it.
+func (c *Checksum) Verify(modelPath string, downloadDigest string) error {
+	if c == nil {
+		return nil
+	}
+	if c.Digest != "" {
+		if downloadDigest == "" {
+			return fmt.Errorf("the digest can only be verified for a single model file or archive")
+		}
+		if !strings.EqualFold(c.Digest, downloadDigest) {
+			return fmt.Errorf("%w: expected digest %s, got %s", ErrChecksumMismatch, c.Digest, downloadDigest)
+		}
+	}
+	for _, name := range sortedKeys(c.Files) {
+		fileName := filepath.Join(modelPath, name)
+		if !strings.HasPrefix(fileName, filepath.Clean(modelPath)+string(os.PathSeparator)) {
+			return fmt.Errorf("%s: illegal file path", name)
+		}
+		digest, err := FileDigest(fileName)
+		if err != nil {
+			return fmt.Errorf("%w: unable to compute digest of %s: %v", ErrChecksumMismatch, name, err)
+		}
+		if !strings.EqualFold(c.Files[name], digest) {
+			return fmt.Errorf("%w: expected digest %s for %s, got %s", ErrChecksumMismatch, c.Files[name], name, digest)
+		}
+	}
+	return nil
+}
+
+// VerifiedDigest is the digest recorded once the model is verified. It is the model digest if
+// set, else the digest of the list of file digests.
+func (c *Checksum) VerifiedDigest() string {
+	if c == nil {
+		return ""
+	}
+	if c.Digest != "" {
+		return strings.ToLower(c.Digest)
+	}
+	if len(c.Files) == 0 {
+		return ""
+	}
+	// the list is formatted as by sha256sum so that it can be reproduced easily
+	h := newDigester()
+	for _, name := range sortedKeys(c.Files) {
+		digest := strings.TrimPrefix(strings.ToLower(c.Files[name]), DigestAlgorithmSHA256+":")
+		_, _ = fmt.Fprintf(h, "%s  %s\n", digest, name)
+	}
+	return formatDigest(h)
+}
+
+// verifyDownload verifies the downloaded model and removes it if it is corrupted.
+func verifyDownload(modelPath string, checksum *Checksum, downloadDigest string) error {
+	if err := checksum.Verify(modelPath, downloadDigest); err != nil {
+		log.Error(err, "Removing model which failed verification", "modelPath", modelPath)
+		if removeErr := os.RemoveAll(modelPath); removeErr != nil {
+			log.Error(removeErr, "failed to remove model", "modelPath", modelPath)
+		}
+		return err
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestValidateDigest(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	scenarios := map[string]struct {
+		digest   string
+		expected gomega.OmegaMatcher
+	}{
+		"Valid": {
+			digest:   "sha256:" + strings.Repeat("a", 64),
+			expected: gomega.BeNil(),
+		},
+		"MissingAlgorithm": {
+			digest:   strings.Repeat("a", 64),
+			expected: gomega.HaveOccurred(),
+		},
+		"UnsupportedAlgorithm": {
+			digest:   "md5:" + strings.Repeat("a", 32),
+			expected: gomega.HaveOccurred(),
+		},
+		"Truncated": {
+			digest:   "sha256:" + strings.Repeat("a", 63),
+			expected: gomega.HaveOccurred(),
+		},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			g.Expect(ValidateDigest(scenario.digest)).To(scenario.expected)
+		})
+	}
+}
+
+func TestChecksumVerify(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	modelPath := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(modelPath, "model.pt"), []byte("weights"), 0644)).To(gomega.Succeed())
+	digest, err := FileDigest(filepath.Join(modelPath, "model.pt"))
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(digest).To(gomega.Equal("sha256:9a129038d9a00aed0cf6a7ea059ca50a813449061ab87848cf1a13eafdf33b2c"))
+
+	var noChecksum *Checksum
+	g.Expect(noChecksum.Verify(modelPath, "")).To(gomega.Succeed())
+	g.Expect(noChecksum.VerifiedDigest()).To(gomega.BeEmpty())
+
+	checksum := &Checksum{Files: map[string]string{"model.pt": "sha256:" + strings.Repeat("0", 64)}}
+	g.Expect(checksum.Verify(modelPath, "")).To(gomega.MatchError(ErrChecksumMismatch))
+	checksum = &Checksum{Files: map[string]string{"model.pt": "sha256:" + strings.ToUpper(digest[7:])}}
+	g.Expect(checksum.Verify(modelPath, "")).To(gomega.Succeed())
+	g.Expect(checksum.VerifiedDigest()).To(gomega.HavePrefix("sha256:"))
+
+	// Files outside of the model directory are rejected
+	checksum = &Checksum{Files: map[string]string{"../model.pt": digest}}
+	g.Expect(checksum.Verify(modelPath, "")).NotTo(gomega.Succeed())
+
+	// The model digest can only be checked against the digest of the download
+	checksum = &Checksum{Digest: digest}
+	g.Expect(checksum.Verify(modelPath, "")).NotTo(gomega.Succeed())
+	g.Expect(checksum.Verify(modelPath, digest)).To(gomega.Succeed())
+	g.Expect(checksum.VerifiedDigest()).To(gomega.Equal(digest))
+}
@@ -21,28 +21,39 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	gstorage "cloud.google.com/go/storage"
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/googleapis/google-cloud-go-testing/storage/stiface"
 	"google.golang.org/api/iterator"
 )
 
 type GCSProvider struct {
 	Client stiface.Client
+	// Limiter limits the download throughput, it is unlimited if nil
+	Limiter *BandwidthLimiter
+	// DownloadOptions configures the ranged downloads, DefaultDownloadOptions are used if nil
+	DownloadOptions *DownloadOptions
 }
 
-func (p *GCSProvider) DownloadModel(modelDir string, modelName string, storageUri string) error {
+func (p *GCSProvider) DownloadModel(modelDir string, modelName string, storageUri string, checksum *Checksum) error {
 	log.Info("Downloading model ", "modelName", modelName, "storageUri", storageUri, "modelDir", modelDir)
+	storageUri, params, err := ParseStorageURI(GCS, storageUri)
+	if err != nil {
+		return err
+	}
 	gcsUri := strings.TrimPrefix(storageUri, string(GCS))
 	tokens := strings.SplitN(gcsUri, "/", 2)
 	prefix := ""
 	if len(tokens) == 2 {
 		prefix = tokens[1]
 	}
+	options := DefaultDownloadOptions
+	if p.DownloadOptions != nil {
+		options = *p.DownloadOptions
+	}
 	ctx := context.Background()
 	gcsObjectDownloader := &GCSObjectDownloader{
 		Context:    ctx,
@@ -51,6 +62,9 @@ func (p *GCSProvider) DownloadModel(modelDir string, modelName string, storageUr
 		ModelName:  modelName,
 		Bucket:     tokens[0],
 		Item:       prefix,
+		Generation: params.Generation,
+		limiter:    p.Limiter,
+		options:    options,
 	}
 	it, err := gcsObjectDownloader.GetObjectIterator(p.Client)
 	if err != nil {
@@ -59,7 +73,13 @@ func (p *GCSProvider) DownloadModel(modelDir string, modelName string, storageUr
 	if err := gcsObjectDownloader.Download(p.Client, it); err != nil {
 		return fmt.Errorf("unable to download object/s because: %w", err)
 	}
-	return nil
+	downloadDigest := ""
+	if checksum != nil && checksum.Digest != "" && len(gcsObjectDownloader.fileNames) == 1 {
+		if downloadDigest, err = FileDigest(gcsObjectDownloader.fileNames[0]); err != nil {
+			return err
+		}
+	}
+	return verifyDownload(filepath.Join(modelDir, modelName), checksum, downloadDigest)
 }
 
 type GCSObjectDownloader struct {
@@ -69,18 +89,45 @@ type GCSObjectDownloader struct {
 	ModelName  string
 	Bucket     string
 	Item       string
+	// Generation is the generation of the object the item refers to, the latest objects are downloaded if 0
+	Generation int64
+	// fileNames are the files the objects were downloaded to
+	fileNames []string
+	limiter   *BandwidthLimiter
+	options   DownloadOptions
 }
 
 func (g *GCSObjectDownloader) GetObjectIterator(client stiface.Client) (stiface.ObjectIterator, error) {
+	if g.Generation != 0 {
+		attrs, err := client.Bucket(g.Bucket).Object(g.Item).Generation(g.Generation).Attrs(g.Context)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the generation %d of %s: %w", g.Generation, g.StorageUri, err)
+		}
+		return &objectAttrsIterator{items: []*gstorage.ObjectAttrs{attrs}}, nil
+	}
 	query := &gstorage.Query{Prefix: g.Item}
 	return client.Bucket(g.Bucket).Objects(g.Context, query), nil
 }
 
-func (g *GCSObjectDownloader) Download(client stiface.Client, it stiface.ObjectIterator) error {
-	var errs []error
-	// flag to help determine if query prefix returned an empty iterator
-	var foundObject = false
+// objectAttrsIterator iterates over the attributes of known objects.
+type objectAttrsIterator struct {
+	stiface.ObjectIterator
+	items []*gstorage.ObjectAttrs
+}
 
+func (i *objectAttrsIterator) Next() (*gstorage.ObjectAttrs, error) {
+	if len(i.items) == 0 {
+		return nil, iterator.Done
+	}
+	item := i.items[0]
+	i.items = i.items[1:]
+	return item, nil
+}
+
+// Download downloads the objects in ranged parts, resuming the files which were partially
+// downloaded by a previous attempt.
+func (g *GCSObjectDownloader) Download(client stiface.Client, it stiface.ObjectIterator) error {
+	var downloads []objectDownload
 	for {
 		attrs, err := it.Next()
 		if errors.Is(err, iterator.Done) {
@@ -90,74 +137,59 @@ func (g *GCSObjectDownloader) Download(client stiface.Client, it stiface.ObjectI
 			return fmt.Errorf("an error occurred while iterating: %w", err)
 		}
 		objectValue := strings.TrimPrefix(attrs.Name, g.Item)
-		fileName := filepath.Join(g.ModelDir, g.ModelName, objectValue)
-
-		foundObject = true
-		if FileExists(fileName) {
-			log.Info("Deleting", fileName)
-			if err := os.Remove(fileName); err != nil {
-				return fmt.Errorf("file is unable to be deleted: %w", err)
-			}
-		}
-		file, err := Create(fileName)
-		if err != nil {
-			return fmt.Errorf("file is already created: %w", err)
-		}
-		if err := g.DownloadFile(client, attrs, file); err != nil {
-			errs = append(errs, err)
+		if objectValue == "" {
+			// the object the item refers to is downloaded into the model directory
+			objectValue = filepath.Base(attrs.Name)
 		}
+		fileName := filepath.Join(g.ModelDir, g.ModelName, objectValue)
+		downloads = append(downloads, objectDownload{
+			fileName: fileName,
+			size:     attrs.Size,
+			version:  strconv.FormatInt(attrs.Generation, 10),
+			fetch:    g.fetch(client, attrs),
+		})
+		g.fileNames = append(g.fileNames, fileName)
 	}
-	if !foundObject {
+	if len(downloads) == 0 {
 		return gstorage.ErrObjectNotExist
 	}
-	if len(errs) > 0 {
-		return awserr.NewBatchError("GCSDownloadIncomplete", "some objects failed to download.", errs)
-	}
-	return nil
+	return downloadObjects(g.Context, downloads, g.options)
 }
 
-func (g *GCSObjectDownloader) DownloadFile(client stiface.Client, attrs *gstorage.ObjectAttrs, file *os.File) error {
-	reader, err := client.Bucket(attrs.Bucket).Object(attrs.Name).NewReader(g.Context)
-	if err != nil {
-		return fmt.Errorf("failed to create reader for object(%s) in bucket(%s): %w",
-			attrs.Name,
-			attrs.Bucket,
-			err,
-		)
-	}
-	defer func(reader stiface.Reader) {
-		closeErr := reader.Close()
-		if closeErr != nil {
-			log.Error(closeErr, "failed to close reader")
+func (g *GCSObjectDownloader) fetch(client stiface.Client, attrs *gstorage.ObjectAttrs) fetchRange {
+	return func(ctx context.Context, w io.WriterAt, offset int64, length int64) error {
+		// the parts of another generation of the object must not be mixed in
+		object := client.Bucket(attrs.Bucket).Object(attrs.Name)
+		if attrs.Generation != 0 {
+			object = object.Generation(attrs.Generation)
 		}
-	}(reader)
-	data, err := io.ReadAll(reader)
-	if err != nil {
-		return fmt.Errorf("failed to read object(%s) in bucket(%s): %w",
-			attrs.Name,
-			attrs.Bucket,
-			err,
-		)
-	}
-	return g.WriteToFile(data, attrs, file)
-}
-
-func (g *GCSObjectDownloader) WriteToFile(data []byte, attrs *gstorage.ObjectAttrs, file *os.File) error {
-	_, err := file.Write(data)
-	if err != nil {
-		return fmt.Errorf("failed to write data to file(%s): from object(%s) in bucket(%s): %w",
-			file.Name(),
-			attrs.Name,
-			attrs.Bucket,
-			err,
-		)
-	}
-	defer func(file *os.File) {
-		closeErr := file.Close()
-		if closeErr != nil {
-			log.Error(closeErr, "failed to close file")
+		var reader stiface.Reader
+		var err error
+		if length >= 0 {
+			reader, err = object.NewRangeReader(ctx, offset, length)
+		} else {
+			reader, err = object.NewReader(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create reader for object(%s) in bucket(%s): %w",
+				attrs.Name,
+				attrs.Bucket,
+				err,
+			)
+		}
+		defer func(reader stiface.Reader) {
+			closeErr := reader.Close()
+			if closeErr != nil {
+				log.Error(closeErr, "failed to close reader")
+			}
+		}(reader)
+		if _, err := io.Copy(io.NewOffsetWriter(w, 0), g.limiter.Reader(reader)); err != nil {
+			return fmt.Errorf("failed to read object(%s) in bucket(%s): %w",
+				attrs.Name,
+				attrs.Bucket,
+				err,
+			)
 		}
-	}(file)
-	log.Info("Wrote " + attrs.Prefix + " to file " + file.Name())
-	return nil
+		return nil
+	}
 }
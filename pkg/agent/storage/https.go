@@ -17,12 +17,6 @@ limitations under the License.
 package storage
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"bytes"
-	"compress/gzip"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -30,40 +24,64 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/kserve/kserve/pkg/credentials/https"
+	"sigs.k8s.io/yaml"
 )
 
 const (
+	// HEADER_SUFFIX is the suffix of the deprecated environment variables holding the headers of a host,
+	// the headers are read from the HTTP(S) secrets mounted into HTTPSProvider.SecretsDir instead.
 	HEADER_SUFFIX                  = "-headers"
 	DEFAULT_MAX_DECOMPRESSION_SIZE = 1024 * 1024 * 1024 // 1 GB
 )
 
 type HTTPSProvider struct {
 	Client *http.Client
+	// SecretsDir is the directory the HTTP(S) secrets holding the request headers of a host are
+	// mounted into by name, see pkg/credentials/https
+	SecretsDir string
+	// ArchiveLimits limit the content extracted from archives, DefaultArchiveLimits are used if nil
+	ArchiveLimits *ArchiveLimits
+	// Limiter limits the download throughput, it is unlimited if nil
+	Limiter *BandwidthLimiter
 }
 
-func (m *HTTPSProvider) DownloadModel(modelDir string, modelName string, storageUri string) error {
+func (m *HTTPSProvider) DownloadModel(modelDir string, modelName string, storageUri string, checksum *Checksum) error {
 	log.Info("Download model ", "modelName", modelName, "storageUri", storageUri, "modelDir", modelDir)
 	uri, err := url.Parse(storageUri)
 	if err != nil {
 		return fmt.Errorf("unable to parse storage uri: %w", err)
 	}
+	limits := DefaultArchiveLimits
+	if m.ArchiveLimits != nil {
+		limits = *m.ArchiveLimits
+	}
 	HTTPSDownloader := &HTTPSDownloader{
-		StorageUri: storageUri,
-		ModelDir:   modelDir,
-		ModelName:  modelName,
-		Uri:        uri,
+		StorageUri:    storageUri,
+		ModelDir:      modelDir,
+		ModelName:     modelName,
+		Uri:           uri,
+		SecretsDir:    m.SecretsDir,
+		ArchiveLimits: limits,
+		limiter:       m.Limiter,
 	}
 	if err := HTTPSDownloader.Download(*m.Client); err != nil {
 		return err
 	}
-	return nil
+	return verifyDownload(filepath.Join(modelDir, modelName), checksum, HTTPSDownloader.Digest)
 }
 
 type HTTPSDownloader struct {
-	StorageUri string
-	ModelDir   string
-	ModelName  string
-	Uri        *url.URL
+	StorageUri    string
+	ModelDir      string
+	ModelName     string
+	Uri           *url.URL
+	SecretsDir    string
+	ArchiveLimits ArchiveLimits
+	// Digest is the digest of the downloaded content, set once the download completes
+	Digest  string
+	limiter *BandwidthLimiter
 }
 
 func (h *HTTPSDownloader) Download(client http.Client) error {
@@ -97,45 +115,83 @@ func (h *HTTPSDownloader) Download(client http.Client) error {
 		return fmt.Errorf("URI: %s returned a %d response code", h.StorageUri, resp.StatusCode)
 	}
 
-	// Write content into file(s)
-	contentType := resp.Header.Get("Content-type")
+	// Write content into file(s), computing the digest of the content as it is read
+	digester := newDigester()
+	body := io.TeeReader(h.limiter.Reader(resp.Body), digester)
 	fileDirectory := filepath.Join(h.ModelDir, h.ModelName)
+	paths := strings.Split(h.Uri.Path, "/")
+	fileName := paths[len(paths)-1]
 
-	if strings.Contains(contentType, "application/zip") {
-		if err := extractZipFiles(resp.Body, fileDirectory); err != nil {
-			return err
-		}
-	} else if strings.Contains(contentType, "application/x-tar") || strings.Contains(contentType, "application/x-gtar") ||
-		strings.Contains(contentType, "application/x-gzip") || strings.Contains(contentType, "application/gzip") {
-		if err := extractTarFiles(resp.Body, fileDirectory); err != nil {
-			return err
+	if format, ok := ArchiveFormatOf(resp.Header.Get("Content-type"), fileName); ok {
+		if err := extractArchive(body, format, fileDirectory, h.ArchiveLimits); err != nil {
+			return fmt.Errorf("unable to extract the %s archive: %w", format, err)
 		}
 	} else {
-		paths := strings.Split(h.Uri.Path, "/")
-		fileName := paths[len(paths)-1]
 		fileFullName := filepath.Join(fileDirectory, fileName)
 		file, err := createNewFile(fileFullName)
 		if err != nil {
 			return err
 		}
-		if _, err = io.Copy(file, resp.Body); err != nil {
+		defer file.Close()
+		if _, err = io.Copy(file, body); err != nil {
 			return fmt.Errorf("unable to copy file content: %w", err)
 		}
 	}
-
+	// the archive readers may stop before the end of the content
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return fmt.Errorf("unable to read content: %w", err)
+	}
+	h.Digest = formatDigest(digester)
 	return nil
 }
 
-func (h *HTTPSDownloader) extractHeaders() (headers map[string]string, err error) {
+// extractHeaders returns the headers of the secret mounted for the host of the uri, or else the
+// headers of the deprecated <host>-headers environment variable. The headers are a JSON or YAML map.
+func (h *HTTPSDownloader) extractHeaders() (map[string]string, error) {
 	hostname := h.Uri.Hostname()
-	headerJSON := os.Getenv(hostname + HEADER_SUFFIX)
-	if headerJSON != "" {
-		err = json.Unmarshal([]byte(headerJSON), &headers)
-		if err != nil {
-			log.Error(err, "failed to unmarshal headers")
+	headerData, err := h.readSecretHeaders(hostname)
+	if err != nil {
+		return nil, err
+	}
+	if headerData == "" {
+		headerData = os.Getenv(hostname + HEADER_SUFFIX)
+	}
+	if headerData == "" {
+		return nil, nil
+	}
+	var headers map[string]string
+	if err := yaml.Unmarshal([]byte(headerData), &headers); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the headers of %s: %w", hostname, err)
+	}
+	return headers, nil
+}
+
+// readSecretHeaders returns the headers of the first secret in SecretsDir which host is hostname.
+func (h *HTTPSDownloader) readSecretHeaders(hostname string) (string, error) {
+	if h.SecretsDir == "" {
+		return "", nil
+	}
+	entries, err := os.ReadDir(h.SecretsDir)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		secretDir := filepath.Join(h.SecretsDir, entry.Name())
+		host, err := os.ReadFile(filepath.Join(secretDir, https.HTTPSHost))
+		if err != nil || !strings.EqualFold(strings.TrimSpace(string(host)), hostname) {
+			continue
 		}
+		headers, err := os.ReadFile(filepath.Join(secretDir, https.HEADERS))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		return string(headers), nil
 	}
-	return headers, err
+	return "", nil
 }
 
 func createNewFile(fileFullName string) (*os.File, error) {
@@ -151,101 +207,3 @@ func createNewFile(fileFullName string) (*os.File, error) {
 	}
 	return file, nil
 }
-
-func extractZipFiles(reader io.Reader, dest string) error {
-	body, err := io.ReadAll(reader)
-	if err != nil {
-		return err
-	}
-
-	zipReader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
-	if err != nil {
-		return fmt.Errorf("unable to create new reader: %w", err)
-	}
-
-	// Read all the files from zip archive
-	for _, zipFile := range zipReader.File {
-		fileFullPath := filepath.Join(dest, zipFile.Name) // #nosecG305
-		if !strings.HasPrefix(fileFullPath, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("%s: illegal file path", fileFullPath)
-		}
-
-		if zipFile.Mode().IsDir() {
-			err = os.MkdirAll(fileFullPath, 0755)
-			if err != nil {
-				return fmt.Errorf("unable to create new directory %s", fileFullPath)
-			}
-
-			continue
-		}
-
-		file, err := createNewFile(fileFullPath)
-		if err != nil {
-			return err
-		}
-		rc, err := zipFile.Open()
-		if err != nil {
-			return fmt.Errorf("unable to open file: %w", err)
-		}
-
-		_, err = io.CopyN(file, rc, DEFAULT_MAX_DECOMPRESSION_SIZE) // gosec G110
-		closeErr := file.Close()
-		if closeErr != nil {
-			return closeErr
-		}
-		closeErr = rc.Close()
-		if closeErr != nil {
-			return closeErr
-		}
-		if err != nil {
-			return fmt.Errorf("unable to copy file content: %w", err)
-		}
-	}
-	return nil
-}
-
-func extractTarFiles(reader io.Reader, dest string) error {
-	gzr, err := gzip.NewReader(reader)
-	if err != nil {
-		return err
-	}
-	defer func(gzr *gzip.Reader) {
-		closeErr := gzr.Close()
-		if closeErr != nil {
-			log.Error(closeErr, "failed to close reader")
-		}
-	}(gzr)
-
-	tr := tar.NewReader(gzr)
-
-	// Read all the files from tar archive
-	for {
-		header, err := tr.Next()
-		if errors.Is(err, io.EOF) {
-			break
-		} else if err != nil {
-			return fmt.Errorf("unable to access next tar file: %w", err)
-		}
-
-		fileFullPath := filepath.Join(dest, header.Name) // #nosec G305
-		if header.Typeflag == tar.TypeDir {
-			err = os.MkdirAll(fileFullPath, 0755)
-			if err != nil {
-				return fmt.Errorf("unable to create new directory %s", fileFullPath)
-			}
-
-			continue
-		}
-
-		newFile, err := createNewFile(fileFullPath)
-		if err != nil {
-			return err
-		}
-
-		// gosec G110
-		if _, err := io.CopyN(newFile, tr, DEFAULT_MAX_DECOMPRESSION_SIZE); err != nil {
-			return fmt.Errorf("unable to copy contents to %s: %w", header.Name, err)
-		}
-	}
-	return nil
-}
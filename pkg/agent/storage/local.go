@@ -0,0 +1,242 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kserve/kserve/pkg/constants"
+)
+
+// LocalProvider provides the models of a volume mounted into the agent container, pvc://{pvc}/{path}
+// models from the PVCs mounted under PvcMountRoot and file:///{path} models from under FileRoot. The
+// model files are copied into the model directory, or linked if Link is set, in which case the
+// volume must be mounted at the same path into the model server container.
+type LocalProvider struct {
+	// PvcMountRoot is the directory the PVCs are mounted into by name, constants.ModelPvcMountRoot
+	// if it is empty
+	PvcMountRoot string
+	// FileRoot is the directory the file:// models must be under, so that the other files of the
+	// agent container such as the mounted secrets are not provided. file:// models are rejected if
+	// it is empty.
+	FileRoot string
+	Link     bool
+	// Limiter limits the copy throughput, it is unlimited if nil
+	Limiter *BandwidthLimiter
+}
+
+var _ Provider = (*LocalProvider)(nil)
+
+func (p *LocalProvider) DownloadModel(modelDir string, modelName string, storageUri string, checksum *Checksum) error {
+	log.Info("Download model ", "modelName", modelName, "storageUri", storageUri, "modelDir", modelDir)
+	sourcePath, root, err := p.sourcePath(storageUri)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return fmt.Errorf("unable to read the model at %s: %w", storageUri, err)
+	}
+	if p.Link {
+		// the model server follows the links of the linked files
+		if err := checkLinks(root, sourcePath); err != nil {
+			return fmt.Errorf("invalid model at %s: %w", storageUri, err)
+		}
+	}
+	modelPath := filepath.Join(modelDir, modelName)
+	if err := p.provideModel(sourcePath, modelPath, info); err != nil {
+		// the files provided so far are removed with the partially copied one
+		if removeErr := os.RemoveAll(modelPath); removeErr != nil {
+			log.Error(removeErr, "failed to remove the partially copied model", "path", modelPath)
+		}
+		return err
+	}
+	downloadDigest := ""
+	if !info.IsDir() && checksum != nil && checksum.Digest != "" {
+		if downloadDigest, err = FileDigest(sourcePath); err != nil {
+			return err
+		}
+	}
+	return verifyDownload(modelPath, checksum, downloadDigest)
+}
+
+// provideModel provides the files of the model directory or the model file at sourcePath in modelPath.
+func (p *LocalProvider) provideModel(sourcePath string, modelPath string, info os.FileInfo) error {
+	if !info.IsDir() {
+		return p.provide(sourcePath, filepath.Join(modelPath, info.Name()))
+	}
+	entries, err := os.ReadDir(sourcePath)
+	if err != nil {
+		return fmt.Errorf("unable to read the model at %s: %w", sourcePath, err)
+	}
+	for _, entry := range entries {
+		if err := p.provide(filepath.Join(sourcePath, entry.Name()), filepath.Join(modelPath, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourcePath returns the path of the model in the agent container and the root it must be under.
+func (p *LocalProvider) sourcePath(storageUri string) (string, string, error) {
+	if strings.HasPrefix(storageUri, string(File)) {
+		sourcePath := strings.TrimPrefix(storageUri, string(File))
+		if !filepath.IsAbs(sourcePath) {
+			return "", "", fmt.Errorf("invalid uri %s, expected file:///<absolute path>", storageUri)
+		}
+		if p.FileRoot == "" {
+			return "", "", fmt.Errorf("invalid uri %s, file:// models are disabled", storageUri)
+		}
+		sourcePath = filepath.Clean(sourcePath)
+		if !withinRoot(p.FileRoot, sourcePath) {
+			return "", "", fmt.Errorf("invalid uri %s, the path is outside of %s", storageUri, p.FileRoot)
+		}
+		return sourcePath, p.FileRoot, nil
+	}
+	pvcName, pvcPath, _ := strings.Cut(strings.TrimPrefix(storageUri, string(PVC)), "/")
+	if pvcName == "" {
+		return "", "", fmt.Errorf("invalid uri %s, expected pvc://<pvc name>/[path]", storageUri)
+	}
+	mountRoot := p.PvcMountRoot
+	if mountRoot == "" {
+		mountRoot = constants.ModelPvcMountRoot
+	}
+	pvcRoot := filepath.Join(mountRoot, pvcName)
+	sourcePath := filepath.Join(pvcRoot, pvcPath)
+	if !withinRoot(pvcRoot, sourcePath) {
+		return "", "", fmt.Errorf("invalid uri %s, the path is outside of the PVC", storageUri)
+	}
+	return sourcePath, pvcRoot, nil
+}
+
+// checkLinks returns an error if a link under sourcePath, including under the linked directories,
+// resolves outside of root or does not resolve.
+func checkLinks(root string, sourcePath string) error {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	visited := map[string]bool{}
+	var check func(path string) error
+	check = func(path string) error {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return fmt.Errorf("unable to resolve %s: %w", path, err)
+		}
+		if !isUnder(resolvedRoot, resolved) {
+			return fmt.Errorf("%s resolves outside of %s", path, root)
+		}
+		if visited[resolved] {
+			return nil
+		}
+		visited[resolved] = true
+		info, err := os.Stat(resolved)
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		entries, err := os.ReadDir(resolved)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := check(filepath.Join(path, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(sourcePath)
+}
+
+// withinRoot returns true if the clean path is root or under it, once their symlinks are resolved.
+func withinRoot(root string, path string) bool {
+	root = filepath.Clean(root)
+	if !isUnder(root, path) {
+		return false
+	}
+	// the symlinks are only resolved once the path exists, the missing models fail to be read
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return true
+	}
+	resolvedPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return true
+	}
+	return isUnder(resolvedRoot, resolvedPath)
+}
+
+func isUnder(root string, path string) bool {
+	return path == root || strings.HasPrefix(path, strings.TrimSuffix(root, string(os.PathSeparator))+string(os.PathSeparator))
+}
+
+// provide links or copies the file or directory at source to target.
+func (p *LocalProvider) provide(source string, target string) error {
+	if p.Link {
+		if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+			return err
+		}
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("file is unable to be deleted: %w", err)
+		}
+		return os.Symlink(source, target)
+	}
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(target, relativePath)
+		if info.IsDir() {
+			return os.MkdirAll(targetPath, 0777)
+		}
+		if !info.Mode().IsRegular() {
+			// symlinks and special files of the volume are not followed
+			return nil
+		}
+		return p.copyFile(path, targetPath)
+	})
+}
+
+func (p *LocalProvider) copyFile(source string, target string) error {
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+	targetFile, err := Create(target)
+	if err != nil {
+		return fmt.Errorf("file is already created: %w", err)
+	}
+	defer func(file *os.File) {
+		closeErr := file.Close()
+		if closeErr != nil {
+			log.Error(closeErr, "failed to close file")
+		}
+	}(targetFile)
+	if _, err := io.Copy(targetFile, p.Limiter.Reader(sourceFile)); err != nil {
+		return fmt.Errorf("unable to copy file %s: %w", source, err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestLocalProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	pvcRoot := t.TempDir()
+	modelPath := filepath.Join(pvcRoot, "models", "sklearn", "iris")
+	g.Expect(os.MkdirAll(filepath.Join(modelPath, "1"), 0777)).To(gomega.Succeed())
+	g.Expect(os.WriteFile(filepath.Join(modelPath, "1", "model.joblib"), []byte("model"), 0644)).To(gomega.Succeed())
+	g.Expect(os.WriteFile(filepath.Join(modelPath, "config.json"), []byte("{}"), 0644)).To(gomega.Succeed())
+
+	// Copied models do not depend on the volume
+	modelDir := t.TempDir()
+	provider := &LocalProvider{PvcMountRoot: pvcRoot}
+	g.Expect(provider.DownloadModel(modelDir, "iris", "pvc://models/sklearn/iris", nil)).To(gomega.Succeed())
+	info, err := os.Lstat(filepath.Join(modelDir, "iris", "1", "model.joblib"))
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(info.Mode().IsRegular()).To(gomega.BeTrue())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "iris", "config.json"))).To(gomega.Equal([]byte("{}")))
+
+	// Linked models point into the volume
+	provider = &LocalProvider{PvcMountRoot: pvcRoot, FileRoot: filepath.Join(pvcRoot, "models"), Link: true}
+	g.Expect(provider.DownloadModel(modelDir, "linked", "file://"+modelPath, nil)).To(gomega.Succeed())
+	target, err := os.Readlink(filepath.Join(modelDir, "linked", "1"))
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(target).To(gomega.Equal(filepath.Join(modelPath, "1")))
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "linked", "1", "model.joblib"))).To(gomega.Equal([]byte("model")))
+
+	// Single files are verified against their digest
+	digest, err := FileDigest(filepath.Join(modelPath, "config.json"))
+	g.Expect(err).To(gomega.BeNil())
+	checksum := &Checksum{Digest: digest}
+	g.Expect(provider.DownloadModel(modelDir, "config", "pvc://models/sklearn/iris/config.json", checksum)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "config", "config.json"))).To(gomega.Equal([]byte("{}")))
+
+	g.Expect(provider.DownloadModel(modelDir, "escape", "pvc://models/../other", nil)).NotTo(gomega.Succeed())
+	g.Expect(provider.DownloadModel(modelDir, "relative", "file://models", nil)).NotTo(gomega.Succeed())
+	g.Expect(provider.DownloadModel(modelDir, "missing", "pvc://models/missing", nil)).NotTo(gomega.Succeed())
+}
+
+func TestLocalProviderFileRoot(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	fileRoot := t.TempDir()
+	secretDir := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(secretDir, "token"), []byte("secret"), 0600)).To(gomega.Succeed())
+	g.Expect(os.WriteFile(filepath.Join(fileRoot, "model.bin"), []byte("model"), 0644)).To(gomega.Succeed())
+	g.Expect(os.Symlink(secretDir, filepath.Join(fileRoot, "secrets"))).To(gomega.Succeed())
+
+	modelDir := t.TempDir()
+	provider := &LocalProvider{FileRoot: fileRoot}
+	g.Expect(provider.DownloadModel(modelDir, "model", "file://"+filepath.Join(fileRoot, "model.bin"), nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "model", "model.bin"))).To(gomega.Equal([]byte("model")))
+
+	for name, storageUri := range map[string]string{
+		"outside": "file://" + secretDir,
+		"escape":  "file://" + fileRoot + "/../" + filepath.Base(secretDir),
+		"symlink": "file://" + filepath.Join(fileRoot, "secrets"),
+	} {
+		g.Expect(provider.DownloadModel(modelDir, name, storageUri, nil)).NotTo(gomega.Succeed(), name)
+		g.Expect(filepath.Join(modelDir, name, "token")).NotTo(gomega.BeAnExistingFile(), name)
+	}
+
+	// The linked models must not have links out of the root, including under the linked directories
+	g.Expect(os.MkdirAll(filepath.Join(fileRoot, "linked"), 0777)).To(gomega.Succeed())
+	g.Expect(os.WriteFile(filepath.Join(fileRoot, "linked", "model.bin"), []byte("model"), 0644)).To(gomega.Succeed())
+	g.Expect(os.Symlink(secretDir, filepath.Join(fileRoot, "linked", "secrets"))).To(gomega.Succeed())
+	g.Expect(os.MkdirAll(filepath.Join(fileRoot, "nested"), 0777)).To(gomega.Succeed())
+	g.Expect(os.Symlink(filepath.Join(fileRoot, "secrets"), filepath.Join(fileRoot, "nested", "config"))).To(gomega.Succeed())
+	g.Expect(os.MkdirAll(filepath.Join(fileRoot, "other"), 0777)).To(gomega.Succeed())
+	g.Expect(os.Symlink(filepath.Join(secretDir, "token"), filepath.Join(fileRoot, "other", "token"))).To(gomega.Succeed())
+	g.Expect(os.MkdirAll(filepath.Join(fileRoot, "indirect"), 0777)).To(gomega.Succeed())
+	g.Expect(os.Symlink(filepath.Join(fileRoot, "other"), filepath.Join(fileRoot, "indirect", "other"))).To(gomega.Succeed())
+	linkProvider := &LocalProvider{FileRoot: fileRoot, Link: true}
+	for _, name := range []string{"linked", "nested", "indirect"} {
+		g.Expect(linkProvider.DownloadModel(modelDir, name, "file://"+filepath.Join(fileRoot, name), nil)).NotTo(gomega.Succeed(), name)
+		g.Expect(filepath.Join(modelDir, name)).NotTo(gomega.BeAnExistingFile(), name)
+	}
+	// the links are not followed when the model is copied
+	g.Expect(provider.DownloadModel(modelDir, "linked", "file://"+filepath.Join(fileRoot, "linked"), nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "linked", "model.bin"))).To(gomega.Equal([]byte("model")))
+	g.Expect(filepath.Join(modelDir, "linked", "secrets")).NotTo(gomega.BeAnExistingFile())
+
+	// file:// models are disabled without a root
+	provider = &LocalProvider{}
+	g.Expect(provider.DownloadModel(modelDir, "disabled", "file://"+filepath.Join(fileRoot, "model.bin"), nil)).NotTo(gomega.Succeed())
+}
+
+func TestLocalProviderRemovesPartialCopies(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	pvcRoot := t.TempDir()
+	g.Expect(os.MkdirAll(filepath.Join(pvcRoot, "models", "model", "variables"), 0777)).To(gomega.Succeed())
+	g.Expect(os.WriteFile(filepath.Join(pvcRoot, "models", "model", "saved_model.pb"), []byte("model"), 0644)).To(gomega.Succeed())
+
+	// the variables directory can not be created once saved_model.pb is copied
+	modelDir := t.TempDir()
+	g.Expect(os.MkdirAll(filepath.Join(modelDir, "model", "model"), 0777)).To(gomega.Succeed())
+	g.Expect(os.WriteFile(filepath.Join(modelDir, "model", "model", "variables"), []byte{}, 0644)).To(gomega.Succeed())
+
+	provider := &LocalProvider{PvcMountRoot: pvcRoot}
+	g.Expect(provider.DownloadModel(modelDir, "model", "pvc://models", nil)).NotTo(gomega.Succeed())
+	g.Expect(filepath.Join(modelDir, "model", "model", "saved_model.pb")).NotTo(gomega.BeAnExistingFile())
+	// the whole model directory is removed
+	g.Expect(filepath.Join(modelDir, "model")).NotTo(gomega.BeAnExistingFile())
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kserve/kserve/pkg/credentials/mlflow"
+)
+
+// Prefixes of the uris of the models registered in MLflow, models:/{name}/{version|stage},
+// models:/{name}@{alias} or mlflow://{name}/{version|stage}. They are resolved to the storage uri
+// of the artifacts of the model version before the model is downloaded.
+const (
+	MLflowModelsPrefix = "models:/"
+	MLflowPrefix       = "mlflow://"
+)
+
+// ModelRegistryPrefixes are the prefixes of the model registry uris.
+var ModelRegistryPrefixes = []string{MLflowModelsPrefix, MLflowPrefix}
+
+// wasbsURIRegex matches the Azure Blob Storage uris of the MLflow artifact stores,
+// wasbs://{container}@{account}.blob.core.windows.net/{path}
+var wasbsURIRegex = regexp.MustCompile(`^wasbs?://([^@/]+)@([a-z0-9]+\.blob\.core\.windows\.net)/?(.*)$`)
+
+// IsModelRegistryURI returns true if the uri refers to a model registry rather than to the storage
+// of the model.
+func IsModelRegistryURI(storageUri string) bool {
+	for _, prefix := range ModelRegistryPrefixes {
+		if strings.HasPrefix(storageUri, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolvedModel is the model version a model registry uri resolves to.
+type ResolvedModel struct {
+	// StorageURI is the location of the artifacts of the model version
+	StorageURI string
+	// Version is the version of the model in the registry
+	Version string
+}
+
+// ModelResolver resolves the model registry uris to the storage uri of the model version.
+type ModelResolver interface {
+	Resolve(storageUri string) (*ResolvedModel, error)
+}
+
+// MLflowResolver resolves the model registry uris through the REST API of the MLflow tracking
+// server. The stages and aliases are resolved to the model version they point to at the time.
+type MLflowResolver struct {
+	// TrackingURI is the url of the tracking server, e.g. https://mlflow.example.com
+	TrackingURI string
+	// Token is the bearer token of the requests, the basic credentials are used if it is empty
+	Token    string
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+// NewMLflowResolverFromEnv configures the resolver from the environment of the MLflow client, see
+// pkg/credentials/mlflow. It returns nil if no tracking server is configured.
+func NewMLflowResolverFromEnv() *MLflowResolver {
+	trackingUri := os.Getenv(mlflow.MLflowTrackingURI)
+	if trackingUri == "" {
+		return nil
+	}
+	return &MLflowResolver{
+		TrackingURI: trackingUri,
+		Token:       os.Getenv(mlflow.MLflowTrackingToken),
+		Username:    os.Getenv(mlflow.MLflowTrackingUsername),
+		Password:    os.Getenv(mlflow.MLflowTrackingPassword),
+		Client:      &http.Client{Timeout: time.Minute},
+	}
+}
+
+// mlflowModelRef is a registered model along with either the version, the stage or the alias of
+// the model version it refers to.
+type mlflowModelRef struct {
+	Name    string
+	Version string
+	Stage   string
+	Alias   string
+}
+
+// parseMLflowURI parses models:/{name}/{version|stage}, models:/{name}@{alias} and the equivalent
+// mlflow:// uris. The latest stage refers to the latest version of the model whatever its stage.
+func parseMLflowURI(storageUri string) (*mlflowModelRef, error) {
+	path := strings.TrimPrefix(strings.TrimPrefix(storageUri, MLflowModelsPrefix), MLflowPrefix)
+	ref := &mlflowModelRef{}
+	if name, alias, found := cutLast(path, "@"); found {
+		ref.Name, ref.Alias = name, alias
+	} else if name, version, found := cutLast(path, "/"); found {
+		ref.Name = name
+		if _, err := strconv.ParseUint(version, 10, 64); err == nil {
+			ref.Version = version
+		} else {
+			ref.Stage = version
+		}
+	}
+	if ref.Name == "" || (ref.Version == "" && ref.Stage == "" && ref.Alias == "") {
+		return nil, fmt.Errorf("invalid model registry uri %s, expected %s{name}/{version|stage} or %s{name}@{alias}",
+			storageUri, MLflowModelsPrefix, MLflowModelsPrefix)
+	}
+	return ref, nil
+}
+
+// ValidateModelRegistryURI checks the syntax of a model registry uri, the model version it refers to
+// is only resolved when the model is downloaded.
+func ValidateModelRegistryURI(storageUri string) error {
+	_, err := parseMLflowURI(storageUri)
+	return err
+}
+
+func cutLast(s string, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+type mlflowModelVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Source  string `json:"source"`
+}
+
+func (r *MLflowResolver) Resolve(storageUri string) (*ResolvedModel, error) {
+	ref, err := parseMLflowURI(storageUri)
+	if err != nil {
+		return nil, err
+	}
+	version := ref.Version
+	switch {
+	case ref.Alias != "":
+		response := struct {
+			ModelVersion mlflowModelVersion `json:"model_version"`
+		}{}
+		query := url.Values{"name": {ref.Name}, "alias": {ref.Alias}}
+		if err := r.call(http.MethodGet, "registered-models/alias", query, nil, &response); err != nil {
+			return nil, fmt.Errorf("failed to resolve the alias %s of model %s: %w", ref.Alias, ref.Name, err)
+		}
+		version = response.ModelVersion.Version
+	case ref.Stage != "":
+		version, err = r.latestVersion(ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if version == "" {
+		return nil, fmt.Errorf("model registry uri %s does not resolve to a model version", storageUri)
+	}
+
+	response := struct {
+		ArtifactURI string `json:"artifact_uri"`
+	}{}
+	query := url.Values{"name": {ref.Name}, "version": {version}}
+	if err := r.call(http.MethodGet, "model-versions/get-download-uri", query, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get the artifact uri of version %s of model %s: %w", version, ref.Name, err)
+	}
+	artifactUri, err := artifactStorageURI(response.ArtifactURI)
+	if err != nil {
+		return nil, fmt.Errorf("version %s of model %s: %w", version, ref.Name, err)
+	}
+	return &ResolvedModel{StorageURI: artifactUri, Version: version}, nil
+}
+
+// latestVersion returns the latest version of the model in the stage of the reference.
+func (r *MLflowResolver) latestVersion(ref *mlflowModelRef) (string, error) {
+	request := map[string]interface{}{"name": ref.Name}
+	if !strings.EqualFold(ref.Stage, "latest") {
+		request["stages"] = []string{ref.Stage}
+	}
+	response := struct {
+		ModelVersions []mlflowModelVersion `json:"model_versions"`
+	}{}
+	if err := r.call(http.MethodPost, "registered-models/get-latest-versions", nil, request, &response); err != nil {
+		return "", fmt.Errorf("failed to get the latest versions of model %s: %w", ref.Name, err)
+	}
+	// without stages, the latest version of every stage is returned
+	latest, latestVersion := "", uint64(0)
+	for _, modelVersion := range response.ModelVersions {
+		version, err := strconv.ParseUint(modelVersion.Version, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid version %q of model %s", modelVersion.Version, ref.Name)
+		}
+		if latest == "" || version > latestVersion {
+			latest, latestVersion = modelVersion.Version, version
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("model %s has no version in stage %s", ref.Name, ref.Stage)
+	}
+	return latest, nil
+}
+
+// call calls the endpoint of the model registry REST API of the tracking server and decodes the
+// response into result.
+func (r *MLflowResolver) call(method string, endpoint string, query url.Values, request interface{}, result interface{}) error {
+	apiUrl := strings.TrimSuffix(r.TrackingURI, "/") + "/api/2.0/mlflow/" + endpoint
+	if len(query) > 0 {
+		apiUrl += "?" + query.Encode()
+	}
+	var body io.Reader
+	if request != nil {
+		encoded, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, apiUrl, body)
+	if err != nil {
+		return err
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	} else if r.Username != "" {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// the error of the tracking server is a json object with an error_code and a message
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("the tracking server responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// artifactStorageURI converts the artifact uri of a model version to the storage uri it is
+// downloaded from. The artifacts proxied by the tracking server are not supported.
+func artifactStorageURI(artifactUri string) (string, error) {
+	switch {
+	case artifactUri == "":
+		return "", fmt.Errorf("the model version has no artifact uri")
+	case strings.HasPrefix(artifactUri, "mlflow-artifacts:"), strings.HasPrefix(artifactUri, "runs:"),
+		strings.HasPrefix(artifactUri, "dbfs:"):
+		return "", fmt.Errorf("the artifact uri %s is served by the tracking server, the model artifacts must be in a storage "+
+			"the models are downloaded from", artifactUri)
+	case strings.HasPrefix(artifactUri, "/"):
+		return string(File) + artifactUri, nil
+	}
+	if match := wasbsURIRegex.FindStringSubmatch(artifactUri); match != nil {
+		return fmt.Sprintf("https://%s/%s/%s", match[2], match[1], match[3]), nil
+	}
+	return artifactUri, nil
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestParseMLflowURI(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	scenarios := map[string]struct {
+		uri      string
+		expected *mlflowModelRef
+	}{
+		"Version":      {uri: "models:/fraud-detector/3", expected: &mlflowModelRef{Name: "fraud-detector", Version: "3"}},
+		"Stage":        {uri: "models:/fraud-detector/Production", expected: &mlflowModelRef{Name: "fraud-detector", Stage: "Production"}},
+		"Alias":        {uri: "models:/fraud-detector@champion", expected: &mlflowModelRef{Name: "fraud-detector", Alias: "champion"}},
+		"MLflowScheme": {uri: "mlflow://fraud-detector/latest", expected: &mlflowModelRef{Name: "fraud-detector", Stage: "latest"}},
+		"NestedName":   {uri: "models:/team/fraud-detector/2", expected: &mlflowModelRef{Name: "team/fraud-detector", Version: "2"}},
+		"NoVersion":    {uri: "models:/fraud-detector"},
+		"NoName":       {uri: "models:/@champion"},
+		"EmptyStage":   {uri: "mlflow://fraud-detector/"},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			ref, err := parseMLflowURI(scenario.uri)
+			if scenario.expected == nil {
+				g.Expect(err).To(gomega.HaveOccurred())
+				return
+			}
+			g.Expect(err).To(gomega.BeNil())
+			g.Expect(ref).To(gomega.Equal(scenario.expected))
+		})
+	}
+}
+
+func TestIsModelRegistryURI(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	unregisterProviders(t)
+	g.Expect(IsModelRegistryURI("models:/fraud-detector/Production")).To(gomega.BeTrue())
+	g.Expect(IsModelRegistryURI("mlflow://fraud-detector/Production")).To(gomega.BeTrue())
+	g.Expect(IsModelRegistryURI("s3://models/fraud-detector")).To(gomega.BeFalse())
+
+	// the model registry prefixes can not be shadowed by a plugin
+	g.Expect(RegisterProvider(MLflowPrefix, func() (Provider, error) { return &ExecProvider{}, nil })).NotTo(gomega.Succeed())
+	g.Expect(IsModelRegistryURI("mlflow://fraud-detector/Production")).To(gomega.BeTrue())
+}
+
+// newMLflowServer serves the model registry API for the versions of the fraud-detector model, the
+// Production stage and the champion alias pointing to version 2.
+func newMLflowServer(t *testing.T, artifactUris map[string]string) *httptest.Server {
+	versions := []mlflowModelVersion{
+		{Name: "fraud-detector", Version: "1"},
+		{Name: "fraud-detector", Version: "2"},
+		{Name: "fraud-detector", Version: "10"},
+	}
+	respond := func(w http.ResponseWriter, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/2.0/mlflow/registered-models/get-latest-versions":
+			request := struct {
+				Name   string   `json:"name"`
+				Stages []string `json:"stages"`
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Name != "fraud-detector" {
+				http.Error(w, `{"error_code": "RESOURCE_DOES_NOT_EXIST"}`, http.StatusNotFound)
+				return
+			}
+			switch {
+			case len(request.Stages) == 0:
+				respond(w, map[string]interface{}{"model_versions": versions})
+			case request.Stages[0] == "Production":
+				respond(w, map[string]interface{}{"model_versions": versions[1:2]})
+			default:
+				respond(w, map[string]interface{}{})
+			}
+		case "/api/2.0/mlflow/registered-models/alias":
+			if query.Get("name") != "fraud-detector" || query.Get("alias") != "champion" {
+				http.Error(w, `{"error_code": "RESOURCE_DOES_NOT_EXIST"}`, http.StatusNotFound)
+				return
+			}
+			respond(w, map[string]interface{}{"model_version": versions[1]})
+		case "/api/2.0/mlflow/model-versions/get-download-uri":
+			artifactUri, ok := artifactUris[query.Get("version")]
+			if query.Get("name") != "fraud-detector" || !ok {
+				http.Error(w, `{"error_code": "RESOURCE_DOES_NOT_EXIST"}`, http.StatusNotFound)
+				return
+			}
+			respond(w, map[string]string{"artifact_uri": artifactUri})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMLflowResolver(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	server := newMLflowServer(t, map[string]string{
+		"1":  "s3://mlflow/1/run1/artifacts/model",
+		"2":  "wasbs://mlflow@account.blob.core.windows.net/1/run2/artifacts/model",
+		"10": "mlflow-artifacts:/1/run10/artifacts/model",
+	})
+	resolver := &MLflowResolver{TrackingURI: server.URL + "/", Token: "token", Client: server.Client()}
+
+	scenarios := map[string]struct {
+		uri      string
+		expected *ResolvedModel
+	}{
+		"Version": {
+			uri:      "models:/fraud-detector/1",
+			expected: &ResolvedModel{StorageURI: "s3://mlflow/1/run1/artifacts/model", Version: "1"},
+		},
+		"Stage": {
+			uri:      "models:/fraud-detector/Production",
+			expected: &ResolvedModel{StorageURI: "https://account.blob.core.windows.net/mlflow/1/run2/artifacts/model", Version: "2"},
+		},
+		"Alias": {
+			uri:      "mlflow://fraud-detector@champion",
+			expected: &ResolvedModel{StorageURI: "https://account.blob.core.windows.net/mlflow/1/run2/artifacts/model", Version: "2"},
+		},
+		// the latest version is 10, whose artifacts are proxied by the tracking server
+		"ProxiedArtifacts": {uri: "models:/fraud-detector/latest"},
+		"EmptyStage":       {uri: "models:/fraud-detector/Staging"},
+		"UnknownVersion":   {uri: "models:/fraud-detector/4"},
+		"UnknownModel":     {uri: "models:/churn/Production"},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			resolved, err := resolver.Resolve(scenario.uri)
+			if scenario.expected == nil {
+				g.Expect(err).To(gomega.HaveOccurred())
+				return
+			}
+			g.Expect(err).To(gomega.BeNil())
+			g.Expect(resolved).To(gomega.Equal(scenario.expected))
+		})
+	}
+
+	unauthorized := &MLflowResolver{TrackingURI: server.URL, Client: server.Client()}
+	_, err := unauthorized.Resolve("models:/fraud-detector/1")
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("status 401")))
+}
+
+func TestNewMLflowResolverFromEnv(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	t.Setenv("MLFLOW_TRACKING_URI", "")
+	g.Expect(NewMLflowResolverFromEnv()).To(gomega.BeNil())
+
+	t.Setenv("MLFLOW_TRACKING_URI", "https://mlflow.example.com")
+	t.Setenv("MLFLOW_TRACKING_USERNAME", "user")
+	t.Setenv("MLFLOW_TRACKING_PASSWORD", "password")
+	resolver := NewMLflowResolverFromEnv()
+	g.Expect(resolver.TrackingURI).To(gomega.Equal("https://mlflow.example.com"))
+	g.Expect(resolver.Username).To(gomega.Equal("user"))
+	g.Expect(resolver.Password).To(gomega.Equal("password"))
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const (
+	// ModelcarModelDir is the directory of the model in a modelcar image
+	ModelcarModelDir = "models"
+	// OCITitleAnnotation names the file of an ORAS artifact layer
+	OCITitleAnnotation = "org.opencontainers.image.title"
+	// OCIUnpackAnnotation marks the ORAS artifact layers which are gzipped tarballs of a directory
+	OCIUnpackAnnotation = "io.deis.oras.content.unpack"
+	// OCIWhiteoutPrefix prefixes the names of the files removing the files of the earlier layers
+	OCIWhiteoutPrefix = ".wh."
+	// OCIOpaqueWhiteout removes the content of its directory from the earlier layers
+	OCIOpaqueWhiteout = OCIWhiteoutPrefix + OCIWhiteoutPrefix + ".opq"
+)
+
+// OCIProvider provides the models of oci://{registry}/{repository}[:tag|@digest] images. The model
+// of a modelcar image is the models directory of its filesystem, while every file of an ORAS
+// artifact is part of the model.
+type OCIProvider struct {
+	// Keychain provides the registry credentials. If nil, the image pull secrets mounted under
+	// PullSecretsDir are loaded on every pull, so that the rotated secrets are used, and the
+	// registries are accessed anonymously if PullSecretsDir is empty too.
+	Keychain authn.Keychain
+	// PullSecretsDir is the directory the image pull secrets are mounted into by name
+	PullSecretsDir string
+	// ArchiveLimits limit the content extracted from the layers, DefaultArchiveLimits are used if nil
+	ArchiveLimits *ArchiveLimits
+	// Insecure allows registries served over plain HTTP
+	Insecure bool
+	// Limiter limits the download throughput, it is unlimited if nil
+	Limiter *BandwidthLimiter
+}
+
+var _ Provider = (*OCIProvider)(nil)
+
+func (p *OCIProvider) DownloadModel(modelDir string, modelName string, storageUri string, checksum *Checksum) error {
+	log.Info("Download model ", "modelName", modelName, "storageUri", storageUri, "modelDir", modelDir)
+	var nameOptions []name.Option
+	if p.Insecure {
+		nameOptions = append(nameOptions, name.Insecure)
+	}
+	ref, err := name.ParseReference(strings.TrimPrefix(storageUri, string(OCI)), nameOptions...)
+	if err != nil {
+		return fmt.Errorf("invalid uri %s: %w", storageUri, err)
+	}
+	keychain := p.Keychain
+	if keychain == nil && p.PullSecretsDir != "" {
+		keychain = NewDockerConfigKeychain(p.PullSecretsDir)
+	} else if keychain == nil {
+		keychain = authn.NewMultiKeychain()
+	}
+	image, err := remote.Image(ref,
+		remote.WithAuthFromKeychain(keychain),
+		remote.WithPlatform(v1.Platform{OS: "linux", Architecture: runtime.GOARCH}))
+	if err != nil {
+		return fmt.Errorf("unable to pull %s: %w", ref, err)
+	}
+	manifest, err := image.Manifest()
+	if err != nil {
+		return fmt.Errorf("unable to read the manifest of %s: %w", ref, err)
+	}
+	modelPath := filepath.Join(modelDir, modelName)
+	limits := DefaultArchiveLimits
+	if p.ArchiveLimits != nil {
+		limits = *p.ArchiveLimits
+	}
+	isImage := manifest.Config.MediaType == types.OCIConfigJSON || manifest.Config.MediaType == types.DockerConfigJSON
+	var e *extractor
+	if isImage {
+		// the limits apply to the model directory of the whole image
+		if e, err = newExtractor(modelPath, limits); err != nil {
+			return err
+		}
+	}
+	for _, descriptor := range manifest.Layers {
+		layer, err := image.LayerByDigest(descriptor.Digest)
+		if err != nil {
+			return fmt.Errorf("unable to get layer %s of %s: %w", descriptor.Digest, ref, err)
+		}
+		if isImage {
+			err = p.extractImageLayer(layer, e)
+		} else {
+			err = p.extractArtifactLayer(layer, descriptor, modelPath, limits)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to extract layer %s of %s: %w", descriptor.Digest, ref, err)
+		}
+	}
+	if checksum != nil && checksum.Digest != "" {
+		// the digest of an image is the digest of its manifest
+		digest, err := image.Digest()
+		if err != nil {
+			return err
+		}
+		return verifyDownload(modelPath, checksum, digest.String())
+	}
+	return verifyDownload(modelPath, checksum, "")
+}
+
+// extractImageLayer extracts the modelcar model directory of an image layer with e. Later layers
+// overwrite the files of the earlier ones and their whiteouts remove them, while the links out of
+// the model directory and the special files are skipped.
+func (p *OCIProvider) extractImageLayer(layer v1.Layer, e *extractor) error {
+	reader, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	uncompressed, err := uncompress(layer, p.Limiter.Reader(reader))
+	if err != nil {
+		return err
+	}
+	// the paths extracted from this layer, which the opaque whiteouts do not remove
+	extracted := map[string]bool{}
+	tr := tar.NewReader(uncompressed)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("unable to access next tar file: %w", err)
+		}
+		relativePath, ok := modelcarPath(header.Name)
+		if !ok {
+			continue
+		}
+		dir, base := path.Split(relativePath)
+		switch {
+		case base == OCIOpaqueWhiteout:
+			err = removeContent(e, dir, extracted)
+		case strings.HasPrefix(base, OCIWhiteoutPrefix):
+			err = e.remove(dir + strings.TrimPrefix(base, OCIWhiteoutPrefix))
+		default:
+			header.Name = relativePath
+			if header.Typeflag == tar.TypeLink || (header.Typeflag == tar.TypeSymlink && path.IsAbs(header.Linkname)) {
+				linkTarget, ok := modelcarPath(header.Linkname)
+				if !ok {
+					log.Info("Skipping the link out of the model directory", "file", header.Name, "link", header.Linkname)
+					continue
+				}
+				header.Linkname = linkTarget
+				if header.Typeflag == tar.TypeSymlink {
+					header.Linkname = relativeLink(relativePath, linkTarget)
+				}
+			}
+			for extractedPath := relativePath; extractedPath != "."; extractedPath = path.Dir(extractedPath) {
+				extracted[extractedPath] = true
+			}
+			err = e.extractTarEntry(header, tr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// modelcarPath returns the path of an image file in the modelcar model directory, or false if the
+// file is not in it.
+func modelcarPath(name string) (string, bool) {
+	return strings.CutPrefix(path.Clean(strings.TrimPrefix(name, "/")), ModelcarModelDir+"/")
+}
+
+// removeContent removes the content of a directory extracted from the earlier layers.
+func removeContent(e *extractor, dir string, extracted map[string]bool) error {
+	target, err := e.target(dir)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(target)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if entryPath := path.Join(dir, entry.Name()); !extracted[entryPath] {
+			if err := e.remove(entryPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// uncompress returns the uncompressed content of the layer read from reader.
+func uncompress(layer v1.Layer, reader io.Reader) (io.Reader, error) {
+	mediaType, err := layer.MediaType()
+	if err != nil {
+		return nil, err
+	}
+	switch mediaType {
+	case types.OCIUncompressedLayer, types.DockerUncompressedLayer:
+		return reader, nil
+	case types.OCILayer, types.DockerLayer, types.DockerForeignLayer:
+		return gzip.NewReader(reader)
+	}
+	return nil, fmt.Errorf("unsupported layer media type %s", mediaType)
+}
+
+// extractArtifactLayer writes the file of an ORAS artifact layer, or extracts the directory it is a tarball of.
+func (p *OCIProvider) extractArtifactLayer(layer v1.Layer, descriptor v1.Descriptor, modelPath string, limits ArchiveLimits) error {
+	title := descriptor.Annotations[OCITitleAnnotation]
+	if title == "" {
+		log.Info("Skipping the artifact layer without a title", "digest", descriptor.Digest)
+		return nil
+	}
+	fileFullPath := filepath.Join(modelPath, title)
+	if fileFullPath != filepath.Clean(modelPath) && !strings.HasPrefix(fileFullPath, filepath.Clean(modelPath)+string(os.PathSeparator)) {
+		return fmt.Errorf("%s: illegal file path", title)
+	}
+	reader, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if descriptor.Annotations[OCIUnpackAnnotation] == "true" {
+		return extractArchive(p.Limiter.Reader(reader), Tar, fileFullPath, limits)
+	}
+	return writeFile(fileFullPath, p.Limiter.Reader(reader))
+}
+
+func writeFile(fileFullPath string, reader io.Reader) error {
+	file, err := createNewFile(fileFullPath)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		closeErr := file.Close()
+		if closeErr != nil {
+			log.Error(closeErr, "failed to close file")
+		}
+	}(file)
+	if _, err := io.Copy(file, reader); err != nil {
+		return fmt.Errorf("unable to copy file content: %w", err)
+	}
+	return nil
+}
+
+// dockerConfigKeychain resolves the registry credentials of the image pull secrets mounted by
+// name under a directory, which hold either a .dockerconfigjson or a legacy .dockercfg key.
+type dockerConfigKeychain struct {
+	auths map[string]authn.AuthConfig
+}
+
+// NewDockerConfigKeychain loads the image pull secrets mounted under dir. Secrets which can not be
+// read are skipped, so that a missing secret does not prevent pulling public images.
+func NewDockerConfigKeychain(dir string) authn.Keychain {
+	keychain := &dockerConfigKeychain{auths: map[string]authn.AuthConfig{}}
+	secretDirs, err := os.ReadDir(dir)
+	if err != nil {
+		return keychain
+	}
+	for _, secretDir := range secretDirs {
+		if err := keychain.load(filepath.Join(dir, secretDir.Name())); err != nil {
+			log.Error(err, "Failed to load image pull secret", "secret", secretDir.Name())
+		}
+	}
+	return keychain
+}
+
+func (k *dockerConfigKeychain) load(secretDir string) error {
+	if content, err := os.ReadFile(filepath.Join(secretDir, ".dockerconfigjson")); err == nil {
+		var config struct {
+			Auths map[string]authn.AuthConfig `json:"auths"`
+		}
+		if err := json.Unmarshal(content, &config); err != nil {
+			return err
+		}
+		k.add(config.Auths)
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(secretDir, ".dockercfg"))
+	if err != nil {
+		return err
+	}
+	var auths map[string]authn.AuthConfig
+	if err := json.Unmarshal(content, &auths); err != nil {
+		return err
+	}
+	k.add(auths)
+	return nil
+}
+
+// add keeps the credentials of the first secret listing a registry.
+func (k *dockerConfigKeychain) add(auths map[string]authn.AuthConfig) {
+	for server, auth := range auths {
+		registry := registryHost(server)
+		if _, ok := k.auths[registry]; !ok {
+			k.auths[registry] = auth
+		}
+	}
+}
+
+func (k *dockerConfigKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	if auth, ok := k.auths[registryHost(target.RegistryStr())]; ok {
+		return authn.FromConfig(auth), nil
+	}
+	return authn.Anonymous, nil
+}
+
+// registryHost returns the host of the registry server of a docker config, e.g. index.docker.io
+// for https://index.docker.io/v1/.
+func registryHost(server string) string {
+	if _, host, ok := strings.Cut(server, "://"); ok {
+		server = host
+	}
+	host, _, _ := strings.Cut(server, "/")
+	if host == "docker.io" {
+		return name.DefaultRegistry
+	}
+	return host
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"archive/tar"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/onsi/gomega"
+)
+
+// newRegistry starts an in-process registry which requires the given basic auth credentials.
+func newRegistry(t *testing.T, username string, password string) string {
+	handler := registry.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != username || pass != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	serverURL, _ := url.Parse(server.URL)
+	return serverURL.Host
+}
+
+func TestOCIProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	host := newRegistry(t, "user", "secret")
+	auth := remote.WithAuth(&authn.Basic{Username: "user", Password: "secret"})
+
+	// A modelcar image with the model in the models directory
+	modelcar, err := crane.Image(map[string][]byte{
+		"models/1/model.joblib": []byte("model"),
+		"models/config.json":    []byte("{}"),
+		"etc/hostname":          []byte("modelcar"),
+	})
+	g.Expect(err).To(gomega.BeNil())
+	modelcarRef, err := name.ParseReference(host + "/models/sklearn:v1")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(remote.Write(modelcarRef, modelcar, auth)).To(gomega.Succeed())
+
+	// An ORAS artifact with a single file
+	artifact, err := mutate.Append(
+		mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), "application/vnd.kserve.model.config.v1+json"),
+		mutate.Addendum{
+			Layer:       static.NewLayer([]byte("onnx"), "application/vnd.kserve.model.layer.v1"),
+			Annotations: map[string]string{OCITitleAnnotation: "model.onnx"},
+		})
+	g.Expect(err).To(gomega.BeNil())
+	artifactRef, err := name.ParseReference(host + "/models/onnx:v1")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(remote.Write(artifactRef, artifact, auth)).To(gomega.Succeed())
+
+	// The credentials are read from the image pull secret mounted after the provider is created
+	secretsDir := t.TempDir()
+	provider := &OCIProvider{PullSecretsDir: secretsDir}
+	g.Expect(os.MkdirAll(filepath.Join(secretsDir, "registry-credentials"), 0755)).To(gomega.Succeed())
+	dockerConfig := fmt.Sprintf(`{"auths":{"http://%s":{"auth":"%s"}}}`, host, base64.StdEncoding.EncodeToString([]byte("user:secret")))
+	g.Expect(os.WriteFile(filepath.Join(secretsDir, "registry-credentials", ".dockerconfigjson"), []byte(dockerConfig), 0644)).To(gomega.Succeed())
+
+	modelDir := t.TempDir()
+	g.Expect(provider.DownloadModel(modelDir, "sklearn", "oci://"+host+"/models/sklearn:v1", nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "sklearn", "1", "model.joblib"))).To(gomega.Equal([]byte("model")))
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "sklearn", "config.json"))).To(gomega.Equal([]byte("{}")))
+	g.Expect(filepath.Join(modelDir, "sklearn", "etc")).NotTo(gomega.BeAnExistingFile())
+
+	// The digest of an image is the digest of its manifest
+	digest, err := artifact.Digest()
+	g.Expect(err).To(gomega.BeNil())
+	checksum := &Checksum{Digest: digest.String()}
+	g.Expect(provider.DownloadModel(modelDir, "onnx", "oci://"+host+"/models/onnx:v1", checksum)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "onnx", "model.onnx"))).To(gomega.Equal([]byte("onnx")))
+
+	// The whiteouts of the later layers remove the files of the earlier ones
+	layered, err := mutate.AppendLayers(empty.Image,
+		static.NewLayer(tarArchive(t,
+			archiveEntry{name: "models/model.joblib", content: "model"},
+			archiveEntry{name: "models/stale.joblib", content: "stale"},
+			archiveEntry{name: "models/1/", typeflag: tar.TypeDir},
+			archiveEntry{name: "models/1/stale.joblib", content: "stale"},
+		), types.OCIUncompressedLayer),
+		static.NewLayer(tarArchive(t,
+			archiveEntry{name: "models/.wh.stale.joblib"},
+			archiveEntry{name: "models/1/.wh..wh..opq"},
+			archiveEntry{name: "models/1/model.joblib", content: "model"},
+			archiveEntry{name: "models/latest", linkname: "/models/1/model.joblib", typeflag: tar.TypeSymlink},
+			archiveEntry{name: "models/hostname", linkname: "/etc/hostname", typeflag: tar.TypeSymlink},
+		), types.OCIUncompressedLayer))
+	g.Expect(err).To(gomega.BeNil())
+	layeredRef, err := name.ParseReference(host + "/models/layered:v1")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(remote.Write(layeredRef, layered, auth)).To(gomega.Succeed())
+	g.Expect(provider.DownloadModel(modelDir, "layered", "oci://"+host+"/models/layered:v1", nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "layered", "model.joblib"))).To(gomega.Equal([]byte("model")))
+	g.Expect(filepath.Join(modelDir, "layered", "stale.joblib")).NotTo(gomega.BeAnExistingFile())
+	g.Expect(filepath.Join(modelDir, "layered", "1", "stale.joblib")).NotTo(gomega.BeAnExistingFile())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "layered", "1", "model.joblib"))).To(gomega.Equal([]byte("model")))
+	g.Expect(os.Readlink(filepath.Join(modelDir, "layered", "latest"))).To(gomega.Equal(filepath.Join("1", "model.joblib")))
+	g.Expect(filepath.Join(modelDir, "layered", "hostname")).NotTo(gomega.BeAnExistingFile())
+
+	// The archive limits apply to the model directory of the whole image
+	limited := &OCIProvider{PullSecretsDir: secretsDir, ArchiveLimits: &ArchiveLimits{MaxSize: 1 << 20, MaxFiles: 3}}
+	g.Expect(limited.DownloadModel(t.TempDir(), "layered", "oci://"+host+"/models/layered:v1", nil)).NotTo(gomega.Succeed())
+
+	anonymous := &OCIProvider{}
+	g.Expect(anonymous.DownloadModel(t.TempDir(), "onnx", "oci://"+host+"/models/onnx:v1", nil)).NotTo(gomega.Succeed())
+}
+
+func TestRegistryHost(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	g.Expect(registryHost("https://index.docker.io/v1/")).To(gomega.Equal("index.docker.io"))
+	g.Expect(registryHost("docker.io")).To(gomega.Equal("index.docker.io"))
+	g.Expect(registryHost("registry.example.com:5000")).To(gomega.Equal("registry.example.com:5000"))
+}
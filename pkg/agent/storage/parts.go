@@ -0,0 +1,250 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// PartsFileSuffix is the suffix of the file recording the downloaded parts of a file being downloaded,
+// from which its download is resumed.
+const PartsFileSuffix = ".parts"
+
+// DownloadOptions configures the ranged downloads of the S3 and GCS providers.
+type DownloadOptions struct {
+	// PartSize is the size of the ranges the objects are downloaded in, the objects are
+	// downloaded whole if it is 0
+	PartSize int64
+	// Parallelism is the number of parts of the model downloaded at once
+	Parallelism int
+}
+
+// DefaultDownloadOptions are the options of the providers which are not configured otherwise.
+var DefaultDownloadOptions = DownloadOptions{
+	PartSize:    64 << 20,
+	Parallelism: 8,
+}
+
+func (o DownloadOptions) Validate() error {
+	if o.PartSize < 0 {
+		return fmt.Errorf("the download part size must not be negative")
+	}
+	if o.Parallelism < 1 {
+		return fmt.Errorf("the download parallelism must be positive")
+	}
+	return nil
+}
+
+// fetchRange writes length bytes of an object from offset to w, at offsets relative to offset.
+// The whole object is fetched if length is negative.
+type fetchRange func(ctx context.Context, w io.WriterAt, offset int64, length int64) error
+
+// objectDownload is an object downloaded to a file.
+type objectDownload struct {
+	fileName string
+	// size is the size of the object, or 0 if it is unknown in which case it is downloaded whole
+	size int64
+	// version identifies the content of the object, the parts of another version are not resumed
+	version string
+	fetch   fetchRange
+}
+
+// partsJournal records the downloaded parts of a file.
+type partsJournal struct {
+	Version  string `json:"version"`
+	Size     int64  `json:"size"`
+	PartSize int64  `json:"partSize"`
+	Done     []bool `json:"done"`
+}
+
+type filePart struct {
+	download *fileDownload
+	index    int
+}
+
+// fileDownload is the state of the download of an object to its file.
+type fileDownload struct {
+	object  objectDownload
+	file    *os.File
+	mu      sync.Mutex
+	journal partsJournal
+	pending int
+}
+
+// downloadObjects downloads the objects in parts, options.Parallelism parts at once across all the
+// objects. The parts are streamed to their offset in the files, and the parts downloaded before an
+// interruption are not downloaded again as long as the object did not change.
+func downloadObjects(ctx context.Context, objects []objectDownload, options DownloadOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var parts []filePart
+	var downloads []*fileDownload
+	defer func() {
+		for _, download := range downloads {
+			if err := download.file.Close(); err != nil {
+				log.Error(err, "failed to close file")
+			}
+		}
+	}()
+	for _, object := range objects {
+		download, err := openFileDownload(object, options.PartSize)
+		if err != nil {
+			return err
+		}
+		downloads = append(downloads, download)
+		for i, done := range download.journal.Done {
+			if !done {
+				parts = append(parts, filePart{download: download, index: i})
+			}
+		}
+	}
+	partCh := make(chan filePart)
+	errCh := make(chan error, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < max(options.Parallelism, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range partCh {
+				if err := part.download.fetchPart(ctx, part.index); err != nil {
+					select {
+					case errCh <- err:
+					default:
+					}
+					cancel()
+				}
+			}
+		}()
+	}
+sendParts:
+	for _, part := range parts {
+		select {
+		case partCh <- part:
+		case <-ctx.Done():
+			break sendParts
+		}
+	}
+	close(partCh)
+	wg.Wait()
+	select {
+	case err := <-errCh:
+		return err
+	default:
+	}
+	return ctx.Err()
+}
+
+// openFileDownload opens the file of the object, resuming its download if the journal of a previous
+// download of the same version of the object is found.
+func openFileDownload(object objectDownload, partSize int64) (*fileDownload, error) {
+	if object.size <= 0 || partSize <= 0 {
+		// the object is downloaded whole
+		partSize = 0
+	}
+	partCount := 1
+	if partSize > 0 {
+		partCount = int((object.size + partSize - 1) / partSize)
+	}
+	journal := partsJournal{Version: object.version, Size: object.size, PartSize: partSize, Done: make([]bool, partCount)}
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if previous, ok := readPartsJournal(object.fileName); ok && partSize > 0 && object.version != "" &&
+		previous.Version == journal.Version && previous.Size == journal.Size && previous.PartSize == journal.PartSize &&
+		len(previous.Done) == partCount && FileExists(object.fileName) {
+		log.Info("Resuming the download of the file", "file", object.fileName)
+		journal = previous
+		flags = os.O_RDWR
+	}
+	if err := os.MkdirAll(filepath.Dir(object.fileName), os.ModePerm); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(object.fileName, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file %s: %w", object.fileName, err)
+	}
+	download := &fileDownload{object: object, file: file, journal: journal}
+	for _, done := range journal.Done {
+		if !done {
+			download.pending++
+		}
+	}
+	if download.pending == 0 {
+		// every part of the file was downloaded before the journal could be removed
+		return download, removePartsJournal(object.fileName)
+	}
+	if err := download.writeJournal(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return download, nil
+}
+
+func (d *fileDownload) fetchPart(ctx context.Context, index int) error {
+	offset, length := int64(0), int64(-1)
+	if d.journal.PartSize > 0 {
+		offset = int64(index) * d.journal.PartSize
+		length = min(d.journal.PartSize, d.journal.Size-offset)
+	}
+	if err := d.object.fetch(ctx, io.NewOffsetWriter(d.file, offset), offset, length); err != nil {
+		return fmt.Errorf("unable to download %s: %w", d.object.fileName, err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.journal.Done[index] = true
+	d.pending--
+	if d.pending == 0 {
+		return removePartsJournal(d.object.fileName)
+	}
+	return d.writeJournal()
+}
+
+// writeJournal replaces the journal of the file so that it is never found half written.
+func (d *fileDownload) writeJournal() error {
+	content, err := json.Marshal(d.journal)
+	if err != nil {
+		return err
+	}
+	journalFile := d.object.fileName + PartsFileSuffix
+	if err := os.WriteFile(journalFile+".tmp", content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(journalFile+".tmp", journalFile)
+}
+
+func readPartsJournal(fileName string) (partsJournal, bool) {
+	var journal partsJournal
+	content, err := os.ReadFile(fileName + PartsFileSuffix)
+	if err != nil {
+		return journal, false
+	}
+	if err := json.Unmarshal(content, &journal); err != nil {
+		return journal, false
+	}
+	return journal, true
+}
+
+func removePartsJournal(fileName string) error {
+	if err := os.Remove(fileName + PartsFileSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/kserve/kserve/pkg/agent/mocks"
+	"github.com/onsi/gomega"
+)
+
+// rangeSource serves the ranges of content, failing the ranges from failFrom on.
+type rangeSource struct {
+	content  string
+	mu       sync.Mutex
+	failFrom int64
+	fetched  []int64
+}
+
+func (r *rangeSource) fetch(_ context.Context, w io.WriterAt, offset int64, length int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failFrom >= 0 && offset >= r.failFrom {
+		return errors.New("connection reset")
+	}
+	if length < 0 {
+		length = int64(len(r.content)) - offset
+	}
+	r.fetched = append(r.fetched, offset)
+	_, err := w.WriteAt([]byte(r.content[offset:offset+length]), 0)
+	return err
+}
+
+func TestDownloadObjectsResume(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	fileName := filepath.Join(t.TempDir(), "model", "model.bin")
+	source := &rangeSource{content: "0123456789abcdefghij", failFrom: 12}
+	object := objectDownload{fileName: fileName, size: 20, version: "v1", fetch: source.fetch}
+	options := DownloadOptions{PartSize: 4, Parallelism: 1}
+
+	// The download is interrupted with the first three parts downloaded
+	g.Expect(downloadObjects(context.Background(), []objectDownload{object}, options)).NotTo(gomega.Succeed())
+	g.Expect(source.fetched).To(gomega.Equal([]int64{0, 4, 8}))
+	g.Expect(fileName + PartsFileSuffix).To(gomega.BeAnExistingFile())
+
+	// Only the missing parts are downloaded again
+	source.failFrom, source.fetched = -1, nil
+	g.Expect(downloadObjects(context.Background(), []objectDownload{object}, options)).To(gomega.Succeed())
+	g.Expect(source.fetched).To(gomega.Equal([]int64{12, 16}))
+	g.Expect(os.ReadFile(fileName)).To(gomega.Equal([]byte(source.content)))
+	g.Expect(fileName + PartsFileSuffix).NotTo(gomega.BeAnExistingFile())
+
+	// The parts of another version of the object are not resumed
+	source.failFrom, source.fetched = 8, nil
+	g.Expect(downloadObjects(context.Background(), []objectDownload{object}, options)).NotTo(gomega.Succeed())
+	source.content, source.failFrom, source.fetched = "abcdefghij0123456789", -1, nil
+	object.version = "v2"
+	g.Expect(downloadObjects(context.Background(), []objectDownload{object}, options)).To(gomega.Succeed())
+	g.Expect(source.fetched).To(gomega.HaveLen(5))
+	g.Expect(os.ReadFile(fileName)).To(gomega.Equal([]byte(source.content)))
+
+	// Objects of unknown size are downloaded whole
+	source.fetched = nil
+	object.size = 0
+	g.Expect(downloadObjects(context.Background(), []objectDownload{object}, DownloadOptions{PartSize: 4, Parallelism: 4})).To(gomega.Succeed())
+	g.Expect(source.fetched).To(gomega.Equal([]int64{0}))
+	g.Expect(os.ReadFile(fileName)).To(gomega.Equal([]byte(source.content)))
+}
+
+func TestDownloadObjectsParallel(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	dir := t.TempDir()
+	var objects []objectDownload
+	for i := 0; i < 3; i++ {
+		source := &rangeSource{content: strings.Repeat(fmt.Sprint(i), 1000), failFrom: -1}
+		objects = append(objects, objectDownload{
+			fileName: filepath.Join(dir, fmt.Sprintf("shard-%d", i)),
+			size:     1000,
+			version:  "v1",
+			fetch:    source.fetch,
+		})
+	}
+	g.Expect(downloadObjects(context.Background(), objects, DownloadOptions{PartSize: 64, Parallelism: 8})).To(gomega.Succeed())
+	for i, object := range objects {
+		g.Expect(os.ReadFile(object.fileName)).To(gomega.Equal([]byte(strings.Repeat(fmt.Sprint(i), 1000))))
+	}
+}
+
+type rangeS3Client struct {
+	s3iface.S3API
+	objects map[string]string
+}
+
+func (c *rangeS3Client) ListObjects(input *s3.ListObjectsInput) (*s3.ListObjectsOutput, error) {
+	output := &s3.ListObjectsOutput{}
+	for key, content := range c.objects {
+		if strings.HasPrefix(key, *input.Prefix) {
+			output.Contents = append(output.Contents, &s3.Object{
+				Key:  aws.String(key),
+				Size: aws.Int64(int64(len(content))),
+				ETag: aws.String(`"` + key + `"`),
+			})
+		}
+	}
+	return output, nil
+}
+
+// rangeS3Downloader serves the ranged requests of the objects of the client.
+type rangeS3Downloader struct {
+	client *rangeS3Client
+}
+
+func (d *rangeS3Downloader) DownloadWithIterator(_ aws.Context, iter s3manager.BatchDownloadIterator, _ ...func(*s3manager.Downloader)) error {
+	for iter.Next() {
+		object := iter.DownloadObject()
+		content := d.client.objects[*object.Object.Key]
+		var start, end int
+		if _, err := fmt.Sscanf(aws.StringValue(object.Object.Range), "bytes=%d-%d", &start, &end); err != nil {
+			return err
+		}
+		if aws.StringValue(object.Object.IfMatch) != `"`+*object.Object.Key+`"` {
+			return errors.New("precondition failed")
+		}
+		if _, err := object.Writer.WriteAt([]byte(content[start:end+1]), 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestS3ProviderRangedDownload(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	client := &rangeS3Client{objects: map[string]string{
+		"models/llm/model-00001.safetensors": strings.Repeat("a", 100),
+		"models/llm/model-00002.safetensors": strings.Repeat("b", 50),
+	}}
+	provider := &S3Provider{
+		Client:          client,
+		Downloader:      &rangeS3Downloader{client: client},
+		DownloadOptions: &DownloadOptions{PartSize: 16, Parallelism: 4},
+	}
+	modelDir := t.TempDir()
+	g.Expect(provider.DownloadModel(modelDir, "llm", "s3://bucket/models/llm/", nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "llm", "model-00001.safetensors"))).To(gomega.Equal([]byte(strings.Repeat("a", 100))))
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "llm", "model-00002.safetensors"))).To(gomega.Equal([]byte(strings.Repeat("b", 50))))
+}
+
+func TestDownloadOptionsValidate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	g.Expect(DefaultDownloadOptions.Validate()).To(gomega.Succeed())
+	g.Expect(DownloadOptions{Parallelism: 1}.Validate()).To(gomega.Succeed())
+	g.Expect(DownloadOptions{PartSize: 1 << 20}.Validate()).NotTo(gomega.Succeed())
+	g.Expect(DownloadOptions{PartSize: -1, Parallelism: 1}.Validate()).NotTo(gomega.Succeed())
+}
+
+func TestGCSProviderRangedDownload(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	client := mocks.NewMockClient()
+	g.Expect(client.Bucket("bucket").Create(context.Background(), "project", nil)).To(gomega.Succeed())
+	writer := client.Bucket("bucket").Object("models/llm/model.safetensors").NewWriter(context.Background())
+	_, err := writer.Write([]byte(strings.Repeat("weights", 10)))
+	g.Expect(err).To(gomega.BeNil())
+
+	provider := &GCSProvider{
+		Client:          client,
+		DownloadOptions: &DownloadOptions{PartSize: 16, Parallelism: 2},
+	}
+	modelDir := t.TempDir()
+	g.Expect(provider.DownloadModel(modelDir, "llm", "gs://bucket/models/llm/", nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "llm", "model.safetensors"))).To(gomega.Equal([]byte(strings.Repeat("weights", 10))))
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Environment variables of the exec plugin commands
+const (
+	PluginStorageUriEnv = "KSERVE_STORAGE_URI"
+	PluginModelPathEnv  = "KSERVE_MODEL_PATH"
+)
+
+// PluginArtifactsPath is the path of the sidecar plugin API the model artifacts are downloaded from
+const PluginArtifactsPath = "/v1/artifacts"
+
+// PluginConfig configures the provider of a custom protocol, which is either an exec plugin run by
+// the agent or a sidecar plugin served next to it.
+type PluginConfig struct {
+	// Protocol is the protocol of the uris the plugin provides, e.g. artifactory://
+	Protocol string `json:"protocol"`
+	// Command is the executable and the first arguments of an exec plugin. It is run with the storage
+	// uri and the path the model is downloaded to as its last arguments, and in the
+	// KSERVE_STORAGE_URI and KSERVE_MODEL_PATH environment variables.
+	Command []string `json:"command,omitempty"`
+	// Endpoint is the url of a sidecar plugin, e.g. http://localhost:9500. The agent gets the model
+	// from <endpoint>/v1/artifacts?storageUri=<storage uri> as an archive.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Timeout is the max duration of a download, e.g. 30m. It is unlimited if empty.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Validate checks the plugin is either an exec or a sidecar plugin of a custom protocol.
+func (c PluginConfig) Validate() error {
+	if err := validateCustomProtocol(c.ProtocolOf()); err != nil {
+		return err
+	}
+	if (len(c.Command) == 0) == (c.Endpoint == "") {
+		return fmt.Errorf("the plugin of %s must have either a command or an endpoint", c.Protocol)
+	}
+	if c.Endpoint != "" {
+		endpoint, err := url.Parse(c.Endpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("the endpoint %s of the plugin of %s is not an http(s) url", c.Endpoint, c.Protocol)
+		}
+	}
+	if _, err := c.timeout(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ProtocolOf returns the protocol of the plugin, the :// suffix is optional in the config.
+func (c PluginConfig) ProtocolOf() Protocol {
+	return Protocol(strings.TrimSuffix(c.Protocol, "://") + "://")
+}
+
+func (c PluginConfig) timeout() (time.Duration, error) {
+	if c.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(c.Timeout)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("the timeout %s of the plugin of %s is not a positive duration", c.Timeout, c.Protocol)
+	}
+	return timeout, nil
+}
+
+// NewProvider creates the provider of the plugin.
+func (c PluginConfig) NewProvider() (Provider, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	timeout, _ := c.timeout()
+	if len(c.Command) != 0 {
+		return &ExecProvider{Command: c.Command, Timeout: timeout}, nil
+	}
+	return &SidecarProvider{Endpoint: strings.TrimSuffix(c.Endpoint, "/"), Client: &http.Client{Timeout: timeout}}, nil
+}
+
+// RegisterPlugins registers the providers of the custom protocols of the plugins.
+func RegisterPlugins(plugins []PluginConfig) error {
+	for _, plugin := range plugins {
+		if err := plugin.Validate(); err != nil {
+			return err
+		}
+		if err := RegisterProvider(plugin.ProtocolOf(), plugin.NewProvider); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExecProvider downloads the models by running a command, which writes the model files into the
+// path it is given.
+type ExecProvider struct {
+	Command []string
+	// Timeout is the max duration of the command, it is unlimited if 0
+	Timeout time.Duration
+}
+
+var _ Provider = (*ExecProvider)(nil)
+
+// maxPluginOutput is the size of the end of the plugin output kept in the errors
+const maxPluginOutput = 2048
+
+func (p *ExecProvider) DownloadModel(modelDir string, modelName string, storageUri string, checksum *Checksum) error {
+	log.Info("Download model ", "modelName", modelName, "storageUri", storageUri, "modelDir", modelDir)
+	modelPath := filepath.Join(modelDir, modelName)
+	if err := os.MkdirAll(modelPath, 0777); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	args := append(append([]string{}, p.Command[1:]...), storageUri, modelPath)
+	cmd := exec.CommandContext(ctx, p.Command[0], args...) // #nosec G204
+	cmd.Env = append(os.Environ(), PluginStorageUriEnv+"="+storageUri, PluginModelPathEnv+"="+modelPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(output) > maxPluginOutput {
+			output = output[len(output)-maxPluginOutput:]
+		}
+		return fmt.Errorf("the plugin %s failed to download %s: %w: %s", p.Command[0], storageUri, err, bytes.TrimSpace(output))
+	}
+	downloadDigest := ""
+	if checksum != nil && checksum.Digest != "" {
+		// the digest of a model can only be verified if it is a single file
+		if entries, err := os.ReadDir(modelPath); err == nil && len(entries) == 1 && entries[0].Type().IsRegular() {
+			if downloadDigest, err = FileDigest(filepath.Join(modelPath, entries[0].Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return verifyDownload(modelPath, checksum, downloadDigest)
+}
+
+// SidecarProvider downloads the models from a plugin served next to the agent, which responds to
+// GET <endpoint>/v1/artifacts?storageUri=<storage uri> with the model files in an archive.
+type SidecarProvider struct {
+	Endpoint string
+	Client   *http.Client
+	// Limiter limits the download throughput, it is unlimited if nil
+	Limiter *BandwidthLimiter
+}
+
+var _ Provider = (*SidecarProvider)(nil)
+
+func (p *SidecarProvider) DownloadModel(modelDir string, modelName string, storageUri string, checksum *Checksum) error {
+	log.Info("Download model ", "modelName", modelName, "storageUri", storageUri, "modelDir", modelDir)
+	resp, err := p.Client.Get(p.Endpoint + PluginArtifactsPath + "?" + url.Values{"storageUri": {storageUri}}.Encode())
+	if err != nil {
+		return fmt.Errorf("failed to make a request to the plugin %s: %w", p.Endpoint, err)
+	}
+	defer func(Body io.ReadCloser) {
+		closeErr := Body.Close()
+		if closeErr != nil {
+			log.Error(closeErr, "failed to close body")
+		}
+	}(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxPluginOutput))
+		return fmt.Errorf("the plugin %s returned a %d response code for %s: %s", p.Endpoint, resp.StatusCode, storageUri, bytes.TrimSpace(message))
+	}
+	format, ok := ArchiveFormatOf(resp.Header.Get("Content-Type"), "")
+	if !ok {
+		return fmt.Errorf("the plugin %s returned the content type %q for %s, it must be an archive",
+			p.Endpoint, resp.Header.Get("Content-Type"), storageUri)
+	}
+	digester := newDigester()
+	body := io.TeeReader(p.Limiter.Reader(resp.Body), digester)
+	modelPath := filepath.Join(modelDir, modelName)
+	if err := extractArchive(body, format, modelPath, DefaultArchiveLimits); err != nil {
+		return fmt.Errorf("unable to extract the %s archive of %s: %w", format, storageUri, err)
+	}
+	// the archive readers may stop before the end of the content
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return fmt.Errorf("unable to read content: %w", err)
+	}
+	return verifyDownload(modelPath, checksum, formatDigest(digester))
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+// unregisterProviders removes the custom protocols registered by a test.
+func unregisterProviders(t *testing.T) {
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		providerRegistry = map[Protocol]ProviderFactory{}
+	})
+}
+
+func TestRegisterProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	unregisterProviders(t)
+	factory := func() (Provider, error) { return &ExecProvider{Command: []string{"true"}}, nil }
+
+	g.Expect(RegisterProvider("artifactory://", factory)).To(gomega.Succeed())
+	g.Expect(RegisterProvider("artifactory://", factory)).NotTo(gomega.Succeed())
+	g.Expect(RegisterProvider(S3, factory)).NotTo(gomega.Succeed())
+	g.Expect(RegisterProvider(AzureBlob, factory)).NotTo(gomega.Succeed())
+	g.Expect(RegisterProvider(MLflowPrefix, factory)).NotTo(gomega.Succeed())
+	g.Expect(RegisterProvider("Artifactory://", factory)).NotTo(gomega.Succeed())
+	g.Expect(RegisterProvider("artifactory", factory)).NotTo(gomega.Succeed())
+	g.Expect(RegisteredProtocols()).To(gomega.Equal([]Protocol{"artifactory://"}))
+	g.Expect(GetAllProtocol()).To(gomega.ContainElements(string(S3), "artifactory://"))
+
+	providers := map[Protocol]Provider{}
+	provider, err := GetProvider(providers, "artifactory://")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(provider).To(gomega.BeAssignableToTypeOf(&ExecProvider{}))
+	g.Expect(providers).To(gomega.HaveKey(Protocol("artifactory://")))
+}
+
+func TestRegisterPlugins(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	unregisterProviders(t)
+	g.Expect(RegisterPlugins([]PluginConfig{
+		{Protocol: "artifactory", Command: []string{"/plugins/artifactory"}, Timeout: "30m"},
+		{Protocol: "registry://", Endpoint: "http://localhost:9500/"},
+	})).To(gomega.Succeed())
+	g.Expect(RegisteredProtocols()).To(gomega.Equal([]Protocol{"artifactory://", "registry://"}))
+
+	provider, err := GetProvider(map[Protocol]Provider{}, "registry://")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(provider.(*SidecarProvider).Endpoint).To(gomega.Equal("http://localhost:9500"))
+
+	invalid := map[string]PluginConfig{
+		"NoCommandOrEndpoint":  {Protocol: "a://"},
+		"CommandAndEndpoint":   {Protocol: "b://", Command: []string{"/plugins/b"}, Endpoint: "http://localhost:9500"},
+		"InvalidEndpoint":      {Protocol: "c://", Endpoint: "localhost:9500"},
+		"InvalidTimeout":       {Protocol: "d://", Command: []string{"/plugins/d"}, Timeout: "forever"},
+		"BuiltinProtocol":      {Protocol: "s3://", Command: []string{"/plugins/s3"}},
+		"ModelRegistry":        {Protocol: "mlflow://", Command: []string{"/plugins/mlflow"}},
+		"RegisteredProtocol":   {Protocol: "artifactory://", Command: []string{"/plugins/artifactory"}},
+		"InvalidProtocolChars": {Protocol: "my_plugin://", Command: []string{"/plugins/mine"}},
+	}
+	for name, plugin := range invalid {
+		g.Expect(RegisterPlugins([]PluginConfig{plugin})).NotTo(gomega.Succeed(), name)
+	}
+}
+
+func TestExecProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	pluginDir := t.TempDir()
+	plugin := filepath.Join(pluginDir, "plugin")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = \"--fail\" ]; then echo \"unknown model $KSERVE_STORAGE_URI\" >&2; exit 3; fi\n" +
+		"printf '%s' \"$2\" > \"$KSERVE_MODEL_PATH/model.txt\"\n"
+	g.Expect(os.WriteFile(plugin, []byte(script), 0755)).To(gomega.Succeed())
+
+	modelDir := t.TempDir()
+	provider := &ExecProvider{Command: []string{plugin, "--download"}}
+	g.Expect(provider.DownloadModel(modelDir, "model", "artifactory://models/fraud/1", nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "model", "model.txt"))).To(gomega.Equal([]byte("artifactory://models/fraud/1")))
+
+	// the digest of a single file is verified
+	fileDigest, err := FileDigest(filepath.Join(modelDir, "model", "model.txt"))
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(provider.DownloadModel(modelDir, "verified", "artifactory://models/fraud/1", &Checksum{Digest: fileDigest})).To(gomega.Succeed())
+	g.Expect(provider.DownloadModel(modelDir, "corrupted", "artifactory://models/fraud/1", &Checksum{Digest: "sha256:" + strings.Repeat("0", 64)})).NotTo(gomega.Succeed())
+	g.Expect(filepath.Join(modelDir, "corrupted")).NotTo(gomega.BeAnExistingFile())
+
+	provider = &ExecProvider{Command: []string{plugin, "--fail"}}
+	err = provider.DownloadModel(modelDir, "failed", "artifactory://models/missing/1", nil)
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("unknown model artifactory://models/missing/1"))
+}
+
+func TestSidecarProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	tarball := tarArchive(t, archiveEntry{name: "model.joblib", content: "model"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("storageUri") {
+		case "registry://fraud/1":
+			w.Header().Set("Content-Type", "application/x-tar")
+			_, _ = w.Write(tarball)
+		case "registry://fraud/file":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("model"))
+		default:
+			http.Error(w, "unknown model", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	modelDir := t.TempDir()
+	provider := &SidecarProvider{Endpoint: server.URL, Client: server.Client()}
+	g.Expect(provider.DownloadModel(modelDir, "fraud", "registry://fraud/1", nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "fraud", "model.joblib"))).To(gomega.Equal([]byte("model")))
+	g.Expect(provider.DownloadModel(modelDir, "file", "registry://fraud/file", nil)).NotTo(gomega.Succeed())
+	err := provider.DownloadModel(modelDir, "missing", "registry://fraud/2", nil)
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("unknown model"))
+}
@@ -16,26 +16,43 @@ limitations under the License.
 
 package storage
 
+import "slices"
+
 type Provider interface {
-	DownloadModel(modelDir string, modelName string, storageUri string) error
+	// DownloadModel downloads the model to modelDir/modelName and verifies it against checksum
+	// unless it is nil. A model which fails the verification is removed.
+	DownloadModel(modelDir string, modelName string, storageUri string, checksum *Checksum) error
 }
 
 type Protocol string
 
 const (
-	S3  Protocol = "s3://"
-	GCS Protocol = "gs://"
-	//PVC   Protocol = "pvc://"
-	//File  Protocol = "file://"
-	HTTPS Protocol = "https://"
-	HTTP  Protocol = "http://"
+	S3      Protocol = "s3://"
+	GCS     Protocol = "gs://"
+	PVC     Protocol = "pvc://"
+	File    Protocol = "file://"
+	OCI     Protocol = "oci://"
+	HDFS    Protocol = "hdfs://"
+	WebHDFS Protocol = "webhdfs://"
+	HTTPS   Protocol = "https://"
+	HTTP    Protocol = "http://"
+	// AzureBlob is the protocol of the Azure Blob Storage URIs, which are https URIs matched by
+	// AzureBlobURIRegex rather than by a prefix
+	AzureBlob Protocol = "azure://"
 )
 
-var SupportedProtocols = []Protocol{S3, GCS, HTTPS, HTTP}
+var SupportedProtocols = []Protocol{S3, GCS, PVC, File, OCI, HDFS, WebHDFS, HTTPS, HTTP}
 
+// GetAllProtocol returns the prefixes of the storage uris the models are downloaded from, the
+// protocols of the providers followed by the model registry prefixes.
 func GetAllProtocol() (protocols []string) {
-	for _, protocol := range SupportedProtocols {
+	for _, protocol := range Protocols() {
 		protocols = append(protocols, string(protocol))
 	}
+	for _, prefix := range ModelRegistryPrefixes {
+		if !slices.Contains(protocols, prefix) {
+			protocols = append(protocols, prefix)
+		}
+	}
 	return protocols
 }
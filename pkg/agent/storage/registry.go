@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// ProviderFactory creates the provider of a custom protocol, the first time a model of the
+// protocol is downloaded.
+type ProviderFactory func() (Provider, error)
+
+var (
+	registryMu       sync.RWMutex
+	providerRegistry = map[Protocol]ProviderFactory{}
+
+	protocolRegex = regexp.MustCompile(`^[a-z][a-z0-9+.-]*://$`)
+)
+
+// RegisterProvider registers the provider factory of a custom protocol, e.g. artifactory://. The
+// protocols of the builtin providers and the model registry prefixes, which are resolved before
+// the provider is looked up, can not be registered.
+func RegisterProvider(protocol Protocol, factory ProviderFactory) error {
+	if err := validateCustomProtocol(protocol); err != nil {
+		return err
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := providerRegistry[protocol]; ok {
+		return fmt.Errorf("the protocol %s is registered already", protocol)
+	}
+	providerRegistry[protocol] = factory
+	return nil
+}
+
+func validateCustomProtocol(protocol Protocol) error {
+	if !protocolRegex.MatchString(string(protocol)) {
+		return fmt.Errorf("the protocol %q is invalid, it must be a lowercase uri scheme followed by ://", protocol)
+	}
+	if slices.Contains(SupportedProtocols, protocol) || protocol == AzureBlob {
+		return fmt.Errorf("the protocol %s is provided by the agent", protocol)
+	}
+	if slices.Contains(ModelRegistryPrefixes, string(protocol)) {
+		return fmt.Errorf("the protocol %s is a model registry resolved by the agent", protocol)
+	}
+	return nil
+}
+
+// RegisteredProtocols returns the custom protocols in order.
+func RegisteredProtocols() []Protocol {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	protocols := make([]Protocol, 0, len(providerRegistry))
+	for protocol := range providerRegistry {
+		protocols = append(protocols, protocol)
+	}
+	sort.Slice(protocols, func(i, j int) bool { return protocols[i] < protocols[j] })
+	return protocols
+}
+
+// Protocols returns the protocols of the builtin providers followed by the custom protocols.
+func Protocols() []Protocol {
+	return append(slices.Clone(SupportedProtocols), RegisteredProtocols()...)
+}
+
+func registeredProvider(protocol Protocol) (ProviderFactory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := providerRegistry[protocol]
+	return factory, ok
+}
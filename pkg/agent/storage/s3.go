@@ -17,21 +17,39 @@ limitations under the License.
 package storage
 
 import (
+	"context"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
-	"os"
+	"io"
 	"path/filepath"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"strings"
+	"sync"
 )
 
 type S3Provider struct {
 	Client     s3iface.S3API
 	Downloader s3manageriface.DownloadWithIterator
+	// Limiter limits the download throughput, it is unlimited if nil
+	Limiter *BandwidthLimiter
+	// DownloadOptions configures the ranged downloads, DefaultDownloadOptions are used if nil
+	DownloadOptions *DownloadOptions
+	// Session creates the clients of the uris with a region or an endpoint parameter, these uris
+	// are not supported if it is nil
+	Session *session.Session
+
+	mu      sync.Mutex
+	clients map[URIParams]*s3Client
+}
+
+type s3Client struct {
+	client     s3iface.S3API
+	downloader s3manageriface.DownloadWithIterator
 }
 
 var log = logf.Log.WithName("modelAgent")
@@ -44,36 +62,119 @@ type S3ObjectDownloader struct {
 	ModelName  string
 	Bucket     string
 	Prefix     string
+	// VersionId is the version of the object the prefix refers to, the latest objects are downloaded if empty
+	VersionId  string
 	downloader s3manageriface.DownloadWithIterator
+	limiter    *BandwidthLimiter
+	options    DownloadOptions
 }
 
-func (m *S3Provider) DownloadModel(modelDir string, modelName string, storageUri string) error {
+func (m *S3Provider) DownloadModel(modelDir string, modelName string, storageUri string, checksum *Checksum) error {
 	log.Info("Download model ", "modelName", modelName, "storageUri", storageUri, "modelDir", modelDir)
+	storageUri, params, err := ParseStorageURI(S3, storageUri)
+	if err != nil {
+		return err
+	}
+	client, err := m.clientFor(params)
+	if err != nil {
+		return err
+	}
 	s3Uri := strings.TrimPrefix(storageUri, string(S3))
 	tokens := strings.SplitN(s3Uri, "/", 2)
 	prefix := ""
 	if len(tokens) == 2 {
 		prefix = tokens[1]
 	}
+	options := DefaultDownloadOptions
+	if m.DownloadOptions != nil {
+		options = *m.DownloadOptions
+	}
 	s3ObjectDownloader := &S3ObjectDownloader{
 		StorageUri: storageUri,
 		ModelDir:   modelDir,
 		ModelName:  modelName,
 		Bucket:     tokens[0],
 		Prefix:     prefix,
-		downloader: m.Downloader,
+		VersionId:  params.VersionId,
+		downloader: client.downloader,
+		limiter:    m.Limiter,
+		options:    options,
+	}
+	var objects []*s3.Object
+	if params.VersionId != "" {
+		objects, err = s3ObjectDownloader.GetObjectVersion(client.client)
+	} else {
+		objects, err = s3ObjectDownloader.GetAllObjects(client.client)
 	}
-	objects, err := s3ObjectDownloader.GetAllObjects(m.Client)
 	if err != nil {
 		return fmt.Errorf("unable to get batch objects %w", err)
 	}
 	if err := s3ObjectDownloader.Download(objects); err != nil {
 		return err
 	}
-	return nil
+	downloadDigest := ""
+	if checksum != nil && checksum.Digest != "" && len(objects) == 1 {
+		if downloadDigest, err = FileDigest(s3ObjectDownloader.fileName(*objects[0].Key)); err != nil {
+			return err
+		}
+	}
+	return verifyDownload(filepath.Join(modelDir, modelName), checksum, downloadDigest)
+}
+
+// clientFor returns the client of the region and the endpoint of the uri parameters, which is
+// created from the session of the provider the first time it is used.
+func (m *S3Provider) clientFor(params URIParams) (*s3Client, error) {
+	if params.Region == "" && params.Endpoint == "" {
+		return &s3Client{client: m.Client, downloader: m.Downloader}, nil
+	}
+	if m.Session == nil {
+		return nil, fmt.Errorf("the s3 provider has no session to create the client of region %q and endpoint %q",
+			params.Region, params.Endpoint)
+	}
+	key := URIParams{Region: params.Region, Endpoint: params.Endpoint}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if client, ok := m.clients[key]; ok {
+		return client, nil
+	}
+	config := aws.NewConfig()
+	if params.Region != "" {
+		config = config.WithRegion(params.Region)
+	}
+	if params.Endpoint != "" {
+		config = config.WithEndpoint(params.Endpoint)
+	}
+	sessionClient := s3.New(m.Session, config)
+	client := &s3Client{
+		client:     sessionClient,
+		downloader: s3manager.NewDownloaderWithClient(sessionClient, func(d *s3manager.Downloader) {}),
+	}
+	if m.clients == nil {
+		m.clients = map[URIParams]*s3Client{}
+	}
+	m.clients[key] = client
+	return client, nil
 }
 
-func (s *S3ObjectDownloader) GetAllObjects(s3Svc s3iface.S3API) ([]s3manager.BatchDownloadObject, error) {
+// GetObjectVersion returns the version of the object the prefix refers to.
+func (s *S3ObjectDownloader) GetObjectVersion(s3Svc s3iface.S3API) ([]*s3.Object, error) {
+	resp, err := s3Svc.HeadObject(&s3.HeadObjectInput{
+		Bucket:    aws.String(s.Bucket),
+		Key:       aws.String(s.Prefix),
+		VersionId: aws.String(s.VersionId),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the version %s of %s: %w", s.VersionId, s.StorageUri, err)
+	}
+	return []*s3.Object{{
+		Key:  aws.String(s.Prefix),
+		Size: resp.ContentLength,
+		ETag: resp.ETag,
+	}}, nil
+}
+
+// GetAllObjects lists the objects of the model, leaving out the directory markers.
+func (s *S3ObjectDownloader) GetAllObjects(s3Svc s3iface.S3API) ([]*s3.Object, error) {
 	resp, err := s3Svc.ListObjects(&s3.ListObjectsInput{
 		Bucket: aws.String(s.Bucket),
 		Prefix: aws.String(s.Prefix),
@@ -81,63 +182,62 @@ func (s *S3ObjectDownloader) GetAllObjects(s3Svc s3iface.S3API) ([]s3manager.Bat
 	if err != nil {
 		return nil, err
 	}
-	results := make([]s3manager.BatchDownloadObject, 0)
-
-	if len(resp.Contents) == 0 {
-		return nil, fmt.Errorf("%s has no objects or does not exist", s.StorageUri)
-	}
-
-	var foundObject = false
-
+	results := make([]*s3.Object, 0)
 	for _, object := range resp.Contents {
 		if strings.HasSuffix(*object.Key, "/") {
 			continue
 		}
-		subObjectKey := strings.TrimPrefix(*object.Key, s.Prefix)
-		fileName := filepath.Join(s.ModelDir, s.ModelName, subObjectKey)
-
-		if FileExists(fileName) {
-			// File got corrupted or is mid-download :(
-			// TODO: Figure out if we can maybe continue?
-			if err := os.Remove(fileName); err != nil {
-				return nil, fmt.Errorf("file is unable to be deleted: %w", err)
-			}
-		}
-		file, err := Create(fileName)
-		if err != nil {
-			return nil, fmt.Errorf("file is already created: %w", err)
-		}
-		object := s3manager.BatchDownloadObject{
-			Object: &s3.GetObjectInput{
-				Key:    aws.String(*object.Key),
-				Bucket: aws.String(s.Bucket),
-			},
-			Writer: file,
-			After: func() error {
-				defer func(file *os.File) {
-					closeErr := file.Close()
-					if closeErr != nil {
-						log.Error(closeErr, "failed to close file")
-					}
-				}(file)
-				return nil
-			},
-		}
-		foundObject = true
 		results = append(results, object)
 	}
-
-	if !foundObject {
+	if len(results) == 0 {
 		return nil, fmt.Errorf("%s has no objects or does not exist", s.StorageUri)
 	}
-
 	return results, nil
 }
 
-func (s *S3ObjectDownloader) Download(objects []s3manager.BatchDownloadObject) error {
-	iter := &s3manager.DownloadObjectsIterator{Objects: objects}
-	if err := s.downloader.DownloadWithIterator(aws.BackgroundContext(), iter); err != nil {
-		return err
+// fileName returns the name of the file the object is downloaded to, the object the prefix refers
+// to is downloaded into the model directory.
+func (s *S3ObjectDownloader) fileName(key string) string {
+	subObjectKey := strings.TrimPrefix(key, s.Prefix)
+	if subObjectKey == "" {
+		subObjectKey = filepath.Base(key)
+	}
+	return filepath.Join(s.ModelDir, s.ModelName, subObjectKey)
+}
+
+// Download downloads the objects in ranged parts, resuming the files which were partially
+// downloaded by a previous attempt.
+func (s *S3ObjectDownloader) Download(objects []*s3.Object) error {
+	downloads := make([]objectDownload, 0, len(objects))
+	for _, object := range objects {
+		downloads = append(downloads, objectDownload{
+			fileName: s.fileName(*object.Key),
+			size:     aws.Int64Value(object.Size),
+			version:  aws.StringValue(object.ETag),
+			fetch:    s.fetch(object),
+		})
+	}
+	return downloadObjects(aws.BackgroundContext(), downloads, s.options)
+}
+
+func (s *S3ObjectDownloader) fetch(object *s3.Object) fetchRange {
+	return func(ctx context.Context, w io.WriterAt, offset int64, length int64) error {
+		input := &s3.GetObjectInput{
+			Key:    aws.String(*object.Key),
+			Bucket: aws.String(s.Bucket),
+		}
+		if s.VersionId != "" {
+			input.VersionId = aws.String(s.VersionId)
+		}
+		if length >= 0 {
+			input.Range = aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+			// the parts of another version of the object must not be mixed in
+			input.IfMatch = object.ETag
+		}
+		iter := &s3manager.DownloadObjectsIterator{Objects: []s3manager.BatchDownloadObject{{
+			Object: input,
+			Writer: s.limiter.WriterAt(w),
+		}}}
+		return s.downloader.DownloadWithIterator(ctx, iter)
 	}
-	return nil
 }
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Query parameters of the storage uris, which pin the version of the model artifact or configure
+// the client it is downloaded with, e.g. s3://bucket/model.joblib?versionId=<version>
+const (
+	VersionIdParam  = "versionId"
+	GenerationParam = "generation"
+	RegionParam     = "region"
+	EndpointParam   = "endpoint"
+)
+
+// ProtocolParams are the query parameters supported by the protocols. The query of the uris of the
+// other protocols is left to their provider, e.g. the signature of presigned https urls.
+var ProtocolParams = map[Protocol][]string{
+	S3:  {VersionIdParam, RegionParam, EndpointParam},
+	GCS: {GenerationParam},
+}
+
+// URIParams are the query parameters of a storage uri.
+type URIParams struct {
+	// VersionId is the version of the S3 object the uri refers to
+	VersionId string
+	// Generation is the generation of the GCS object the uri refers to
+	Generation int64
+	// Region is the region of the S3 bucket, the region of the provider is used if empty
+	Region string
+	// Endpoint is the url of the S3 compatible service, the endpoint of the provider is used if empty
+	Endpoint string
+}
+
+// ParseStorageURI splits the query parameters off a storage uri of the protocol. The uris of the
+// protocols which do not support parameters are returned unchanged.
+func ParseStorageURI(protocol Protocol, storageUri string) (string, URIParams, error) {
+	params := URIParams{}
+	supported, ok := ProtocolParams[protocol]
+	if !ok {
+		return storageUri, params, nil
+	}
+	uri, rawQuery, found := strings.Cut(storageUri, "?")
+	if !found {
+		return storageUri, params, nil
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", params, fmt.Errorf("unable to parse the query of the storage uri %s: %w", storageUri, err)
+	}
+	for name, values := range query {
+		if !slices.Contains(supported, name) {
+			return "", params, fmt.Errorf("the query parameter %s is not supported by %s uris, the supported parameters are %s",
+				name, protocol, strings.Join(supported, ", "))
+		}
+		if len(values) != 1 || values[0] == "" {
+			return "", params, fmt.Errorf("the query parameter %s of the storage uri %s must have a single value", name, storageUri)
+		}
+	}
+	params.VersionId = query.Get(VersionIdParam)
+	params.Region = query.Get(RegionParam)
+	params.Endpoint = query.Get(EndpointParam)
+	if generation := query.Get(GenerationParam); generation != "" {
+		if params.Generation, err = strconv.ParseInt(generation, 10, 64); err != nil || params.Generation <= 0 {
+			return "", params, fmt.Errorf("the generation %s of the storage uri %s is not a positive integer", generation, storageUri)
+		}
+	}
+	if params.Endpoint != "" {
+		endpoint, err := url.Parse(params.Endpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return "", params, fmt.Errorf("the endpoint %s of the storage uri %s is not an http(s) url", params.Endpoint, storageUri)
+		}
+	}
+	// the versions are those of a single object
+	if params.VersionId != "" || params.Generation != 0 {
+		_, key, _ := strings.Cut(strings.TrimPrefix(uri, string(protocol)), "/")
+		if key == "" || strings.HasSuffix(key, "/") {
+			return "", params, fmt.Errorf("the storage uri %s pins the version of a directory, it must refer to a single object", storageUri)
+		}
+	}
+	return uri, params, nil
+}
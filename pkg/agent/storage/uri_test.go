@@ -0,0 +1,176 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/kserve/kserve/pkg/agent/mocks"
+	"github.com/onsi/gomega"
+)
+
+func TestParseStorageURI(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	scenarios := map[string]struct {
+		protocol   Protocol
+		storageUri string
+		uri        string
+		params     URIParams
+		valid      bool
+	}{
+		"NoQuery": {
+			protocol: S3, storageUri: "s3://bucket/model", uri: "s3://bucket/model", valid: true,
+		},
+		"S3Params": {
+			protocol:   S3,
+			storageUri: "s3://bucket/model/model.joblib?versionId=3HL4kqtJ&region=eu-west-1&endpoint=https%3A%2F%2Fminio.example.com%3A9000",
+			uri:        "s3://bucket/model/model.joblib",
+			params:     URIParams{VersionId: "3HL4kqtJ", Region: "eu-west-1", Endpoint: "https://minio.example.com:9000"},
+			valid:      true,
+		},
+		"S3RegionOfDirectory": {
+			protocol: S3, storageUri: "s3://bucket/model/?region=eu-west-1", uri: "s3://bucket/model/", params: URIParams{Region: "eu-west-1"}, valid: true,
+		},
+		"GCSGeneration": {
+			protocol: GCS, storageUri: "gs://bucket/model.joblib?generation=1700000000000000", uri: "gs://bucket/model.joblib",
+			params: URIParams{Generation: 1700000000000000}, valid: true,
+		},
+		"HTTPSQueryUnchanged": {
+			protocol: HTTPS, storageUri: "https://example.com/model.joblib?versionId=1", uri: "https://example.com/model.joblib?versionId=1", valid: true,
+		},
+		"UnsupportedParam":   {protocol: GCS, storageUri: "gs://bucket/model.joblib?versionId=1"},
+		"EmptyParam":         {protocol: S3, storageUri: "s3://bucket/model.joblib?versionId="},
+		"RepeatedParam":      {protocol: S3, storageUri: "s3://bucket/model.joblib?region=a&region=b"},
+		"InvalidGeneration":  {protocol: GCS, storageUri: "gs://bucket/model.joblib?generation=latest"},
+		"NegativeGeneration": {protocol: GCS, storageUri: "gs://bucket/model.joblib?generation=-1"},
+		"InvalidEndpoint":    {protocol: S3, storageUri: "s3://bucket/model.joblib?endpoint=minio:9000"},
+		"VersionOfDirectory": {protocol: S3, storageUri: "s3://bucket/model/?versionId=1"},
+		"VersionOfBucket":    {protocol: S3, storageUri: "s3://bucket?versionId=1"},
+	}
+	for name, scenario := range scenarios {
+		uri, params, err := ParseStorageURI(scenario.protocol, scenario.storageUri)
+		if !scenario.valid {
+			g.Expect(err).NotTo(gomega.BeNil(), name)
+			continue
+		}
+		g.Expect(err).To(gomega.BeNil(), name)
+		g.Expect(uri).To(gomega.Equal(scenario.uri), name)
+		g.Expect(params).To(gomega.Equal(scenario.params), name)
+	}
+}
+
+// versionedS3Client serves the versions of the objects of a versioned bucket.
+type versionedS3Client struct {
+	s3iface.S3API
+	versions map[string]map[string]string
+}
+
+func (c *versionedS3Client) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	content, ok := c.versions[*input.Key][aws.StringValue(input.VersionId)]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(content))),
+		ETag:          aws.String(`"` + aws.StringValue(input.VersionId) + `"`),
+	}, nil
+}
+
+func (c *versionedS3Client) DownloadWithIterator(_ aws.Context, iter s3manager.BatchDownloadIterator, _ ...func(*s3manager.Downloader)) error {
+	for iter.Next() {
+		object := iter.DownloadObject()
+		content, ok := c.versions[*object.Object.Key][aws.StringValue(object.Object.VersionId)]
+		if !ok {
+			return errors.New("not found")
+		}
+		if object.Object.Range != nil {
+			var start, end int
+			if _, err := fmt.Sscanf(*object.Object.Range, "bytes=%d-%d", &start, &end); err != nil {
+				return err
+			}
+			content = content[start : end+1]
+		}
+		if _, err := object.Writer.WriteAt([]byte(content), 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestS3ProviderVersion(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	client := &versionedS3Client{versions: map[string]map[string]string{
+		"models/iris/model.joblib": {"v1": strings.Repeat("1", 40), "v2": strings.Repeat("2", 30)},
+	}}
+	provider := &S3Provider{
+		Client:          client,
+		Downloader:      client,
+		DownloadOptions: &DownloadOptions{PartSize: 16, Parallelism: 2},
+	}
+	modelDir := t.TempDir()
+	g.Expect(provider.DownloadModel(modelDir, "iris", "s3://bucket/models/iris/model.joblib?versionId=v1", nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "iris", "model.joblib"))).To(gomega.Equal([]byte(strings.Repeat("1", 40))))
+	g.Expect(provider.DownloadModel(modelDir, "missing", "s3://bucket/models/iris/model.joblib?versionId=v3", nil)).NotTo(gomega.Succeed())
+
+	// the clients of the regions and endpoints are created from the session
+	g.Expect(provider.DownloadModel(modelDir, "region", "s3://bucket/models/iris/model.joblib?region=eu-west-1", nil)).NotTo(gomega.Succeed())
+	sess, err := session.NewSession(&aws.Config{Region: aws.String("us-east-1"), Credentials: credentials.AnonymousCredentials})
+	g.Expect(err).To(gomega.BeNil())
+	provider.Session = sess
+	regionClient, err := provider.clientFor(URIParams{VersionId: "v1", Region: "eu-west-1"})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(aws.StringValue(regionClient.client.(*s3.S3).Config.Region)).To(gomega.Equal("eu-west-1"))
+	endpointClient, err := provider.clientFor(URIParams{Endpoint: "http://minio:9000"})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(endpointClient.client.(*s3.S3).Endpoint).To(gomega.Equal("http://minio:9000"))
+	g.Expect(provider.clientFor(URIParams{Region: "eu-west-1"})).To(gomega.BeIdenticalTo(regionClient))
+	defaultClient, err := provider.clientFor(URIParams{VersionId: "v1"})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(defaultClient.client).To(gomega.BeIdenticalTo(client))
+}
+
+func TestGCSProviderGeneration(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	client := mocks.NewMockClient()
+	ctx := context.Background()
+	g.Expect(client.Bucket("bucket").Create(ctx, "project", nil)).To(gomega.Succeed())
+	for _, content := range []string{"generation 1", "generation 2"} {
+		writer := client.Bucket("bucket").Object("models/iris/model.joblib").NewWriter(ctx)
+		_, err := writer.Write([]byte(content))
+		g.Expect(err).To(gomega.BeNil())
+	}
+
+	provider := &GCSProvider{Client: client}
+	modelDir := t.TempDir()
+	g.Expect(provider.DownloadModel(modelDir, "iris", "gs://bucket/models/iris/model.joblib?generation=2", nil)).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "iris", "model.joblib"))).To(gomega.Equal([]byte("generation 2")))
+	// the mock only keeps the latest generation
+	g.Expect(provider.DownloadModel(modelDir, "old", "gs://bucket/models/iris/model.joblib?generation=1", nil)).NotTo(gomega.Succeed())
+	g.Expect(provider.DownloadModel(modelDir, "invalid", "gs://bucket/models/iris/model.joblib?versionId=1", nil)).NotTo(gomega.Succeed())
+}
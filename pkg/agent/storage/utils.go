@@ -32,7 +32,10 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/googleapis/google-cloud-go-testing/storage/stiface"
+	"github.com/kserve/kserve/pkg/constants"
 	gcscredential "github.com/kserve/kserve/pkg/credentials/gcs"
+	"github.com/kserve/kserve/pkg/credentials/hdfs"
+	"github.com/kserve/kserve/pkg/credentials/https"
 	s3credential "github.com/kserve/kserve/pkg/credentials/s3"
 	"google.golang.org/api/option"
 )
@@ -106,6 +109,14 @@ func GetProvider(providers map[Protocol]Provider, protocol Protocol) (Provider,
 	if provider, ok := providers[protocol]; ok {
 		return provider, nil
 	}
+	if factory, ok := registeredProvider(protocol); ok {
+		provider, err := factory()
+		if err != nil {
+			return nil, fmt.Errorf("unable to create the provider of %s: %w", protocol, err)
+		}
+		providers[protocol] = provider
+		return provider, nil
+	}
 
 	switch protocol {
 	case GCS:
@@ -168,16 +179,35 @@ func GetProvider(providers map[Protocol]Provider, protocol Protocol) (Provider,
 		providers[S3] = &S3Provider{
 			Client:     sessionClient,
 			Downloader: s3manager.NewDownloaderWithClient(sessionClient, func(d *s3manager.Downloader) {}),
+			Session:    sess,
+		}
+	case AzureBlob:
+		providers[AzureBlob] = &AzureProvider{}
+	case PVC, File:
+		// pvc:// and file:// models are provided the same way
+		provider := &LocalProvider{}
+		providers[PVC] = provider
+		providers[File] = provider
+	case OCI:
+		providers[OCI] = &OCIProvider{
+			PullSecretsDir: constants.OCIPullSecretsMountRoot,
 		}
+	case HDFS, WebHDFS:
+		// the HDFS secret is only read once a model is downloaded
+		provider := &WebHDFSProvider{SecretDir: hdfs.MountPath}
+		providers[HDFS] = provider
+		providers[WebHDFS] = provider
 	case HTTPS:
 		httpsClient := &http.Client{}
 		providers[HTTPS] = &HTTPSProvider{
-			Client: httpsClient,
+			Client:     httpsClient,
+			SecretsDir: https.MountPath,
 		}
 	case HTTP:
 		httpsClient := &http.Client{}
 		providers[HTTP] = &HTTPSProvider{
-			Client: httpsClient,
+			Client:     httpsClient,
+			SecretsDir: https.MountPath,
 		}
 	}
 
@@ -0,0 +1,381 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	krbclient "github.com/jcmturner/gokrb5/v8/client"
+	krbconfig "github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/kserve/kserve/pkg/credentials/hdfs"
+)
+
+// DefaultKrb5Config is the Kerberos configuration used unless the KRB5_CONFIG environment variable is set
+const DefaultKrb5Config = "/etc/krb5.conf"
+
+// WebHDFSConfig is the configuration of the HDFS secret mounted by the credential builder, see
+// pkg/credentials/hdfs.
+type WebHDFSConfig struct {
+	// Namenode is the WebHDFS endpoint of the namenode, e.g. https://namenode:9871
+	Namenode string
+	// RootPath is the path the model paths are relative to, they are absolute if it is empty
+	RootPath string
+	// UserProxy is the user the requests are made as, the user.name of simple authentication or
+	// the user impersonated by the Kerberos principal
+	UserProxy         string
+	Headers           map[string]string
+	TLSCert           string
+	TLSKey            string
+	TLSCA             string
+	TLSSkipVerify     bool
+	KerberosPrincipal string
+	KerberosKeytab    string
+}
+
+// LoadWebHDFSConfig reads the HDFS secret mounted at secretDir. The file secrets, such as the
+// keytab and the certificates, are referenced by their path.
+func LoadWebHDFSConfig(secretDir string) (*WebHDFSConfig, error) {
+	read := func(key string) (string, error) {
+		content, err := os.ReadFile(filepath.Join(secretDir, key))
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return strings.TrimSpace(string(content)), err
+	}
+	filePath := func(key string) string {
+		if FileExists(filepath.Join(secretDir, key)) {
+			return filepath.Join(secretDir, key)
+		}
+		return ""
+	}
+	config := &WebHDFSConfig{
+		TLSCert:        filePath(hdfs.TlsCert),
+		TLSKey:         filePath(hdfs.TlsKey),
+		TLSCA:          filePath(hdfs.TlsCa),
+		KerberosKeytab: filePath(hdfs.KerberosKeytab),
+	}
+	var err error
+	if config.Namenode, err = read(hdfs.HdfsNamenode); err != nil {
+		return nil, err
+	}
+	if config.Namenode == "" {
+		return nil, fmt.Errorf("the HDFS secret at %s has no %s", secretDir, hdfs.HdfsNamenode)
+	}
+	if config.RootPath, err = read(hdfs.HdfsRootPath); err != nil {
+		return nil, err
+	}
+	if config.UserProxy, err = read(hdfs.UserProxy); err != nil {
+		return nil, err
+	}
+	if config.KerberosPrincipal, err = read(hdfs.KerberosPrincipal); err != nil {
+		return nil, err
+	}
+	skipVerify, err := read(hdfs.TlsSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	config.TLSSkipVerify = strings.ToLower(skipVerify) == "true"
+	headers, err := read(hdfs.Headers)
+	if err != nil {
+		return nil, err
+	}
+	if headers != "" {
+		if err := json.Unmarshal([]byte(headers), &config.Headers); err != nil {
+			return nil, fmt.Errorf("invalid %s in the HDFS secret: %w", hdfs.Headers, err)
+		}
+	}
+	return config, nil
+}
+
+// WebHDFSProvider provides the models of hdfs://{path} and webhdfs://{path} uris through the
+// WebHDFS REST API of the namenode. Directories are downloaded recursively. The paths of the uris
+// are relative to the root path of the HDFS secret and can not leave it.
+type WebHDFSProvider struct {
+	// SecretDir is the HDFS secret the provider is configured from on its first download, unless
+	// Config is set. The secret is reloaded on the download which follows a request which failed to
+	// authenticate, so that the rotated credentials and keytabs are picked up.
+	SecretDir string
+	Client    *http.Client
+	Config    *WebHDFSConfig
+	// Kerberos authenticates the namenode requests with SPNEGO, simple authentication is used if nil
+	Kerberos *krbclient.Client
+	// Limiter limits the download throughput, it is unlimited if nil
+	Limiter *BandwidthLimiter
+	// mu is held for reading by the downloads, the secret is only reloaded once they are done
+	mu sync.RWMutex
+	// stale is set once a request failed to authenticate
+	stale atomic.Bool
+}
+
+var _ Provider = (*WebHDFSProvider)(nil)
+
+// NewWebHDFSProvider creates the provider of the HDFS configuration, logging into Kerberos if it
+// has a principal.
+func NewWebHDFSProvider(config *WebHDFSConfig) (*WebHDFSProvider, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.TLSSkipVerify} // #nosec G402
+	if config.TLSCA != "" {
+		ca, err := os.ReadFile(config.TLSCA)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in %s", config.TLSCA)
+		}
+	}
+	if config.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	provider := &WebHDFSProvider{
+		Client: &http.Client{Transport: transport},
+		Config: config,
+	}
+	if config.KerberosPrincipal != "" {
+		kt, err := keytab.Load(config.KerberosKeytab)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the Kerberos keytab: %w", err)
+		}
+		krb5ConfigPath := os.Getenv("KRB5_CONFIG")
+		if krb5ConfigPath == "" {
+			krb5ConfigPath = DefaultKrb5Config
+		}
+		krb5Config, err := krbconfig.Load(krb5ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the Kerberos configuration: %w", err)
+		}
+		username, realm, _ := strings.Cut(config.KerberosPrincipal, "@")
+		if realm == "" {
+			realm = krb5Config.LibDefaults.DefaultRealm
+		}
+		provider.Kerberos = krbclient.NewWithKeytab(username, realm, kt, krb5Config, krbclient.DisablePAFXFAST(true))
+		if err := provider.Kerberos.Login(); err != nil {
+			return nil, fmt.Errorf("unable to log into Kerberos as %s: %w", config.KerberosPrincipal, err)
+		}
+	}
+	return provider, nil
+}
+
+// configure loads the HDFS secret unless the provider is already configured, or reloads it if a
+// request failed to authenticate since. It returns with the read lock held unless it fails.
+func (p *WebHDFSProvider) configure() error {
+	p.mu.RLock()
+	if p.Config != nil && !p.stale.Load() {
+		return nil
+	}
+	p.mu.RUnlock()
+	p.mu.Lock()
+	if p.Config == nil || p.stale.Load() {
+		config, err := LoadWebHDFSConfig(p.SecretDir)
+		if err != nil {
+			p.mu.Unlock()
+			return err
+		}
+		configured, err := NewWebHDFSProvider(config)
+		if err != nil {
+			p.mu.Unlock()
+			return err
+		}
+		p.Client, p.Kerberos, p.Config = configured.Client, configured.Kerberos, configured.Config
+		p.stale.Store(false)
+	}
+	p.mu.Unlock()
+	p.mu.RLock()
+	return nil
+}
+
+// authenticationFailed has the HDFS secret reloaded on the next download, if the provider was
+// configured from it.
+func (p *WebHDFSProvider) authenticationFailed() {
+	if p.SecretDir != "" {
+		p.stale.Store(true)
+	}
+}
+
+// hdfsPath returns the path of the storage uri under the root path, it fails if the path leaves it.
+func (p *WebHDFSProvider) hdfsPath(storageUri string) (string, error) {
+	uriPath := strings.TrimPrefix(strings.TrimPrefix(storageUri, string(HDFS)), string(WebHDFS))
+	root := path.Clean("/" + p.Config.RootPath)
+	hdfsPath := path.Join(root, uriPath)
+	if root != "/" && hdfsPath != root && !strings.HasPrefix(hdfsPath, root+"/") {
+		return "", fmt.Errorf("invalid uri %s, the path is outside of the root path %s", storageUri, root)
+	}
+	return hdfsPath, nil
+}
+
+type webHDFSFileStatus struct {
+	PathSuffix string `json:"pathSuffix"`
+	Type       string `json:"type"`
+}
+
+func (p *WebHDFSProvider) DownloadModel(modelDir string, modelName string, storageUri string, checksum *Checksum) error {
+	log.Info("Download model ", "modelName", modelName, "storageUri", storageUri, "modelDir", modelDir)
+	if err := p.configure(); err != nil {
+		return err
+	}
+	defer p.mu.RUnlock()
+	hdfsPath, err := p.hdfsPath(storageUri)
+	if err != nil {
+		return err
+	}
+	var status struct {
+		FileStatus webHDFSFileStatus `json:"FileStatus"`
+	}
+	if err := p.getJSON(hdfsPath, "GETFILESTATUS", &status); err != nil {
+		return err
+	}
+	modelPath := filepath.Join(modelDir, modelName)
+	if status.FileStatus.Type != "DIRECTORY" {
+		digester := newDigester()
+		if err := p.downloadFile(hdfsPath, filepath.Join(modelPath, path.Base(hdfsPath)), digester); err != nil {
+			return err
+		}
+		return verifyDownload(modelPath, checksum, formatDigest(digester))
+	}
+	if err := p.downloadDir(hdfsPath, modelPath); err != nil {
+		return err
+	}
+	return verifyDownload(modelPath, checksum, "")
+}
+
+func (p *WebHDFSProvider) downloadDir(hdfsPath string, dir string) error {
+	var statuses struct {
+		FileStatuses struct {
+			FileStatus []webHDFSFileStatus `json:"FileStatus"`
+		} `json:"FileStatuses"`
+	}
+	if err := p.getJSON(hdfsPath, "LISTSTATUS", &statuses); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, status := range statuses.FileStatuses.FileStatus {
+		if status.PathSuffix == "" || strings.Contains(status.PathSuffix, "/") || status.PathSuffix == ".." {
+			return fmt.Errorf("%s: illegal file path", status.PathSuffix)
+		}
+		childPath := path.Join(hdfsPath, status.PathSuffix)
+		target := filepath.Join(dir, status.PathSuffix)
+		var err error
+		switch status.Type {
+		case "DIRECTORY":
+			err = p.downloadDir(childPath, target)
+		case "FILE":
+			err = p.downloadFile(childPath, target, io.Discard)
+		default:
+			log.Info("Skipping the model file which is not a regular file", "file", childPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *WebHDFSProvider) downloadFile(hdfsPath string, fileName string, digester io.Writer) error {
+	resp, err := p.do(hdfsPath, "OPEN")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	file, err := createNewFile(fileName)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		closeErr := file.Close()
+		if closeErr != nil {
+			log.Error(closeErr, "failed to close file")
+		}
+	}(file)
+	if _, err := io.Copy(file, io.TeeReader(p.Limiter.Reader(resp.Body), digester)); err != nil {
+		return fmt.Errorf("unable to download %s: %w", hdfsPath, err)
+	}
+	return nil
+}
+
+func (p *WebHDFSProvider) getJSON(hdfsPath string, op string, v interface{}) error {
+	resp, err := p.do(hdfsPath, op)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid %s response for %s: %w", op, hdfsPath, err)
+	}
+	return nil
+}
+
+// do sends a WebHDFS request for the path to the namenode, following the redirects to the datanodes.
+func (p *WebHDFSProvider) do(hdfsPath string, op string) (*http.Response, error) {
+	query := url.Values{"op": []string{op}}
+	if p.Config.UserProxy != "" {
+		if p.Kerberos != nil {
+			query.Set("doas", p.Config.UserProxy)
+		} else {
+			query.Set("user.name", p.Config.UserProxy)
+		}
+	}
+	requestURL := strings.TrimSuffix(p.Config.Namenode, "/") + "/webhdfs/v1" + (&url.URL{Path: hdfsPath}).EscapedPath() + "?" + query.Encode()
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range p.Config.Headers {
+		req.Header.Set(name, value)
+	}
+	if p.Kerberos != nil {
+		// the namenode redirects the reads to the datanodes with a delegation token, so only its
+		// requests are authenticated
+		if err := spnego.SetSPNEGOHeader(p.Kerberos, req, ""); err != nil {
+			p.authenticationFailed()
+			return nil, fmt.Errorf("unable to authenticate to the namenode: %w", err)
+		}
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("WebHDFS %s request for %s failed: %w", op, hdfsPath, err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		p.authenticationFailed()
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("WebHDFS %s request for %s returned a %d response code: %s", op, hdfsPath, resp.StatusCode, body)
+	}
+	return resp, nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kserve/kserve/pkg/credentials/hdfs"
+	"github.com/onsi/gomega"
+)
+
+// newWebHDFSServer serves the files as a namenode which redirects the reads to a datanode.
+func newWebHDFSServer(t *testing.T, files map[string]string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Gateway") != "kserve" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/datanode" {
+			_, _ = w.Write([]byte(files[r.URL.Query().Get("path")]))
+			return
+		}
+		hdfsPath, ok := strings.CutPrefix(r.URL.Path, "/webhdfs/v1")
+		if !ok || r.URL.Query().Get("user.name") != "kserve" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, isFile := files[hdfsPath]
+		var children []webHDFSFileStatus
+		for name := range files {
+			if suffix, ok := strings.CutPrefix(name, hdfsPath+"/"); ok {
+				child, _, nested := strings.Cut(suffix, "/")
+				status := webHDFSFileStatus{PathSuffix: child, Type: "FILE"}
+				if nested {
+					status.Type = "DIRECTORY"
+				}
+				children = append(children, status)
+			}
+		}
+		if !isFile && len(children) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Query().Get("op") {
+		case "GETFILESTATUS":
+			status := webHDFSFileStatus{Type: "DIRECTORY"}
+			if isFile {
+				status.Type = "FILE"
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"FileStatus": status})
+		case "LISTSTATUS":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"FileStatuses": map[string]interface{}{"FileStatus": children}})
+		case "OPEN":
+			http.Redirect(w, r, server.URL+"/datanode?path="+hdfsPath, http.StatusTemporaryRedirect)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebHDFSProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	server := newWebHDFSServer(t, map[string]string{
+		"/models/sklearn/1/model.joblib": "model",
+		"/models/sklearn/config.json":    "{}",
+	})
+
+	secretDir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	for key, value := range map[string]string{
+		hdfs.HdfsNamenode: server.URL,
+		hdfs.HdfsRootPath: "/models",
+		hdfs.UserProxy:    "kserve",
+		hdfs.Headers:      `{"X-Gateway": "kserve"}`,
+		hdfs.TlsCa:        string(ca),
+	} {
+		g.Expect(os.WriteFile(filepath.Join(secretDir, key), []byte(value), 0644)).To(gomega.Succeed())
+	}
+	provider := &WebHDFSProvider{SecretDir: secretDir}
+
+	// Directories are downloaded recursively
+	modelDir := t.TempDir()
+	g.Expect(provider.DownloadModel(modelDir, "sklearn", "hdfs://sklearn", nil)).To(gomega.Succeed())
+	g.Expect(provider.Config.TLSCA).To(gomega.Equal(filepath.Join(secretDir, hdfs.TlsCa)))
+	g.Expect(provider.Kerberos).To(gomega.BeNil())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "sklearn", "1", "model.joblib"))).To(gomega.Equal([]byte("model")))
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "sklearn", "config.json"))).To(gomega.Equal([]byte("{}")))
+
+	// Single files are verified against their digest
+	configFile := filepath.Join(modelDir, "sklearn", "config.json")
+	digest, err := FileDigest(configFile)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(provider.DownloadModel(modelDir, "config", "webhdfs://sklearn/config.json", &Checksum{Digest: digest})).To(gomega.Succeed())
+	g.Expect(os.ReadFile(filepath.Join(modelDir, "config", "config.json"))).To(gomega.Equal([]byte("{}")))
+
+	g.Expect(provider.DownloadModel(modelDir, "missing", "hdfs://missing", nil)).NotTo(gomega.Succeed())
+
+	// The paths can not leave the root path
+	for _, storageUri := range []string{"hdfs://../other", "webhdfs://sklearn/../../etc/passwd", "hdfs://../models-other"} {
+		err := provider.DownloadModel(modelDir, "escaped", storageUri, nil)
+		g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("outside of the root path /models")), storageUri)
+	}
+
+	// The secret is reloaded once a request fails to authenticate
+	userFile := filepath.Join(secretDir, hdfs.UserProxy)
+	g.Expect(os.WriteFile(userFile, []byte("expired"), 0644)).To(gomega.Succeed())
+	provider = &WebHDFSProvider{SecretDir: secretDir}
+	g.Expect(provider.DownloadModel(t.TempDir(), "sklearn", "hdfs://sklearn", nil)).NotTo(gomega.Succeed())
+	g.Expect(os.WriteFile(userFile, []byte("kserve"), 0644)).To(gomega.Succeed())
+	g.Expect(provider.DownloadModel(t.TempDir(), "sklearn", "hdfs://sklearn", nil)).To(gomega.Succeed())
+
+	// The server certificate is not trusted without the CA
+	g.Expect(os.Remove(filepath.Join(secretDir, hdfs.TlsCa))).To(gomega.Succeed())
+	provider = &WebHDFSProvider{SecretDir: secretDir}
+	g.Expect(provider.DownloadModel(t.TempDir(), "sklearn", "hdfs://sklearn", nil)).NotTo(gomega.Succeed())
+}
+
+func TestLoadWebHDFSConfig(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	secretDir := t.TempDir()
+	_, err := LoadWebHDFSConfig(secretDir)
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	for key, value := range map[string]string{
+		hdfs.HdfsNamenode:      "https://namenode:9871\n",
+		hdfs.KerberosPrincipal: "kserve@EXAMPLE.COM",
+		hdfs.KerberosKeytab:    "keytab",
+		hdfs.TlsSkipVerify:     "True",
+	} {
+		g.Expect(os.WriteFile(filepath.Join(secretDir, key), []byte(value), 0644)).To(gomega.Succeed())
+	}
+	config, err := LoadWebHDFSConfig(secretDir)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(config).To(gomega.Equal(&WebHDFSConfig{
+		Namenode:          "https://namenode:9871",
+		KerberosPrincipal: "kserve@EXAMPLE.COM",
+		KerberosKeytab:    filepath.Join(secretDir, hdfs.KerberosKeytab),
+		TLSSkipVerify:     true,
+	}))
+}
@@ -0,0 +1,37 @@
+//go:build !linux
+
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"os"
+)
+
+// exchangeDirs exchanges the two directories. The exchange is not atomic on this platform, the
+// model directory is missing for the time of a rename.
+func exchangeDirs(oldPath string, newPath string) error {
+	tmpPath := oldPath + exchangeDirSuffix
+	if err := os.Rename(newPath, tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		_ = os.Rename(tmpPath, newPath)
+		return err
+	}
+	return os.Rename(tmpPath, oldPath)
+}
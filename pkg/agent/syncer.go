@@ -37,6 +37,10 @@ func SyncModelDir(modelDir string, logger *zap.SugaredLogger) (map[string]modelW
 	logger.Infof("Syncing from model dir %s", modelDir)
 	modelTracker := make(map[string]modelWrapper)
 	err := filepath.Walk(modelDir, func(path string, info os.FileInfo, err error) error {
+		// hidden directories such as the model cache do not hold models
+		if info.IsDir() && path != modelDir && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
 		if !info.IsDir() {
 			fileName := info.Name()
 			if strings.HasPrefix(fileName, "SUCCESS.") {
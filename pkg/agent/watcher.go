@@ -24,12 +24,16 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
 	"github.com/kserve/kserve/pkg/constants"
 	"github.com/kserve/kserve/pkg/modelconfig"
 	"go.uber.org/zap"
 )
 
+// modelEventsBufferSize is the minimum size of the buffer of the model events.
+const modelEventsBufferSize = 100
+
 type Watcher struct {
 	configDir    string
 	ModelTracker map[string]modelWrapper
@@ -38,20 +42,36 @@ type Watcher struct {
 }
 
 func NewWatcher(configDir string, modelDir string, logger *zap.SugaredLogger) Watcher {
+	modelConfigFile := fmt.Sprintf("%s/%s", configDir, constants.ModelConfigFileName)
+	modelConfigs, configErr := readModelConfig(modelConfigFile)
+	if configErr != nil {
+		logger.Errorf("Failed to sync model config file %v", configErr)
+	}
+	// the models which are not configured are only removed if the config could be read
+	var desired map[string]*v1alpha1.ModelSpec
+	if configErr == nil {
+		desired = make(map[string]*v1alpha1.ModelSpec, len(modelConfigs))
+		for i := range modelConfigs {
+			desired[modelConfigs[i].Name] = &modelConfigs[i].Spec
+		}
+	}
+	if _, err := ReconcileModelDir(modelDir, desired, logger); err != nil {
+		logger.Errorf("Failed to reconcile model dir %v", err)
+	}
 	modelTracker, err := SyncModelDir(modelDir, logger)
 	if err != nil {
 		logger.Errorf("Failed to sync model dir %v", err)
 	}
+	// The ops of the models configured on startup are all buffered before the puller reads them,
+	// there is at most an op per configured model and an op per downloaded model
 	watcher := Watcher{
 		configDir:    configDir,
 		ModelTracker: modelTracker,
-		ModelEvents:  make(chan ModelOp, 100),
+		ModelEvents:  make(chan ModelOp, max(modelEventsBufferSize, len(modelConfigs)+len(modelTracker))),
 		logger:       logger,
 	}
-	modelConfigFile := fmt.Sprintf("%s/%s", configDir, constants.ModelConfigFileName)
-	err = watcher.syncModelConfig(modelConfigFile, true)
-	if err != nil {
-		logger.Errorf("Failed to sync model config file %v", err)
+	if configErr == nil {
+		watcher.parseConfig(modelConfigs, true)
 	}
 	return watcher
 }
@@ -62,21 +82,26 @@ type modelWrapper struct {
 }
 
 func (w *Watcher) syncModelConfig(modelConfigFile string, initializing bool) error {
-	file, err := os.ReadFile(modelConfigFile)
+	modelConfigs, err := readModelConfig(modelConfigFile)
 	if err != nil {
 		return err
-	} else {
-		modelConfigs := make(modelconfig.ModelConfigs, 0)
-		err = json.Unmarshal(file, &modelConfigs)
-		if err != nil {
-			return err
-		} else {
-			w.parseConfig(modelConfigs, initializing)
-		}
 	}
+	w.parseConfig(modelConfigs, initializing)
 	return nil
 }
 
+func readModelConfig(modelConfigFile string) (modelconfig.ModelConfigs, error) {
+	file, err := os.ReadFile(modelConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	modelConfigs := make(modelconfig.ModelConfigs, 0)
+	if err := json.Unmarshal(file, &modelConfigs); err != nil {
+		return nil, err
+	}
+	return modelConfigs, nil
+}
+
 func (w *Watcher) Start() {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -134,6 +159,10 @@ func (w *Watcher) Start() {
 	<-done
 }
 
+// ignorePriority compares the model specs regardless of their priority, which only affects the
+// order of the downloads and does not require the model to be updated.
+var ignorePriority = cmpopts.IgnoreFields(v1alpha1.ModelSpec{}, "Priority")
+
 func (w *Watcher) parseConfig(modelConfigs modelconfig.ModelConfigs, initializing bool) {
 	for _, modelConfig := range modelConfigs {
 		name, spec := modelConfig.Name, modelConfig.Spec
@@ -142,18 +171,18 @@ func (w *Watcher) parseConfig(modelConfigs modelconfig.ModelConfigs, initializin
 			// New - add
 			w.ModelTracker[name] = modelWrapper{Spec: &spec}
 			w.modelAdded(name, &spec, initializing)
-		} else if !cmp.Equal(spec, *existing.Spec) {
+		} else if !cmp.Equal(spec, *existing.Spec, ignorePriority) {
 			w.ModelTracker[name] = modelWrapper{
-				Spec:  existing.Spec,
+				Spec:  &spec,
 				stale: false,
 			}
-			// Changed - replace
-			w.modelRemoved(name)
-			w.modelAdded(name, &spec, initializing)
-		} else if cmp.Equal(spec, *existing.Spec) {
-			// This model didn't change, mark the stale flag to false
+			// Changed - update in place
+			w.modelUpdated(name, &spec, initializing)
+		} else {
+			// This model didn't change, mark the stale flag to false. Its spec is replaced in case
+			// its priority changed
 			w.ModelTracker[name] = modelWrapper{
-				Spec:  existing.Spec,
+				Spec:  &spec,
 				stale: false,
 			}
 		}
@@ -185,6 +214,16 @@ func (w *Watcher) modelAdded(name string, spec *v1alpha1.ModelSpec, initializing
 	}
 }
 
+func (w *Watcher) modelUpdated(name string, spec *v1alpha1.ModelSpec, initializing bool) {
+	w.logger.Infof("updating model %s", name)
+	w.ModelEvents <- ModelOp{
+		OnStartup: initializing,
+		ModelName: name,
+		Op:        Update,
+		Spec:      spec,
+	}
+}
+
 func (w *Watcher) modelRemoved(name string) {
 	w.logger.Infof("removing model %s", name)
 	w.ModelEvents <- ModelOp{
@@ -18,13 +18,17 @@ package agent
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	logger "log"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	gstorage "cloud.google.com/go/storage"
@@ -128,6 +132,43 @@ var _ = Describe("Watcher", func() {
 		})
 	})
 
+	Describe("Sync many models config on startup", func() {
+		Context("Getting more model events than the default buffer size", func() {
+			It("should buffer the events of all the models", func() {
+				defer GinkgoRecover()
+				modelConfigs := make(modelconfig.ModelConfigs, 0, 150)
+				for i := 0; i < 150; i++ {
+					modelConfigs = append(modelConfigs, modelconfig.ModelConfig{
+						Name: fmt.Sprintf("model%d", i),
+						Spec: v1alpha1.ModelSpec{
+							StorageURI: fmt.Sprintf("s3://models/model%d", i),
+							Framework:  "sklearn",
+						},
+					})
+				}
+				Expect(os.MkdirAll("/tmp/configs", os.ModePerm)).To(Succeed())
+				DeferCleanup(func() {
+					os.RemoveAll("/tmp/configs")
+				})
+				file, _ := json.MarshalIndent(modelConfigs, "", " ")
+				Expect(os.WriteFile("/tmp/configs/"+constants.ModelConfigFileName, file, os.ModePerm)).To(Succeed())
+
+				watchers := make(chan Watcher, 1)
+				go func() {
+					watchers <- NewWatcher("/tmp/configs", modelDir, sugar)
+				}()
+				var watcher Watcher
+				Eventually(watchers).Should(Receive(&watcher))
+				Expect(watcher.ModelEvents).To(HaveLen(len(modelConfigs)))
+				for i := 0; i < len(modelConfigs); i++ {
+					modelOp := <-watcher.ModelEvents
+					Expect(modelOp.OnStartup).To(BeTrue())
+					Expect(modelOp.Op).To(Equal(Add))
+				}
+			})
+		})
+	})
+
 	Describe("Watch model config changes", func() {
 		Context("When new models are added", func() {
 			It("Should download and load the new models", func() {
@@ -295,8 +336,10 @@ var _ = Describe("Watcher", func() {
 				watcher.parseConfig(modelConfigs, false)
 				Eventually(func() int { return len(puller.channelMap) }).Should(Equal(0))
 				Eventually(func() int { return puller.opStats["model1"][Add] }).Should(Equal(1))
-				Eventually(func() int { return puller.opStats["model2"][Add] }).Should(Equal(2))
-				Eventually(func() int { return puller.opStats["model2"][Remove] }).Should(Equal(1))
+				Eventually(func() int { return puller.opStats["model2"][Add] }).Should(Equal(1))
+				Eventually(func() int { return puller.opStats["model2"][Update] }).Should(Equal(1))
+				Expect(puller.opStats["model2"][Remove]).To(Equal(0))
+				Expect(watcher.ModelTracker["model2"].Spec.StorageURI).To(Equal("s3://models/model2v2"))
 			})
 		})
 
@@ -370,7 +413,7 @@ var _ = Describe("Watcher", func() {
 				}
 				modelName := "model1"
 				modelStorageURI := "gs://testBucket/"
-				err := cl.DownloadModel(modelDir, modelName, modelStorageURI)
+				err := cl.DownloadModel(modelDir, modelName, modelStorageURI, nil)
 				Expect(err).To(BeNil())
 
 				testFile := filepath.Join(modelDir, modelName, "testModel1")
@@ -404,7 +447,7 @@ var _ = Describe("Watcher", func() {
 				modelName := "model1"
 				modelStorageURI := "gs://testBucket/testModel2"
 				expectedErr := fmt.Errorf("unable to download object/s because: %w", gstorage.ErrObjectNotExist)
-				actualErr := cl.DownloadModel(modelDir, modelName, modelStorageURI)
+				actualErr := cl.DownloadModel(modelDir, modelName, modelStorageURI, nil)
 				Expect(actualErr).To(Equal(expectedErr))
 			})
 		})
@@ -436,7 +479,7 @@ var _ = Describe("Watcher", func() {
 				}
 
 				modelStorageURI := "gs://testBucket/"
-				err := cl.DownloadModel(modelDir, "", modelStorageURI)
+				err := cl.DownloadModel(modelDir, "", modelStorageURI, nil)
 				Expect(err).To(BeNil())
 			})
 		})
@@ -585,7 +628,7 @@ var _ = Describe("Watcher", func() {
 						Client: ts.Client(),
 					}
 
-					err := cl.DownloadModel(modelDir, modelName, modelStorageURI)
+					err := cl.DownloadModel(modelDir, modelName, modelStorageURI, nil)
 					Expect(err).To(BeNil())
 
 					testFile := filepath.Join(modelDir, modelName, modelFile)
@@ -596,6 +639,59 @@ var _ = Describe("Watcher", func() {
 			})
 		})
 
+		Context("Model Download Verification", func() {
+			It("should verify the downloaded model against its digests", func() {
+				modelContents := "Temporary content\n"
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprint(w, modelContents)
+				}))
+				defer ts.Close()
+				cl := storage.HTTPSProvider{
+					Client: ts.Client(),
+				}
+				sum := sha256.Sum256([]byte(modelContents))
+				digest := "sha256:" + hex.EncodeToString(sum[:])
+				wrongDigest := "sha256:" + strings.Repeat("0", 64)
+
+				scenarios := map[string]struct {
+					checksum      *storage.Checksum
+					expectedError error
+				}{
+					"MatchingDigest": {
+						checksum: &storage.Checksum{Digest: digest},
+					},
+					"MatchingFileDigests": {
+						checksum: &storage.Checksum{Files: map[string]string{"model.joblib": digest}},
+					},
+					"WrongDigest": {
+						checksum:      &storage.Checksum{Digest: wrongDigest},
+						expectedError: storage.ErrChecksumMismatch,
+					},
+					"WrongFileDigests": {
+						checksum:      &storage.Checksum{Files: map[string]string{"model.joblib": wrongDigest}},
+						expectedError: storage.ErrChecksumMismatch,
+					},
+					"MissingFile": {
+						checksum:      &storage.Checksum{Files: map[string]string{"missing.joblib": digest}},
+						expectedError: storage.ErrChecksumMismatch,
+					},
+				}
+				for name, scenario := range scenarios {
+					logger.Printf("Verifying scenario %s", name)
+					modelName := "model-" + strings.ToLower(name)
+					err := cl.DownloadModel(modelDir, modelName, ts.URL+"/model.joblib", scenario.checksum)
+					if scenario.expectedError == nil {
+						Expect(err).To(BeNil())
+						Expect(filepath.Join(modelDir, modelName, "model.joblib")).To(BeARegularFile())
+					} else {
+						Expect(errors.Is(err, scenario.expectedError)).To(BeTrue())
+						// the corrupted model is removed
+						Expect(filepath.Join(modelDir, modelName)).NotTo(BeAnExistingFile())
+					}
+				}
+			})
+		})
+
 		Context("Model Download Failure", func() {
 			It("should fail out if the uri does not exist", func() {
 				logger.Printf("Creating Client")
@@ -607,7 +703,7 @@ var _ = Describe("Watcher", func() {
 					Client: ts.Client(),
 				}
 
-				actualErr := cl.DownloadModel(modelDir, modelName, invalidModelStorageURI)
+				actualErr := cl.DownloadModel(modelDir, modelName, invalidModelStorageURI, nil)
 				Expect(actualErr).NotTo(Equal(nil))
 			})
 		})
@@ -667,9 +763,9 @@ var _ = Describe("Watcher", func() {
 						Client: tarServer.Client(),
 					}
 
-					err := zipcl.DownloadModel(modelDir, zipModel, zipStorageURI)
+					err := zipcl.DownloadModel(modelDir, zipModel, zipStorageURI, nil)
 					Expect(err).To(BeNil())
-					err = tarcl.DownloadModel(modelDir, tarModel, tarStorageURI)
+					err = tarcl.DownloadModel(modelDir, tarModel, tarStorageURI, nil)
 					Expect(err).To(BeNil())
 				}
 			})
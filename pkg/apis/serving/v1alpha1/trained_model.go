@@ -29,6 +29,7 @@ import (
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".status.url"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.modelState"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:resource:path=trainedmodels,shortName=tm,singular=trainedmodel
 type TrainedModel struct {
@@ -70,6 +71,18 @@ type ModelSpec struct {
 	Framework string `json:"framework"`
 	// Maximum memory this model will consume, this field is used to decide if a model server has enough memory to load this model.
 	Memory resource.Quantity `json:"memory"`
+	// Expected digest of the model as it is downloaded from the storage URI, in the form "sha256:<hex>".
+	// This is the digest of the archive for archived models, or of the single model file.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+	// Expected digests of the downloaded model files in the form "sha256:<hex>", keyed by the file path
+	// relative to the model directory.
+	// +optional
+	FileDigests map[string]string `json:"fileDigests,omitempty"`
+	// Priority of the model download when the model agent downloads the models in priority order,
+	// the models with the highest priority are downloaded first.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
 }
 
 func (tms *TrainedModelList) TotalRequestedMemory() resource.Quantity {
@@ -33,8 +33,34 @@ type TrainedModelStatus struct {
 	// Addressable endpoint for the deployed trained model
 	// http://<inferenceservice.metadata.name>/v1/models/<trainedmodel>.metadata.name
 	Address *duckv1.Addressable `json:"address,omitempty"`
+	// State of the model as reported by the model agents of the InferenceService predictor
+	// +optional
+	ModelState TrainedModelState `json:"modelState,omitempty"`
+	// Storage uri of the model version the model registry uri of the model, e.g. models:/{name}/{stage},
+	// resolved to, as reported by the model agents
+	// +optional
+	ResolvedStorageURI string `json:"resolvedStorageUri,omitempty"`
+	// Model version the model registry uri of the model resolved to
+	// +optional
+	ResolvedVersion string `json:"resolvedVersion,omitempty"`
 }
 
+// TrainedModelState enum
+// +kubebuilder:validation:Enum="";Downloading;Loading;Loaded;FailedToLoad
+type TrainedModelState string
+
+// TrainedModelState Enum values
+const (
+	// Model is being downloaded by the model agent
+	Downloading TrainedModelState = "Downloading"
+	// Model is downloaded and being loaded onto the model server
+	Loading TrainedModelState = "Loading"
+	// Model is loaded onto the model server of every predictor replica
+	Loaded TrainedModelState = "Loaded"
+	// Model failed to download or load on at least one predictor replica
+	FailedToLoad TrainedModelState = "FailedToLoad"
+)
+
 // ConditionType represents a Service condition value
 const (
 	// InferenceServiceReady is set when inference service reported readiness
@@ -43,6 +69,9 @@ const (
 	MemoryResourceAvailable apis.ConditionType = "MemoryResourceAvailable"
 	// IsMMSPredictor is set when inference service predictor is set to multi-model serving
 	IsMMSPredictor apis.ConditionType = "IsMMSPredictor"
+	// ModelLoaded is set when the model agents reported the model is loaded, or if the controller does
+	// not poll the model agents for the model state
+	ModelLoaded apis.ConditionType = "ModelLoaded"
 )
 
 // TrainedModel Ready condition is depending on inference service readiness condition
@@ -51,6 +80,7 @@ var conditionSet = apis.NewLivingConditionSet(
 	InferenceServiceReady,
 	MemoryResourceAvailable,
 	IsMMSPredictor,
+	ModelLoaded,
 )
 
 var _ apis.ConditionsAccessor = (*TrainedModelStatus)(nil)
@@ -79,6 +109,8 @@ func (ss *TrainedModelStatus) SetCondition(conditionType apis.ConditionType, con
 	case condition == nil:
 	case condition.Status == v1.ConditionUnknown:
 		conditionSet.Manage(ss).MarkUnknown(conditionType, condition.Reason, condition.Message)
+	case condition.Status == v1.ConditionTrue && condition.Reason != "":
+		conditionSet.Manage(ss).MarkTrueWithReason(conditionType, condition.Reason, condition.Message)
 	case condition.Status == v1.ConditionTrue:
 		conditionSet.Manage(ss).MarkTrue(conditionType)
 	case condition.Status == v1.ConditionFalse:
@@ -36,6 +36,8 @@ const (
 	InvalidTmNameFormatError            = "the Trained Model \"%s\" is invalid: a Trained Model name must consist of alphanumeric characters, '_', or '-'. (e.g. \"my-Name\" or \"abc_123\", regex used for validation is '%s')"
 	InvalidStorageUriFormatError        = "the Trained Model \"%s\" storageUri field is invalid. The storage uri must start with one of the prefixes: %s. (the storage uri given is \"%s\")"
 	InvalidTmMemoryModification         = "the Trained Model \"%s\" memory field is immutable. The memory was \"%s\" but it is updated to \"%s\""
+	InvalidTmDigestError                = "the Trained Model \"%s\" %s field is invalid: %v"
+	InvalidTmStorageUriError            = "the Trained Model \"%s\" storageUri field is invalid: %v"
 )
 
 var (
@@ -43,7 +45,7 @@ var (
 	tmLogger = logf.Log.WithName("trainedmodel-alpha1-validator")
 	// regular expressions for validation of tm name
 	TmRegexp = regexp.MustCompile("^" + TmNameFmt + "$")
-	// protocols that are accepted by storage uri
+	// builtin protocols that are accepted by storage uri, the protocols of the agent storage plugins are accepted too
 	StorageUriProtocols = strings.Join(storage.GetAllProtocol(), CommaSpaceSeparator)
 )
 
@@ -91,6 +93,7 @@ func (tm *TrainedModel) validateTrainedModel() error {
 	return utils.FirstNonNilError([]error{
 		tm.validateTrainedModelName(),
 		tm.validateStorageURI(),
+		tm.validateDigests(),
 	})
 }
 
@@ -111,7 +114,34 @@ func (tm *TrainedModel) validateTrainedModelName() error {
 // Validates TrainModel's storageURI
 func (tm *TrainedModel) validateStorageURI() error {
 	if !utils.IsPrefixSupported(tm.Spec.Model.StorageURI, storage.GetAllProtocol()) {
-		return fmt.Errorf(InvalidStorageUriFormatError, tm.Name, StorageUriProtocols, tm.Spec.Model.StorageURI)
+		return fmt.Errorf(InvalidStorageUriFormatError, tm.Name, strings.Join(storage.GetAllProtocol(), CommaSpaceSeparator), tm.Spec.Model.StorageURI)
+	}
+	if storage.IsModelRegistryURI(tm.Spec.Model.StorageURI) {
+		if err := storage.ValidateModelRegistryURI(tm.Spec.Model.StorageURI); err != nil {
+			return fmt.Errorf(InvalidTmStorageUriError, tm.Name, err)
+		}
+	}
+	for _, protocol := range storage.SupportedProtocols {
+		if strings.HasPrefix(tm.Spec.Model.StorageURI, string(protocol)) {
+			if _, _, err := storage.ParseStorageURI(protocol, tm.Spec.Model.StorageURI); err != nil {
+				return fmt.Errorf(InvalidTmStorageUriError, tm.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validates format of TrainedModel's digests
+func (tm *TrainedModel) validateDigests() error {
+	if tm.Spec.Model.Digest != "" {
+		if err := storage.ValidateDigest(tm.Spec.Model.Digest); err != nil {
+			return fmt.Errorf(InvalidTmDigestError, tm.Name, "digest", err)
+		}
+	}
+	for file, digest := range tm.Spec.Model.FileDigests {
+		if err := storage.ValidateDigest(digest); err != nil {
+			return fmt.Errorf(InvalidTmDigestError, tm.Name, "fileDigests["+file+"]", err)
+		}
 	}
 	return nil
 }
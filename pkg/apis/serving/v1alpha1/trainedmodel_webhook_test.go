@@ -18,6 +18,9 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/kserve/kserve/pkg/agent/storage"
 	"github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -31,6 +34,8 @@ var (
 	storageURI      = "storageURI"
 	framework       = "framework"
 	memory          = "memory"
+	digest          = "digest"
+	fileDigest      = "fileDigest"
 )
 
 func makeTestTrainModel() TrainedModel {
@@ -125,6 +130,68 @@ func TestValidateCreate(t *testing.T) {
 			errMatcher:      gomega.MatchError(fmt.Errorf(InvalidStorageUriFormatError, "bar", StorageUriProtocols, "foo://kfserving/sklearn/iris")),
 			warningsMatcher: gomega.BeEmpty(),
 		},
+		"valid storageURI version": {
+			tm: makeTestTrainModel(),
+			update: map[string]string{
+				storageURI: "s3://kfserving/sklearn/iris/model.joblib?versionId=3HL4kqtJlcpXroDTDmJ",
+			},
+			errMatcher:      gomega.MatchError(nil),
+			warningsMatcher: gomega.BeEmpty(),
+		},
+		"invalid storageURI parameter": {
+			tm: makeTestTrainModel(),
+			update: map[string]string{
+				storageURI: "gs://kfserving/sklearn/iris/model.joblib?versionId=1",
+			},
+			errMatcher: gomega.MatchError(fmt.Errorf(InvalidTmStorageUriError, "bar",
+				func() error {
+					_, _, err := storage.ParseStorageURI(storage.GCS, "gs://kfserving/sklearn/iris/model.joblib?versionId=1")
+					return err
+				}())),
+			warningsMatcher: gomega.BeEmpty(),
+		},
+		"valid model registry storageURI": {
+			tm: makeTestTrainModel(),
+			update: map[string]string{
+				storageURI: "models:/fraud-detector/Production",
+			},
+			errMatcher:      gomega.MatchError(nil),
+			warningsMatcher: gomega.BeEmpty(),
+		},
+		"invalid model registry storageURI": {
+			tm: makeTestTrainModel(),
+			update: map[string]string{
+				storageURI: "mlflow://fraud-detector",
+			},
+			errMatcher: gomega.MatchError(fmt.Errorf(InvalidTmStorageUriError, "bar",
+				storage.ValidateModelRegistryURI("mlflow://fraud-detector"))),
+			warningsMatcher: gomega.BeEmpty(),
+		},
+		"valid digests": {
+			tm: makeTestTrainModel(),
+			update: map[string]string{
+				digest:     "sha256:" + strings.Repeat("a", 64),
+				fileDigest: "sha256:" + strings.Repeat("b", 64),
+			},
+			errMatcher:      gomega.MatchError(nil),
+			warningsMatcher: gomega.BeEmpty(),
+		},
+		"invalid digest": {
+			tm: makeTestTrainModel(),
+			update: map[string]string{
+				digest: "md5:abc",
+			},
+			errMatcher:      gomega.MatchError(fmt.Errorf(InvalidTmDigestError, "bar", "digest", storage.ValidateDigest("md5:abc"))),
+			warningsMatcher: gomega.BeEmpty(),
+		},
+		"invalid file digest": {
+			tm: makeTestTrainModel(),
+			update: map[string]string{
+				fileDigest: "abc",
+			},
+			errMatcher:      gomega.MatchError(fmt.Errorf(InvalidTmDigestError, "bar", "fileDigests[model.joblib]", storage.ValidateDigest("abc"))),
+			warningsMatcher: gomega.BeEmpty(),
+		},
 	}
 
 	for testName, scenario := range scenarios {
@@ -307,5 +374,9 @@ func (tm *TrainedModel) update(tmField string, value string) {
 		tm.Spec.Model.Framework = value
 	} else if tmField == memory {
 		tm.Spec.Model.Memory = resource.MustParse(value)
+	} else if tmField == digest {
+		tm.Spec.Model.Digest = value
+	} else if tmField == fileDigest {
+		tm.Spec.Model.FileDigests = map[string]string{"model.joblib": value}
 	}
 }
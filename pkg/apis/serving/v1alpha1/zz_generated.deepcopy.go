@@ -363,6 +363,13 @@ func (in *InferenceTarget) DeepCopy() *InferenceTarget {
 func (in *ModelSpec) DeepCopyInto(out *ModelSpec) {
 	*out = *in
 	out.Memory = in.Memory.DeepCopy()
+	if in.FileDigests != nil {
+		in, out := &in.FileDigests, &out.FileDigests
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelSpec.
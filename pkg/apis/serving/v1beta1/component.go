@@ -19,6 +19,7 @@ package v1beta1
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/kserve/kserve/pkg/constants"
@@ -36,8 +37,17 @@ const (
 	UnsupportedStorageURIFormatError    = "storageUri, must be one of: [%s] or match https://{}.blob.core.windows.net/{}/{} or be an absolute or relative local path. StorageUri [%s] is not supported."
 	UnsupportedStorageSpecFormatError   = "storage.spec.type, must be one of: [%s]. storage.spec.type [%s] is not supported."
 	InvalidLoggerType                   = "Invalid logger type"
+	InvalidLoggerSinkType               = "Invalid logger sink type"
+	LoggerSinkURLRequiredError          = "Logger URL is required for the %s sink"
+	InvalidLoggerSamplingPercentage     = "Logger sampling percentage %d must be between 0 and 100"
+	InvalidLoggerRegexp                 = "Invalid logger %s regular expression %q: %v"
+	InvalidLoggerStatusCode             = "Invalid logger filter status code %q, must be a status code such as 200 or a class such as 5xx"
+	LoggerJSONFieldPathRequiredError    = "Logger filter jsonField path is required"
+	InvalidLoggerRedactionPath          = "Invalid logger redaction path %q"
+	LoggerRedactionPatternRequiredError = "Logger redaction mask pattern is required"
 	InvalidISVCNameFormatError          = "The InferenceService \"%s\" is invalid: a InferenceService name must consist of lower case alphanumeric characters or '-', and must start with alphabetical character. (e.g. \"my-name\" or \"abc-123\", regex used for validation is '%s')"
 	InvalidProtocol                     = "Invalid protocol %s. Must be one of [%s]"
+	InvalidModelRepository              = "Invalid model repository %q. Must be one of [%s]"
 )
 
 // Constants
@@ -184,9 +194,88 @@ func validateContainerConcurrency(containerConcurrency *int64) error {
 
 func validateLogger(logger *LoggerSpec) error {
 	if logger != nil {
-		if !(logger.Mode == LogAll || logger.Mode == LogRequest || logger.Mode == LogResponse) {
+		if !(logger.Mode == LogAll || logger.Mode == LogRequest || logger.Mode == LogResponse || logger.Mode == LogCombined) {
 			return fmt.Errorf(InvalidLoggerType)
 		}
+		switch logger.Sink {
+		case "", LoggerSinkCloudEvents, LoggerSinkCloudEventsStructured, LoggerSinkHTTPBatch:
+		case LoggerSinkKafka, LoggerSinkFile:
+			// there is no cluster default for these sinks
+			if logger.URL == nil {
+				return fmt.Errorf(LoggerSinkURLRequiredError, logger.Sink)
+			}
+		default:
+			return fmt.Errorf(InvalidLoggerSinkType)
+		}
+		if logger.Filter != nil {
+			if err := validateLoggerFilter(logger.Filter); err != nil {
+				return err
+			}
+		}
+		if logger.Redaction != nil {
+			return validateLoggerRedaction(logger.Redaction)
+		}
+	}
+	return nil
+}
+
+var loggerStatusCodeRegex = regexp.MustCompile("^[1-5][0-9x][0-9x]$")
+
+func validateLoggerFilter(filter *LoggerFilterSpec) error {
+	if err := validateSamplingPercentage(filter.SamplingPercentage); err != nil {
+		return err
+	}
+	for _, rule := range filter.Rules {
+		if err := validateSamplingPercentage(rule.SamplingPercentage); err != nil {
+			return err
+		}
+		if _, err := regexp.Compile(rule.Path); err != nil {
+			return fmt.Errorf(InvalidLoggerRegexp, "filter path", rule.Path, err)
+		}
+		for _, code := range rule.StatusCodes {
+			if !loggerStatusCodeRegex.MatchString(code) {
+				return fmt.Errorf(InvalidLoggerStatusCode, code)
+			}
+		}
+		for header, value := range rule.Headers {
+			if _, err := regexp.Compile(value); err != nil {
+				return fmt.Errorf(InvalidLoggerRegexp, "filter header "+header, value, err)
+			}
+		}
+		if rule.JSONField != nil {
+			if rule.JSONField.Path == "" {
+				return fmt.Errorf(LoggerJSONFieldPathRequiredError)
+			}
+			if _, err := regexp.Compile(rule.JSONField.Value); err != nil {
+				return fmt.Errorf(InvalidLoggerRegexp, "filter jsonField value", rule.JSONField.Value, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateLoggerRedaction(redaction *LoggerRedactionSpec) error {
+	for _, path := range append(append([]string{}, redaction.Drop...), redaction.Hash...) {
+		for _, segment := range strings.Split(path, ".") {
+			if segment == "" {
+				return fmt.Errorf(InvalidLoggerRedactionPath, path)
+			}
+		}
+	}
+	for _, mask := range redaction.Masks {
+		if mask.Pattern == "" {
+			return fmt.Errorf(LoggerRedactionPatternRequiredError)
+		}
+		if _, err := regexp.Compile(mask.Pattern); err != nil {
+			return fmt.Errorf(InvalidLoggerRegexp, "redaction mask", mask.Pattern, err)
+		}
+	}
+	return nil
+}
+
+func validateSamplingPercentage(percentage *int32) error {
+	if percentage != nil && (*percentage < 0 || *percentage > 100) {
+		return fmt.Errorf(InvalidLoggerSamplingPercentage, *percentage)
 	}
 	return nil
 }
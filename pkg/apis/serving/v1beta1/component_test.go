@@ -138,12 +138,119 @@ func TestComponentExtensionSpec_validateLogger(t *testing.T) {
 			},
 			matcher: gomega.BeNil(),
 		},
+		"LoggerWithLogCombinedMode": {
+			logger: &LoggerSpec{
+				Mode: LogCombined,
+			},
+			matcher: gomega.BeNil(),
+		},
 		"InvalidLoggerMode": {
 			logger: &LoggerSpec{
 				Mode: "InvalidMode",
 			},
 			matcher: gomega.MatchError(fmt.Errorf(InvalidLoggerType)),
 		},
+		"LoggerWithKafkaSink": {
+			logger: &LoggerSpec{
+				Mode: LogAll,
+				Sink: LoggerSinkKafka,
+				URL:  proto.String("kafka://broker:9092/payloads"),
+			},
+			matcher: gomega.BeNil(),
+		},
+		"LoggerWithFileSinkWithoutURL": {
+			logger: &LoggerSpec{
+				Mode: LogAll,
+				Sink: LoggerSinkFile,
+			},
+			matcher: gomega.MatchError(fmt.Errorf(LoggerSinkURLRequiredError, LoggerSinkFile)),
+		},
+		"InvalidLoggerSink": {
+			logger: &LoggerSpec{
+				Mode: LogAll,
+				Sink: "InvalidSink",
+			},
+			matcher: gomega.MatchError(fmt.Errorf(InvalidLoggerSinkType)),
+		},
+		"LoggerWithFilter": {
+			logger: &LoggerSpec{
+				Mode: LogAll,
+				Filter: &LoggerFilterSpec{
+					SamplingPercentage: proto.Int32(1),
+					Rules: []LoggerFilterRule{
+						{
+							StatusCodes:        []string{"4xx", "503"},
+							SamplingPercentage: proto.Int32(100),
+						},
+						{
+							Path:      "^/v2/models/.*/infer$",
+							Headers:   map[string]string{"X-Tenant": "^gold-"},
+							JSONField: &LoggerJSONFieldPredicate{Path: "parameters.debug", Value: "true"},
+						},
+					},
+				},
+			},
+			matcher: gomega.BeNil(),
+		},
+		"LoggerWithInvalidSamplingPercentage": {
+			logger: &LoggerSpec{
+				Mode:   LogAll,
+				Filter: &LoggerFilterSpec{SamplingPercentage: proto.Int32(101)},
+			},
+			matcher: gomega.MatchError(fmt.Errorf(InvalidLoggerSamplingPercentage, 101)),
+		},
+		"LoggerWithInvalidStatusCode": {
+			logger: &LoggerSpec{
+				Mode: LogAll,
+				Filter: &LoggerFilterSpec{
+					Rules: []LoggerFilterRule{{StatusCodes: []string{"50"}}},
+				},
+			},
+			matcher: gomega.MatchError(fmt.Errorf(InvalidLoggerStatusCode, "50")),
+		},
+		"LoggerWithInvalidPathRegexp": {
+			logger: &LoggerSpec{
+				Mode: LogAll,
+				Filter: &LoggerFilterSpec{
+					Rules: []LoggerFilterRule{{Path: "("}},
+				},
+			},
+			matcher: gomega.HaveOccurred(),
+		},
+		"LoggerWithoutJSONFieldPath": {
+			logger: &LoggerSpec{
+				Mode: LogAll,
+				Filter: &LoggerFilterSpec{
+					Rules: []LoggerFilterRule{{JSONField: &LoggerJSONFieldPredicate{Value: "x"}}},
+				},
+			},
+			matcher: gomega.MatchError(fmt.Errorf(LoggerJSONFieldPathRequiredError)),
+		},
+		"LoggerWithRedaction": {
+			logger: &LoggerSpec{
+				Mode: LogAll,
+				Redaction: &LoggerRedactionSpec{
+					Drop:  []string{"instances.*.notes", "inputs.notes"},
+					Hash:  []string{"instances.*.ssn"},
+					Masks: []LoggerRedactionMask{{Pattern: "[0-9]{3}-[0-9]{2}-[0-9]{4}"}},
+				},
+			},
+			matcher: gomega.BeNil(),
+		},
+		"LoggerWithInvalidRedactionPath": {
+			logger: &LoggerSpec{
+				Mode:      LogAll,
+				Redaction: &LoggerRedactionSpec{Drop: []string{"instances..notes"}},
+			},
+			matcher: gomega.MatchError(fmt.Errorf(InvalidLoggerRedactionPath, "instances..notes")),
+		},
+		"LoggerWithoutRedactionPattern": {
+			logger: &LoggerSpec{
+				Mode:      LogAll,
+				Redaction: &LoggerRedactionSpec{Masks: []LoggerRedactionMask{{}}},
+			},
+			matcher: gomega.MatchError(fmt.Errorf(LoggerRedactionPatternRequiredError)),
+		},
 		"LoggerIsNil": {
 			logger:  nil,
 			matcher: gomega.BeNil(),
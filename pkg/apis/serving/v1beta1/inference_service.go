@@ -36,7 +36,7 @@ type InferenceServiceSpec struct {
 }
 
 // LoggerType controls the scope of log publishing
-// +kubebuilder:validation:Enum=all;request;response
+// +kubebuilder:validation:Enum=all;request;response;combined
 type LoggerType string
 
 // LoggerType Enum
@@ -47,20 +47,130 @@ const (
 	LogRequest LoggerType = "request"
 	// Logger mode to log only response
 	LogResponse LoggerType = "response"
+	// Logger mode to log the request and response together in a single inference event
+	LogCombined LoggerType = "combined"
+)
+
+// LoggerSinkType controls where and how logged payloads are delivered
+// +kubebuilder:validation:Enum=cloudevents;cloudevents-structured;http-batch;kafka;file
+type LoggerSinkType string
+
+// LoggerSinkType Enum
+const (
+	// Post each payload as a binary mode CloudEvent over HTTP
+	LoggerSinkCloudEvents LoggerSinkType = "cloudevents"
+	// Post each payload as a structured mode CloudEvent over HTTP
+	LoggerSinkCloudEventsStructured LoggerSinkType = "cloudevents-structured"
+	// Post batches of payloads as arrays of structured mode CloudEvents over HTTP
+	LoggerSinkHTTPBatch LoggerSinkType = "http-batch"
+	// Produce each payload as a binary mode CloudEvent to a Kafka topic
+	LoggerSinkKafka LoggerSinkType = "kafka"
+	// Append each payload as a structured mode CloudEvent to a rotated JSON lines file
+	LoggerSinkFile LoggerSinkType = "file"
 )
 
 // LoggerSpec specifies optional payload logging available for all components
 type LoggerSpec struct {
-	// URL to send logging events
+	// URL to send logging events. For the kafka sink the URL is of the form
+	// kafka://<broker>[,<broker>]/<topic>, for the file sink it is of the form file:///<path>
 	// +optional
 	URL *string `json:"url,omitempty"`
 	// Specifies the scope of the loggers. <br />
 	// Valid values are: <br />
 	// - "all" (default): log both request and response; <br />
 	// - "request": log only request; <br />
-	// - "response": log only response <br />
+	// - "response": log only response; <br />
+	// - "combined": log the request and response together in a single event <br />
 	// +optional
 	Mode LoggerType `json:"mode,omitempty"`
+	// Specifies the sink the logging events are delivered to. <br />
+	// Valid values are: <br />
+	// - "cloudevents" (default): binary mode CloudEvents posted over HTTP; <br />
+	// - "cloudevents-structured": structured mode CloudEvents posted over HTTP; <br />
+	// - "http-batch": arrays of structured mode CloudEvents posted over HTTP; <br />
+	// - "kafka": binary mode CloudEvents produced to a Kafka topic; <br />
+	// - "file": structured mode CloudEvents appended to a rotated JSON lines file <br />
+	// +optional
+	Sink LoggerSinkType `json:"sink,omitempty"`
+	// Specifies which requests are logged. All requests are logged if not set.
+	// +optional
+	Filter *LoggerFilterSpec `json:"filter,omitempty"`
+	// Specifies how the logged request and response payloads are redacted before they are sent.
+	// +optional
+	Redaction *LoggerRedactionSpec `json:"redaction,omitempty"`
+}
+
+// LoggerFilterSpec selects the requests which are logged. The rules are evaluated in order and the
+// sampling percentage of the first matching rule applies; requests which match no rule are sampled
+// with SamplingPercentage. The request and the response of a sampled request are both logged.
+// Responses with a status code other than 200 are only logged if they match a rule with StatusCodes.
+type LoggerFilterSpec struct {
+	// Percentage, from 0 to 100, of the requests matching no rule to log. Defaults to 100.
+	// +optional
+	SamplingPercentage *int32 `json:"samplingPercentage,omitempty"`
+	// Rules selecting requests to log with their own sampling percentage.
+	// +optional
+	Rules []LoggerFilterRule `json:"rules,omitempty"`
+}
+
+// LoggerFilterRule matches a request when all of its conditions match.
+type LoggerFilterRule struct {
+	// Regular expression the request path must match.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Status codes the response must have, such as "200", or status code classes, such as "5xx".
+	// When set, the request is only logged once the response is received.
+	// +optional
+	StatusCodes []string `json:"statusCodes,omitempty"`
+	// Request headers which must be present, mapped to regular expressions their value must match.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+	// Predicate on a field of the JSON request body.
+	// +optional
+	JSONField *LoggerJSONFieldPredicate `json:"jsonField,omitempty"`
+	// Percentage, from 0 to 100, of the matching requests to log. Defaults to 100.
+	// +optional
+	SamplingPercentage *int32 `json:"samplingPercentage,omitempty"`
+}
+
+// LoggerRedactionSpec specifies the fields removed or hashed from JSON payloads and the patterns masked
+// in them. Fields are selected by dot separated paths. A path segment selects the field of an object
+// with that name, or the element of an array at that index; "*" selects every field or element.
+// A segment which is not an index selects the elements of an array which are objects with a "name"
+// field of that value, such as the named tensors of the v2 protocol, e.g. "inputs.ssn".
+// If fields are removed or hashed, payloads which can not be decoded are not logged. Only the JSON
+// header of the v2 payloads using the binary tensor extension is logged, without the binary data.
+type LoggerRedactionSpec struct {
+	// Paths of the fields to remove, e.g. "instances.*.notes".
+	// +optional
+	Drop []string `json:"drop,omitempty"`
+	// Paths of the fields to replace with the hex encoded SHA-256 hash of their value. The data of
+	// a selected v2 tensor is hashed element by element.
+	// +optional
+	Hash []string `json:"hash,omitempty"`
+	// Patterns masked in every string of the payload, or in the whole payload if it is not JSON.
+	// +optional
+	Masks []LoggerRedactionMask `json:"masks,omitempty"`
+}
+
+// LoggerRedactionMask replaces the matches of a regular expression.
+type LoggerRedactionMask struct {
+	// Regular expression to mask, e.g. "[0-9]{3}-[0-9]{2}-[0-9]{4}".
+	Pattern string `json:"pattern"`
+	// Replacement of the matches, which may refer to capture groups as $1. Defaults to "****".
+	// +optional
+	Replacement *string `json:"replacement,omitempty"`
+}
+
+// LoggerJSONFieldPredicate matches a field of the JSON request body.
+type LoggerJSONFieldPredicate struct {
+	// Dot separated path to the field, array elements are selected by their index,
+	// e.g. "parameters.user_id" or "instances.0.country".
+	Path string `json:"path"`
+	// Regular expression the field value must match. Values which are not strings are matched
+	// against their JSON encoding. The field only has to be present if not set.
+	// +optional
+	Value string `json:"value,omitempty"`
 }
 
 // Batcher specifies optional payload batching available for all components
@@ -74,6 +184,10 @@ type Batcher struct {
 	// Specifies the timeout of a batch
 	// +optional
 	Timeout *int `json:"timeout,omitempty"`
+	// Specifies whether the errors of single instances are returned along with the predictions of
+	// the other instances of the batch, rather than failing the whole batch
+	// +optional
+	PartialFailure *bool `json:"partialFailure,omitempty"`
 }
 
 // InferenceService is the Schema for the InferenceServices API
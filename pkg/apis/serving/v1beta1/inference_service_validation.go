@@ -19,7 +19,9 @@ package v1beta1
 import (
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
@@ -71,6 +73,10 @@ func (isvc *InferenceService) ValidateCreate() (admission.Warnings, error) {
 		return allWarnings, err
 	}
 
+	if err := validateModelRepository(annotations); err != nil {
+		return allWarnings, err
+	}
+
 	for _, component := range []Component{
 		&isvc.Spec.Predictor,
 		isvc.Spec.Transformer,
@@ -131,6 +137,15 @@ func validateInferenceServiceName(isvc *InferenceService) error {
 	return nil
 }
 
+// Validation of the repository API the model agent loads the models through
+func validateModelRepository(annotations map[string]string) error {
+	repository, ok := annotations[constants.ModelRepositoryAnnotationKey]
+	if ok && !slices.Contains(constants.ModelRepositoryTypes, repository) {
+		return fmt.Errorf(InvalidModelRepository, repository, strings.Join(constants.ModelRepositoryTypes, ", "))
+	}
+	return nil
+}
+
 // Validation of isvc autoscaler class
 func validateInferenceServiceAutoscaler(isvc *InferenceService) error {
 	annotations := isvc.ObjectMeta.Annotations
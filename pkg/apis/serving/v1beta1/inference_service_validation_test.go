@@ -132,6 +132,20 @@ func TestInvalidAutoscalerHPAMetrics(t *testing.T) {
 	g.Expect(warnings).Should(gomega.BeEmpty())
 }
 
+func TestModelRepository(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	isvc := makeTestRawInferenceService()
+	isvc.ObjectMeta.Annotations["serving.kserve.io/model-repository"] = "torchserve"
+	warnings, err := isvc.ValidateCreate()
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(warnings).Should(gomega.BeEmpty())
+
+	isvc.ObjectMeta.Annotations["serving.kserve.io/model-repository"] = "triton"
+	warnings, err = isvc.ValidateCreate()
+	g.Expect(err).ShouldNot(gomega.Succeed())
+	g.Expect(warnings).Should(gomega.BeEmpty())
+}
+
 func TestRejectMultipleModelSpecs(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 	isvc := makeTestInferenceService()
@@ -76,6 +76,11 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.InferenceServicesConfig":      schema_pkg_apis_serving_v1beta1_InferenceServicesConfig(ref),
 		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.IngressConfig":                schema_pkg_apis_serving_v1beta1_IngressConfig(ref),
 		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LightGBMSpec":                 schema_pkg_apis_serving_v1beta1_LightGBMSpec(ref),
+		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerFilterRule":             schema_pkg_apis_serving_v1beta1_LoggerFilterRule(ref),
+		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerFilterSpec":             schema_pkg_apis_serving_v1beta1_LoggerFilterSpec(ref),
+		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerJSONFieldPredicate":     schema_pkg_apis_serving_v1beta1_LoggerJSONFieldPredicate(ref),
+		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerRedactionMask":          schema_pkg_apis_serving_v1beta1_LoggerRedactionMask(ref),
+		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerRedactionSpec":          schema_pkg_apis_serving_v1beta1_LoggerRedactionSpec(ref),
 		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerSpec":                   schema_pkg_apis_serving_v1beta1_LoggerSpec(ref),
 		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.ModelCopies":                  schema_pkg_apis_serving_v1beta1_ModelCopies(ref),
 		"github.com/kserve/kserve/pkg/apis/serving/v1beta1.ModelFormat":                  schema_pkg_apis_serving_v1beta1_ModelFormat(ref),
@@ -756,6 +761,36 @@ func schema_pkg_apis_serving_v1alpha1_ModelSpec(ref common.ReferenceCallback) co
 							Ref:         ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
 						},
 					},
+					"digest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Expected digest of the model as it is downloaded from the storage URI, in the form \"sha256:<hex>\". This is the digest of the archive for archived models, or of the single model file.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"fileDigests": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Expected digests of the downloaded model files in the form \"sha256:<hex>\", keyed by the file path relative to the model directory.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"priority": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Priority of the model download when the model agent downloads the models in priority order, the models with the highest priority are downloaded first.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 				},
 				Required: []string{"storageUri", "framework", "memory"},
 			},
@@ -1840,6 +1875,13 @@ func schema_pkg_apis_serving_v1beta1_Batcher(ref common.ReferenceCallback) commo
 							Format:      "int32",
 						},
 					},
+					"partialFailure": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Specifies whether the errors of single instances are returned along with the predictions of the other instances of the batch, rather than failing the whole batch",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
@@ -5289,6 +5331,225 @@ func schema_pkg_apis_serving_v1beta1_LightGBMSpec(ref common.ReferenceCallback)
 	}
 }
 
+func schema_pkg_apis_serving_v1beta1_LoggerFilterRule(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "LoggerFilterRule matches a request when all of its conditions match.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"path": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Regular expression the request path must match.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"statusCodes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status codes the response must have, such as \"200\", or status code classes, such as \"5xx\". When set, the request is only logged once the response is received.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"headers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Request headers which must be present, mapped to regular expressions their value must match.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"jsonField": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Predicate on a field of the JSON request body.",
+							Ref:         ref("github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerJSONFieldPredicate"),
+						},
+					},
+					"samplingPercentage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Percentage, from 0 to 100, of the matching requests to log. Defaults to 100.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerJSONFieldPredicate"},
+	}
+}
+
+func schema_pkg_apis_serving_v1beta1_LoggerFilterSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "LoggerFilterSpec selects the requests which are logged. The rules are evaluated in order and the sampling percentage of the first matching rule applies; requests which match no rule are sampled with SamplingPercentage. The request and the response of a sampled request are both logged. Responses with a status code other than 200 are only logged if they match a rule with StatusCodes.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"samplingPercentage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Percentage, from 0 to 100, of the requests matching no rule to log. Defaults to 100.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"rules": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Rules selecting requests to log with their own sampling percentage.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerFilterRule"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerFilterRule"},
+	}
+}
+
+func schema_pkg_apis_serving_v1beta1_LoggerJSONFieldPredicate(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "LoggerJSONFieldPredicate matches a field of the JSON request body.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"path": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Dot separated path to the field, array elements are selected by their index, e.g. \"parameters.user_id\" or \"instances.0.country\".",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"value": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Regular expression the field value must match. Values which are not strings are matched against their JSON encoding. The field only has to be present if not set.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"path"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_serving_v1beta1_LoggerRedactionMask(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "LoggerRedactionMask replaces the matches of a regular expression.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"pattern": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Regular expression to mask, e.g. \"[0-9]{3}-[0-9]{2}-[0-9]{4}\".",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"replacement": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Replacement of the matches, which may refer to capture groups as $1. Defaults to \"****\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"pattern"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_serving_v1beta1_LoggerRedactionSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "LoggerRedactionSpec specifies the fields removed or hashed from JSON payloads and the patterns masked in them. Fields are selected by dot separated paths. A path segment selects the field of an object with that name, or the element of an array at that index; \"*\" selects every field or element. A segment which is not an index selects the elements of an array which are objects with a \"name\" field of that value, such as the named tensors of the v2 protocol, e.g. \"inputs.ssn\". If fields are removed or hashed, payloads which can not be decoded are not logged. Only the JSON header of the v2 payloads using the binary tensor extension is logged, without the binary data.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"drop": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Paths of the fields to remove, e.g. \"instances.*.notes\".",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"hash": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Paths of the fields to replace with the hex encoded SHA-256 hash of their value. The data of a selected v2 tensor is hashed element by element.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"masks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Patterns masked in every string of the payload, or in the whole payload if it is not JSON.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerRedactionMask"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerRedactionMask"},
+	}
+}
+
 func schema_pkg_apis_serving_v1beta1_LoggerSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -5298,21 +5559,42 @@ func schema_pkg_apis_serving_v1beta1_LoggerSpec(ref common.ReferenceCallback) co
 				Properties: map[string]spec.Schema{
 					"url": {
 						SchemaProps: spec.SchemaProps{
-							Description: "URL to send logging events",
+							Description: "URL to send logging events. For the kafka sink the URL is of the form kafka://<broker>[,<broker>]/<topic>, for the file sink it is of the form file:///<path>",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
 					"mode": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Specifies the scope of the loggers. <br /> Valid values are: <br /> - \"all\" (default): log both request and response; <br /> - \"request\": log only request; <br /> - \"response\": log only response <br />",
+							Description: "Specifies the scope of the loggers. <br /> Valid values are: <br /> - \"all\" (default): log both request and response; <br /> - \"request\": log only request; <br /> - \"response\": log only response; <br /> - \"combined\": log the request and response together in a single event <br />",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"sink": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Specifies the sink the logging events are delivered to. <br /> Valid values are: <br /> - \"cloudevents\" (default): binary mode CloudEvents posted over HTTP; <br /> - \"cloudevents-structured\": structured mode CloudEvents posted over HTTP; <br /> - \"http-batch\": arrays of structured mode CloudEvents posted over HTTP; <br /> - \"kafka\": binary mode CloudEvents produced to a Kafka topic; <br /> - \"file\": structured mode CloudEvents appended to a rotated JSON lines file <br />",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"filter": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Specifies which requests are logged. All requests are logged if not set.",
+							Ref:         ref("github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerFilterSpec"),
+						},
+					},
+					"redaction": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Specifies how the logged request and response payloads are redacted before they are sent.",
+							Ref:         ref("github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerRedactionSpec"),
+						},
+					},
 				},
 			},
 		},
+		Dependencies: []string{
+			"github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerFilterSpec", "github.com/kserve/kserve/pkg/apis/serving/v1beta1.LoggerRedactionSpec"},
 	}
 }
 
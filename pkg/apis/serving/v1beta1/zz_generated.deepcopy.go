@@ -64,6 +64,11 @@ func (in *Batcher) DeepCopyInto(out *Batcher) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.PartialFailure != nil {
+		in, out := &in.PartialFailure, &out.PartialFailure
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Batcher.
@@ -460,6 +465,137 @@ func (in *LightGBMSpec) DeepCopy() *LightGBMSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoggerFilterRule) DeepCopyInto(out *LoggerFilterRule) {
+	*out = *in
+	if in.StatusCodes != nil {
+		in, out := &in.StatusCodes, &out.StatusCodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.JSONField != nil {
+		in, out := &in.JSONField, &out.JSONField
+		*out = new(LoggerJSONFieldPredicate)
+		**out = **in
+	}
+	if in.SamplingPercentage != nil {
+		in, out := &in.SamplingPercentage, &out.SamplingPercentage
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggerFilterRule.
+func (in *LoggerFilterRule) DeepCopy() *LoggerFilterRule {
+	if in == nil {
+		return nil
+	}
+	out := new(LoggerFilterRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoggerFilterSpec) DeepCopyInto(out *LoggerFilterSpec) {
+	*out = *in
+	if in.SamplingPercentage != nil {
+		in, out := &in.SamplingPercentage, &out.SamplingPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]LoggerFilterRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggerFilterSpec.
+func (in *LoggerFilterSpec) DeepCopy() *LoggerFilterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LoggerFilterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoggerJSONFieldPredicate) DeepCopyInto(out *LoggerJSONFieldPredicate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggerJSONFieldPredicate.
+func (in *LoggerJSONFieldPredicate) DeepCopy() *LoggerJSONFieldPredicate {
+	if in == nil {
+		return nil
+	}
+	out := new(LoggerJSONFieldPredicate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoggerRedactionMask) DeepCopyInto(out *LoggerRedactionMask) {
+	*out = *in
+	if in.Replacement != nil {
+		in, out := &in.Replacement, &out.Replacement
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggerRedactionMask.
+func (in *LoggerRedactionMask) DeepCopy() *LoggerRedactionMask {
+	if in == nil {
+		return nil
+	}
+	out := new(LoggerRedactionMask)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoggerRedactionSpec) DeepCopyInto(out *LoggerRedactionSpec) {
+	*out = *in
+	if in.Drop != nil {
+		in, out := &in.Drop, &out.Drop
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hash != nil {
+		in, out := &in.Hash, &out.Hash
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Masks != nil {
+		in, out := &in.Masks, &out.Masks
+		*out = make([]LoggerRedactionMask, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggerRedactionSpec.
+func (in *LoggerRedactionSpec) DeepCopy() *LoggerRedactionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LoggerRedactionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoggerSpec) DeepCopyInto(out *LoggerSpec) {
 	*out = *in
@@ -468,6 +604,16 @@ func (in *LoggerSpec) DeepCopyInto(out *LoggerSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(LoggerFilterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Redaction != nil {
+		in, out := &in.Redaction, &out.Redaction
+		*out = new(LoggerRedactionSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggerSpec.
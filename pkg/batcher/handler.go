@@ -55,6 +55,11 @@ type Response struct {
 	Message     string        `json:"message"`
 	BatchID     string        `json:"batchId"`
 	Predictions []interface{} `json:"predictions"`
+	Errors      []interface{} `json:"errors,omitempty"`
+	StatusCode  int           `json:"-"`
+	ContentType string        `json:"-"`
+	// passthrough is set when Message holds the downstream error body, which is written as is
+	passthrough bool
 }
 
 type ResponseError struct {
@@ -63,6 +68,9 @@ type ResponseError struct {
 
 type PredictionResponse struct {
 	Predictions []interface{} `json:"predictions"`
+	// Errors holds the per-instance errors when the model server reports partial failures,
+	// a null entry marks an instance which was predicted successfully.
+	Errors []interface{} `json:"errors,omitempty"`
 }
 
 type BatcherInfo struct {
@@ -103,54 +111,84 @@ func (handler *BatchHandler) batchPredict() {
 	r := httptest.NewRequest("POST", handler.batcherInfo.Path, reader)
 	rr := httptest.NewRecorder()
 	handler.next.ServeHTTP(rr, r)
-	responseBody := rr.Body.Bytes()
-	if rr.Code != http.StatusOK {
-		handler.log.Errorf("error response with code %v", rr)
-		for _, v := range handler.batcherInfo.ContextMap {
-			res := Response{
-				Message:     string(responseBody),
-				BatchID:     "",
-				Predictions: nil,
-			}
-			*v.ChannelOut <- res
+	handler.dispatchResponse(rr.Code, rr.Header().Get("Content-Type"), rr.Body.Bytes())
+	handler.batcherInfo.InitializeInfo()
+}
+
+// dispatchResponse splits the response of a batched call and sends each caller its share of the
+// predictions, along with the downstream status code and content type.
+func (handler *BatchHandler) dispatchResponse(code int, contentType string, responseBody []byte) {
+	err := json.Unmarshal(responseBody, &handler.batcherInfo.PredictionResponse)
+	predictions := handler.batcherInfo.PredictionResponse.Predictions
+	instanceErrors := handler.batcherInfo.PredictionResponse.Errors
+	partial := handler.PartialFailure && err == nil && len(instanceErrors) == len(handler.batcherInfo.Instances)
+	if code != http.StatusOK && !partial {
+		handler.log.Errorf("error response with code %d: %s", code, string(responseBody))
+		handler.sendAll(Response{
+			Message:     string(responseBody),
+			BatchID:     "",
+			Predictions: nil,
+			StatusCode:  code,
+			ContentType: contentType,
+			passthrough: true,
+		})
+		return
+	}
+	handler.batcherInfo.BatchID = GenerateUUID()
+	if err != nil {
+		handler.sendAll(Response{
+			Message:     err.Error(),
+			BatchID:     handler.batcherInfo.BatchID,
+			StatusCode:  http.StatusInternalServerError,
+			ContentType: "application/json",
+		})
+		return
+	}
+	if partial && len(predictions) == 0 {
+		// every instance failed, the model server may omit the predictions altogether
+		predictions = make([]interface{}, len(handler.batcherInfo.Instances))
+	}
+	if len(predictions) != len(handler.batcherInfo.Instances) {
+		handler.sendAll(Response{
+			Message:     "size of prediction is not equal to the size of instances",
+			BatchID:     handler.batcherInfo.BatchID,
+			StatusCode:  http.StatusInternalServerError,
+			ContentType: "application/json",
+		})
+		return
+	}
+	for _, v := range handler.batcherInfo.ContextMap {
+		res := Response{
+			Message:     "",
+			BatchID:     handler.batcherInfo.BatchID,
+			Predictions: make([]interface{}, 0),
+			StatusCode:  http.StatusOK,
+			ContentType: contentType,
 		}
-	} else {
-		handler.batcherInfo.BatchID = GenerateUUID()
-		err := json.Unmarshal(responseBody, &handler.batcherInfo.PredictionResponse)
-		if err != nil {
-			for _, v := range handler.batcherInfo.ContextMap {
-				res := Response{
-					Message: err.Error(),
-					BatchID: handler.batcherInfo.BatchID,
-				}
-				*v.ChannelOut <- res
+		failed := false
+		for _, i := range v.Index {
+			res.Predictions = append(res.Predictions, predictions[i])
+			if partial {
+				res.Errors = append(res.Errors, instanceErrors[i])
+				failed = failed || instanceErrors[i] != nil
 			}
-		} else {
-			if len(handler.batcherInfo.PredictionResponse.Predictions) != len(handler.batcherInfo.Instances) {
-				for _, v := range handler.batcherInfo.ContextMap {
-					res := Response{
-						Message: "size of prediction is not equal to the size of instances",
-						BatchID: handler.batcherInfo.BatchID,
-					}
-					*v.ChannelOut <- res
-				}
-			} else {
-				for _, v := range handler.batcherInfo.ContextMap {
-					predictions := make([]interface{}, 0)
-					for _, i := range v.Index {
-						predictions = append(predictions, handler.batcherInfo.PredictionResponse.Predictions[i])
-					}
-					res := Response{
-						Message:     "",
-						BatchID:     handler.batcherInfo.BatchID,
-						Predictions: predictions,
-					}
-					*v.ChannelOut <- res
-				}
+		}
+		if failed {
+			// A caller with a failed instance gets the downstream error code, or a 500 when the model
+			// server reported per-instance errors with a successful status.
+			res.StatusCode = code
+			if res.StatusCode == http.StatusOK {
+				res.StatusCode = http.StatusInternalServerError
 			}
 		}
+		*v.ChannelOut <- res
+	}
+}
+
+func (handler *BatchHandler) sendAll(res Response) {
+	for _, v := range handler.batcherInfo.ContextMap {
+		*v.ChannelOut <- res
 	}
-	handler.batcherInfo.InitializeInfo()
 }
 
 func (handler *BatchHandler) batch() {
@@ -203,16 +241,20 @@ type BatchHandler struct {
 	channelIn    chan Input
 	MaxBatchSize int
 	MaxLatency   int
-	batcherInfo  BatcherInfo
+	// PartialFailure splits per-instance errors returned by the model server across the batch
+	// instead of failing every request of the batch.
+	PartialFailure bool
+	batcherInfo    BatcherInfo
 }
 
-func New(maxBatchSize int, maxLatency int, handler http.Handler, logger *zap.SugaredLogger) *BatchHandler {
+func New(maxBatchSize int, maxLatency int, partialFailure bool, handler http.Handler, logger *zap.SugaredLogger) *BatchHandler {
 	batchHandler := BatchHandler{
-		next:         handler,
-		log:          logger,
-		channelIn:    make(chan Input),
-		MaxBatchSize: maxBatchSize,
-		MaxLatency:   maxLatency,
+		next:           handler,
+		log:            logger,
+		channelIn:      make(chan Input),
+		MaxBatchSize:   maxBatchSize,
+		MaxLatency:     maxLatency,
+		PartialFailure: partialFailure,
 	}
 	go batchHandler.Consume()
 	return &batchHandler
@@ -253,13 +295,23 @@ func (handler *BatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	response := <-chl
 	close(chl)
-	rspbytes, err := json.Marshal(response)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if response.StatusCode == 0 {
+		response.StatusCode = http.StatusOK
+	}
+	if response.ContentType != "" {
+		w.Header().Set("Content-Type", response.ContentType)
+	}
+	rspbytes := []byte(response.Message)
+	if !response.passthrough {
+		rspbytes, err = json.Marshal(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 	}
+	w.WriteHeader(response.StatusCode)
 	_, err = w.Write(rspbytes)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		handler.log.Errorf("failed to write response: %v", err)
 	}
 }
@@ -76,7 +76,7 @@ func TestBatcher(t *testing.T) {
 	logger.Infof("predictor url %s", predictorSvcUrl)
 	g.Expect(err).To(gomega.BeNil())
 	httpProxy := httputil.NewSingleHostReverseProxy(predictorSvcUrl)
-	batchHandler := New(32, 50, httpProxy, logger)
+	batchHandler := New(32, 50, false, httpProxy, logger)
 	var wg sync.WaitGroup
 	for i := 0; i < 10; i++ {
 		wg.Add(1)
@@ -116,7 +116,7 @@ func TestBatcherFail(t *testing.T) {
 	logger.Infof("predictor url %s", predictorSvcUrl)
 	g.Expect(err).To(gomega.BeNil())
 	httpProxy := httputil.NewSingleHostReverseProxy(predictorSvcUrl)
-	batchHandler := New(32, 50, httpProxy, logger)
+	batchHandler := New(32, 50, false, httpProxy, logger)
 	var wg sync.WaitGroup
 	for i := 0; i < 10; i++ {
 		wg.Add(1)
@@ -158,7 +158,7 @@ func TestBatcherDefaults(t *testing.T) {
 	logger.Infof("predictor url %s", predictorSvcUrl)
 	g.Expect(err).To(gomega.BeNil())
 	httpProxy := httputil.NewSingleHostReverseProxy(predictorSvcUrl)
-	batchHandler := New(-1, -1, httpProxy, logger)
+	batchHandler := New(-1, -1, false, httpProxy, logger)
 	var wg sync.WaitGroup
 	for i := 0; i < 10; i++ {
 		wg.Add(1)
@@ -170,3 +170,103 @@ func TestBatcherDefaults(t *testing.T) {
 	g.Expect(batchHandler.MaxBatchSize).To(gomega.Equal(MaxBatchSize))
 	g.Expect(batchHandler.MaxLatency).To(gomega.Equal(MaxLatency))
 }
+
+// Tests the downstream status code, content type and error body are returned to every request of the batch
+func TestBatcherPreservesErrorResponse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	logger, _ := pkglogging.NewLogger("", "INFO")
+
+	errorBody := `{"error": "model is not ready"}`
+	predictor := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/problem+json")
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		_, err := rw.Write([]byte(errorBody))
+		g.Expect(err).To(gomega.BeNil())
+	}))
+	defer predictor.Close()
+	predictorSvcUrl, err := url.Parse(predictor.URL)
+	g.Expect(err).To(gomega.BeNil())
+	httpProxy := httputil.NewSingleHostReverseProxy(predictorSvcUrl)
+	batchHandler := New(2, 50, false, httpProxy, logger)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			instances := fmt.Sprintf("{\"instances\": [[%d, %d, %d]]}", index, index, index)
+			r := httptest.NewRequest("POST", "/v1/models/test:predict", bytes.NewReader([]byte(instances)))
+			w := httptest.NewRecorder()
+			batchHandler.ServeHTTP(w, r)
+
+			g.Expect(w.Code).To(gomega.Equal(http.StatusServiceUnavailable))
+			g.Expect(w.Header().Get("Content-Type")).To(gomega.Equal("application/problem+json"))
+			g.Expect(w.Body.String()).To(gomega.Equal(errorBody))
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Tests per-instance errors are split across the batch when partial failures are enabled
+func TestBatcherPartialFailure(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	logger, _ := pkglogging.NewLogger("", "INFO")
+
+	predictor := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		b, err := io.ReadAll(req.Body)
+		g.Expect(err).To(gomega.BeNil())
+		var request Request
+		err = json.Unmarshal(b, &request)
+		g.Expect(err).To(gomega.BeNil())
+		// instances with a negative value fail
+		response := PredictionResponse{}
+		for _, instance := range request.Instances {
+			if instance.([]interface{})[0].(float64) < 0 {
+				response.Predictions = append(response.Predictions, nil)
+				response.Errors = append(response.Errors, "negative input")
+			} else {
+				response.Predictions = append(response.Predictions, instance)
+				response.Errors = append(response.Errors, nil)
+			}
+		}
+		responseBytes, err := json.Marshal(response)
+		g.Expect(err).To(gomega.BeNil())
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadRequest)
+		_, err = rw.Write(responseBytes)
+		g.Expect(err).To(gomega.BeNil())
+	}))
+	defer predictor.Close()
+	predictorSvcUrl, err := url.Parse(predictor.URL)
+	g.Expect(err).To(gomega.BeNil())
+	httpProxy := httputil.NewSingleHostReverseProxy(predictorSvcUrl)
+	batchHandler := New(2, 50, true, httpProxy, logger)
+
+	var wg sync.WaitGroup
+	for _, index := range []int{1, -1} {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			instances := fmt.Sprintf("{\"instances\": [[%d, %d, %d]]}", index, index, index)
+			r := httptest.NewRequest("POST", "/v1/models/test:predict", bytes.NewReader([]byte(instances)))
+			w := httptest.NewRecorder()
+			batchHandler.ServeHTTP(w, r)
+
+			var res Response
+			g.Expect(json.Unmarshal(w.Body.Bytes(), &res)).To(gomega.Succeed())
+			g.Expect(res.Predictions).To(gomega.HaveLen(1))
+			g.Expect(res.Errors).To(gomega.HaveLen(1))
+			g.Expect(w.Header().Get("Content-Type")).To(gomega.Equal("application/json"))
+			if index < 0 {
+				g.Expect(w.Code).To(gomega.Equal(http.StatusBadRequest))
+				g.Expect(res.Errors[0]).To(gomega.Equal("negative input"))
+			} else {
+				g.Expect(w.Code).To(gomega.Equal(http.StatusOK))
+				g.Expect(res.Errors[0]).To(gomega.BeNil())
+			}
+		}(index)
+	}
+	wg.Wait()
+}
@@ -71,6 +71,14 @@ const (
 	AgentEnableFlag       = "--enable-puller"
 	AgentConfigDirArgName = "--config-dir"
 	AgentModelDirArgName  = "--model-dir"
+	// AgentStoragePluginsArgName passes the plugins of the custom storage protocols to the model agent
+	AgentStoragePluginsArgName = "--storage-plugins"
+	// AgentModelStatusPath is the path of the model agent endpoint reporting the state of the models
+	AgentModelStatusPath = "/v1/agent/models"
+	// AgentModelStatusPort is the port the model agent serves AgentModelStatusPath on. It is not the
+	// agent port, so that the endpoint is not exposed to the inference clients.
+	AgentModelStatusPort    = 9082
+	AgentModelStatusPortStr = "9082"
 )
 
 // InferenceService Annotations
@@ -94,8 +102,19 @@ var (
 	DefaultPrometheusPath                       = "/metrics"
 	QueueProxyAggregatePrometheusMetricsPort    = 9088
 	DefaultPodPrometheusPort                    = "9091"
+	// ModelPvcsAnnotationKey is the comma separated list of the PVCs mounted into the model agent and the
+	// model server of a multi-model InferenceService, from which the pvc:// TrainedModels are loaded
+	ModelPvcsAnnotationKey = KServeAPIGroupName + "/model-pvcs"
+	// ModelRepositoryAnnotationKey is the repository API the model agent of a multi-model InferenceService
+	// loads the models through, one of v2, torchserve, tfserving or file
+	ModelRepositoryAnnotationKey = KServeAPIGroupName + "/model-repository"
+	// ModelRepositoryEndpointAnnotationKey is the url of the repository API of the model server
+	ModelRepositoryEndpointAnnotationKey = KServeAPIGroupName + "/model-repository-endpoint"
 )
 
+// ModelRepositoryTypes are the values of the ModelRepositoryAnnotationKey annotation
+var ModelRepositoryTypes = []string{"v2", "torchserve", "tfserving", "file"}
+
 // InferenceService Internal Annotations
 var (
 	InferenceServiceInternalAnnotationsPrefix        = "internal." + KServeAPIGroupName
@@ -106,9 +125,13 @@ var (
 	LoggerInternalAnnotationKey                      = InferenceServiceInternalAnnotationsPrefix + "/logger"
 	LoggerSinkUrlInternalAnnotationKey               = InferenceServiceInternalAnnotationsPrefix + "/logger-sink-url"
 	LoggerModeInternalAnnotationKey                  = InferenceServiceInternalAnnotationsPrefix + "/logger-mode"
+	LoggerSinkTypeInternalAnnotationKey              = InferenceServiceInternalAnnotationsPrefix + "/logger-sink-type"
+	LoggerFilterInternalAnnotationKey                = InferenceServiceInternalAnnotationsPrefix + "/logger-filter"
+	LoggerRedactionInternalAnnotationKey             = InferenceServiceInternalAnnotationsPrefix + "/logger-redaction"
 	BatcherInternalAnnotationKey                     = InferenceServiceInternalAnnotationsPrefix + "/batcher"
 	BatcherMaxBatchSizeInternalAnnotationKey         = InferenceServiceInternalAnnotationsPrefix + "/batcher-max-batchsize"
 	BatcherMaxLatencyInternalAnnotationKey           = InferenceServiceInternalAnnotationsPrefix + "/batcher-max-latency"
+	BatcherPartialFailureInternalAnnotationKey       = InferenceServiceInternalAnnotationsPrefix + "/batcher-partial-failure"
 	AgentShouldInjectAnnotationKey                   = InferenceServiceInternalAnnotationsPrefix + "/agent"
 	AgentModelConfigVolumeNameAnnotationKey          = InferenceServiceInternalAnnotationsPrefix + "/configVolumeName"
 	AgentModelConfigMountPathAnnotationKey           = InferenceServiceInternalAnnotationsPrefix + "/configMountPath"
@@ -327,6 +350,19 @@ const (
 	ModelDirVolumeName    = "model-dir"
 	ModelConfigDir        = "/mnt/configs"
 	ModelDir              = DefaultModelLocalMountPath
+	// ModelPvcVolumeNamePrefix is the prefix of the volumes of the PVCs listed in the
+	// ModelPvcsAnnotationKey annotation, which are mounted read only by name under ModelPvcMountRoot
+	ModelPvcVolumeNamePrefix = "model-pvc-"
+	ModelPvcMountRoot        = "/mnt/pvc"
+	// OCIPullSecretVolumeNamePrefix is the prefix of the volumes of the image pull secrets of the pod,
+	// which are mounted by name under OCIPullSecretsMountRoot to pull the oci:// models
+	OCIPullSecretVolumeNamePrefix = "oci-pull-secret-"
+	OCIPullSecretsMountRoot       = "/mnt/oci-pull-secrets"
+)
+
+// Payload logger
+const (
+	LoggerSpoolVolumeName = "logger-spool"
 )
 
 var (
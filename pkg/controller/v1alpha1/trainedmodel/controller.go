@@ -22,6 +22,7 @@ limitations under the License.
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;update
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch;delete
 package trainedmodel
 
@@ -69,6 +70,13 @@ type TrainedModelReconciler struct {
 	Scheme                *runtime.Scheme
 	Recorder              record.EventRecorder
 	ModelConfigReconciler *modelconfig.ModelConfigReconciler
+	// ModelStatusClient gets the model state from the model agents, NewModelStatusClient is used if it is nil
+	ModelStatusClient ModelStatusClient
+	// ReportModelState enables polling the model agents for the model state. The controller must reach
+	// the predictor pods on constants.AgentModelStatusPort over plain HTTP, which NetworkPolicies or a
+	// strict mTLS service mesh may prevent. The ModelLoaded condition does not hold back the Ready
+	// condition if it is disabled.
+	ReportModelState bool
 }
 
 func (r *TrainedModelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -144,6 +152,12 @@ func (r *TrainedModelReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return reconcile.Result{}, err
 	}
 
+	// Check the model state reported by the model agents of the predictor
+	modelLoaded, err := r.updateModelState(ctx, req, tm)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
 	// update URL and Address fo TrainedModel
 	if err := r.updateStatus(req, tm); err != nil {
 		return ctrl.Result{}, err
@@ -153,6 +167,10 @@ func (r *TrainedModelReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	if err := r.ModelConfigReconciler.Reconcile(req, tm); err != nil {
 		return ctrl.Result{}, err
 	}
+	if !modelLoaded {
+		// The model agents are polled until the model is loaded
+		return ctrl.Result{RequeueAfter: ModelStateRequeueInterval}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
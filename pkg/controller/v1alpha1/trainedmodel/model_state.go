@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trainedmodel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/apis"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kserve/kserve/pkg/agent"
+	v1alpha1api "github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+	v1beta1api "github.com/kserve/kserve/pkg/apis/serving/v1beta1"
+	"github.com/kserve/kserve/pkg/constants"
+)
+
+const (
+	// ModelStateRequeueInterval is how often the model agents are polled until the model is loaded
+	ModelStateRequeueInterval = 10 * time.Second
+	modelStatusTimeout        = 5 * time.Second
+)
+
+// ModelStatusClient gets the state of a model from the model agent of a predictor pod.
+type ModelStatusClient interface {
+	// GetModelStatus returns nil if the model agent does not know the model.
+	GetModelStatus(ctx context.Context, pod *v1.Pod, modelName string) (*agent.ModelStatus, error)
+}
+
+type agentModelStatusClient struct {
+	client *http.Client
+}
+
+func NewModelStatusClient() ModelStatusClient {
+	return &agentModelStatusClient{client: &http.Client{Timeout: modelStatusTimeout}}
+}
+
+func (c *agentModelStatusClient) GetModelStatus(ctx context.Context, pod *v1.Pod, modelName string) (*agent.ModelStatus, error) {
+	statusUrl := fmt.Sprintf("http://%s%s/%s", net.JoinHostPort(pod.Status.PodIP, constants.AgentModelStatusPortStr),
+		constants.AgentModelStatusPath, url.PathEscape(modelName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		status := &agent.ModelStatus{}
+		if err := json.NewDecoder(resp.Body).Decode(status); err != nil {
+			return nil, err
+		}
+		return status, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("model agent responded with status %d", resp.StatusCode)
+	}
+}
+
+// updateModelState sets the model state and the ModelLoaded condition from the states reported by
+// the model agents of the running predictor pods. It returns true once the model is loaded on every pod,
+// or if the model state is not reported, see ReportModelState.
+func (r *TrainedModelReconciler) updateModelState(ctx context.Context, req ctrl.Request, tm *v1alpha1api.TrainedModel) (bool, error) {
+	if !r.ReportModelState {
+		tm.Status.ModelState = ""
+		tm.Status.SetCondition(v1alpha1api.ModelLoaded, &apis.Condition{
+			Status:  v1.ConditionTrue,
+			Reason:  "ModelStateNotReported",
+			Message: "Model state reporting is disabled on the controller",
+		})
+		return true, nil
+	}
+	isvc := &v1beta1api.InferenceService{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: tm.Spec.InferenceService}, isvc); err != nil {
+		return false, err
+	}
+	pods := &v1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(isvc.Namespace), client.MatchingLabels{
+		constants.InferenceServicePodLabelKey: isvc.Name,
+		constants.KServiceComponentLabel:      string(v1beta1api.PredictorComponent),
+	}); err != nil {
+		return false, err
+	}
+
+	statusClient := r.ModelStatusClient
+	if statusClient == nil {
+		statusClient = NewModelStatusClient()
+	}
+	statuses := make(map[string]*agent.ModelStatus)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != v1.PodRunning || pod.Status.PodIP == "" || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		status, err := statusClient.GetModelStatus(ctx, pod, tm.Name)
+		if err != nil {
+			log.Info("Failed to get the model state from the model agent", "TrainedModel", tm.Name, "Pod", pod.Name, "error", err.Error())
+		}
+		statuses[pod.Name] = status
+	}
+
+	state, condition := aggregateModelState(statuses)
+	tm.Status.ModelState = state
+	if resolved := resolvedModel(statuses); resolved != nil {
+		tm.Status.ResolvedStorageURI = resolved.ResolvedStorageURI
+		tm.Status.ResolvedVersion = resolved.ResolvedVersion
+	}
+	tm.Status.SetCondition(v1alpha1api.ModelLoaded, condition)
+	return state == v1alpha1api.Loaded, nil
+}
+
+// resolvedModel returns the status of the first pod, in name order, which reports the model version
+// the model registry uri of the model resolved to, or nil if none does.
+func resolvedModel(statuses map[string]*agent.ModelStatus) *agent.ModelStatus {
+	podNames := make([]string, 0, len(statuses))
+	for podName, status := range statuses {
+		if status != nil && status.ResolvedStorageURI != "" {
+			podNames = append(podNames, podName)
+		}
+	}
+	if len(podNames) == 0 {
+		return nil
+	}
+	sort.Strings(podNames)
+	return statuses[podNames[0]]
+}
+
+// modelStateOrder orders the states from the least to the most advanced
+var modelStateOrder = map[v1alpha1api.TrainedModelState]int{
+	v1alpha1api.Downloading: 0,
+	v1alpha1api.Loading:     1,
+	v1alpha1api.Loaded:      2,
+}
+
+// aggregateModelState returns the state of the model and its ModelLoaded condition given the
+// states reported by each pod, nil if the pod did not report the model. The model fails to load
+// if it fails on any pod, otherwise its state is the least advanced one, the pods which did not
+// report the model yet counting as downloading it.
+func aggregateModelState(statuses map[string]*agent.ModelStatus) (v1alpha1api.TrainedModelState, *apis.Condition) {
+	if len(statuses) == 0 {
+		return "", &apis.Condition{
+			Type:    v1alpha1api.ModelLoaded,
+			Status:  v1.ConditionUnknown,
+			Reason:  "NoPredictorPods",
+			Message: "No running predictor pod reports the state of the model",
+		}
+	}
+	podNames := make([]string, 0, len(statuses))
+	for podName := range statuses {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+
+	var failures []string
+	state, statePod := v1alpha1api.Loaded, ""
+	for _, podName := range podNames {
+		podState := v1alpha1api.Downloading
+		if status := statuses[podName]; status != nil {
+			if status.State == v1alpha1api.FailedToLoad {
+				failures = append(failures, fmt.Sprintf("%s: %s", podName, status.Error))
+				continue
+			}
+			podState = status.State
+		}
+		if order, ok := modelStateOrder[podState]; ok && order < modelStateOrder[state] {
+			state, statePod = podState, podName
+		}
+	}
+	switch {
+	case len(failures) > 0:
+		return v1alpha1api.FailedToLoad, &apis.Condition{
+			Type:    v1alpha1api.ModelLoaded,
+			Status:  v1.ConditionFalse,
+			Reason:  "ModelLoadFailed",
+			Message: "Model failed to load on " + strings.Join(failures, "; "),
+		}
+	case state == v1alpha1api.Loaded:
+		return state, &apis.Condition{
+			Status: v1.ConditionTrue,
+		}
+	default:
+		return state, &apis.Condition{
+			Type:    v1alpha1api.ModelLoaded,
+			Status:  v1.ConditionUnknown,
+			Reason:  "ModelLoading",
+			Message: fmt.Sprintf("Model is in state %s on %s", state, statePod),
+		}
+	}
+}
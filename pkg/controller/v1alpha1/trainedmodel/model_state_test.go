@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trainedmodel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kserve/kserve/pkg/agent"
+	v1alpha1api "github.com/kserve/kserve/pkg/apis/serving/v1alpha1"
+	v1beta1api "github.com/kserve/kserve/pkg/apis/serving/v1beta1"
+	"github.com/kserve/kserve/pkg/constants"
+)
+
+func TestAggregateModelState(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	scenarios := map[string]struct {
+		statuses        map[string]*agent.ModelStatus
+		expectedState   v1alpha1api.TrainedModelState
+		expectedStatus  v1.ConditionStatus
+		expectedMessage string
+	}{
+		"NoPods": {
+			statuses:       map[string]*agent.ModelStatus{},
+			expectedState:  "",
+			expectedStatus: v1.ConditionUnknown,
+		},
+		"AllLoaded": {
+			statuses: map[string]*agent.ModelStatus{
+				"pod-1": {State: v1alpha1api.Loaded},
+				"pod-2": {State: v1alpha1api.Loaded},
+			},
+			expectedState:  v1alpha1api.Loaded,
+			expectedStatus: v1.ConditionTrue,
+		},
+		"LeastAdvancedState": {
+			statuses: map[string]*agent.ModelStatus{
+				"pod-1": {State: v1alpha1api.Loaded},
+				"pod-2": {State: v1alpha1api.Loading},
+			},
+			expectedState:   v1alpha1api.Loading,
+			expectedStatus:  v1.ConditionUnknown,
+			expectedMessage: "Model is in state Loading on pod-2",
+		},
+		"NotReported": {
+			statuses: map[string]*agent.ModelStatus{
+				"pod-1": {State: v1alpha1api.Loaded},
+				"pod-2": nil,
+			},
+			expectedState:   v1alpha1api.Downloading,
+			expectedStatus:  v1.ConditionUnknown,
+			expectedMessage: "Model is in state Downloading on pod-2",
+		},
+		"FailedToLoad": {
+			statuses: map[string]*agent.ModelStatus{
+				"pod-1": {State: v1alpha1api.Loading},
+				"pod-2": {State: v1alpha1api.FailedToLoad, Error: "out of memory"},
+			},
+			expectedState:   v1alpha1api.FailedToLoad,
+			expectedStatus:  v1.ConditionFalse,
+			expectedMessage: "Model failed to load on pod-2: out of memory",
+		},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			state, condition := aggregateModelState(scenario.statuses)
+			g.Expect(state).To(gomega.Equal(scenario.expectedState))
+			g.Expect(condition.Status).To(gomega.Equal(scenario.expectedStatus))
+			if scenario.expectedMessage != "" {
+				g.Expect(condition.Message).To(gomega.Equal(scenario.expectedMessage))
+			}
+		})
+	}
+}
+
+// stubModelStatusClient returns the statuses of the model by pod name.
+type stubModelStatusClient map[string]*agent.ModelStatus
+
+func (s stubModelStatusClient) GetModelStatus(_ context.Context, pod *v1.Pod, _ string) (*agent.ModelStatus, error) {
+	status, ok := s[pod.Name]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	return status, nil
+}
+
+func TestUpdateModelState(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	s := runtime.NewScheme()
+	g.Expect(v1.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(v1alpha1api.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(v1beta1api.AddToScheme(s)).To(gomega.Succeed())
+
+	predictorPod := func(name string, phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					constants.InferenceServicePodLabelKey: "parent",
+					constants.KServiceComponentLabel:      string(v1beta1api.PredictorComponent),
+				},
+			},
+			Status: v1.PodStatus{Phase: phase, PodIP: "10.0.0.1"},
+		}
+	}
+	isvc := &v1beta1api.InferenceService{ObjectMeta: metav1.ObjectMeta{Name: "parent", Namespace: "default"}}
+	tm := &v1alpha1api.TrainedModel{
+		ObjectMeta: metav1.ObjectMeta{Name: "model", Namespace: "default"},
+		Spec:       v1alpha1api.TrainedModelSpec{InferenceService: "parent"},
+	}
+
+	scenarios := map[string]struct {
+		statuses                   stubModelStatusClient
+		expectedState              v1alpha1api.TrainedModelState
+		expectedLoaded             bool
+		expectedResolvedStorageURI string
+		expectedResolvedVersion    string
+	}{
+		"Loaded": {
+			statuses: stubModelStatusClient{
+				"predictor-1": {State: v1alpha1api.Loaded},
+			},
+			expectedState:  v1alpha1api.Loaded,
+			expectedLoaded: true,
+		},
+		"LoadedFromModelRegistry": {
+			statuses: stubModelStatusClient{
+				"predictor-1": {
+					State:              v1alpha1api.Loaded,
+					ResolvedStorageURI: "s3://mlflow/1/abc/artifacts/model",
+					ResolvedVersion:    "3",
+				},
+			},
+			expectedState:              v1alpha1api.Loaded,
+			expectedLoaded:             true,
+			expectedResolvedStorageURI: "s3://mlflow/1/abc/artifacts/model",
+			expectedResolvedVersion:    "3",
+		},
+		"Unreachable": {
+			statuses:       stubModelStatusClient{},
+			expectedState:  v1alpha1api.Downloading,
+			expectedLoaded: false,
+		},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			r := &TrainedModelReconciler{
+				// the pending pod is not polled
+				Client: fake.NewClientBuilder().WithScheme(s).WithObjects(isvc,
+					predictorPod("predictor-1", v1.PodRunning), predictorPod("predictor-2", v1.PodPending)).Build(),
+				ModelStatusClient: scenario.statuses,
+				ReportModelState:  true,
+			}
+			model := tm.DeepCopy()
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "model"}}
+			loaded, err := r.updateModelState(context.TODO(), req, model)
+			g.Expect(err).To(gomega.BeNil())
+			g.Expect(loaded).To(gomega.Equal(scenario.expectedLoaded))
+			g.Expect(model.Status.ModelState).To(gomega.Equal(scenario.expectedState))
+			g.Expect(model.Status.IsConditionReady(v1alpha1api.ModelLoaded)).To(gomega.Equal(scenario.expectedLoaded))
+			g.Expect(model.Status.ResolvedStorageURI).To(gomega.Equal(scenario.expectedResolvedStorageURI))
+			g.Expect(model.Status.ResolvedVersion).To(gomega.Equal(scenario.expectedResolvedVersion))
+		})
+	}
+}
+
+func TestUpdateModelStateNotReported(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	// the model agents are not polled
+	r := &TrainedModelReconciler{ModelStatusClient: stubModelStatusClient{}}
+	model := &v1alpha1api.TrainedModel{
+		ObjectMeta: metav1.ObjectMeta{Name: "model", Namespace: "default"},
+		Spec:       v1alpha1api.TrainedModelSpec{InferenceService: "parent"},
+		Status:     v1alpha1api.TrainedModelStatus{ModelState: v1alpha1api.Loading},
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "model"}}
+	loaded, err := r.updateModelState(context.TODO(), req, model)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(loaded).To(gomega.BeTrue())
+	g.Expect(model.Status.ModelState).To(gomega.BeEmpty())
+	condition := model.Status.GetCondition(v1alpha1api.ModelLoaded)
+	g.Expect(condition.Status).To(gomega.Equal(v1.ConditionTrue))
+	g.Expect(condition.Reason).To(gomega.Equal("ModelStateNotReported"))
+}
@@ -63,6 +63,19 @@ func addLoggerAnnotations(logger *v1beta1.LoggerSpec, annotations map[string]str
 			annotations[constants.LoggerSinkUrlInternalAnnotationKey] = *logger.URL
 		}
 		annotations[constants.LoggerModeInternalAnnotationKey] = string(logger.Mode)
+		if logger.Sink != "" {
+			annotations[constants.LoggerSinkTypeInternalAnnotationKey] = string(logger.Sink)
+		}
+		if logger.Filter != nil {
+			if jsonFilter, err := json.Marshal(logger.Filter); err == nil {
+				annotations[constants.LoggerFilterInternalAnnotationKey] = string(jsonFilter)
+			}
+		}
+		if logger.Redaction != nil {
+			if jsonRedaction, err := json.Marshal(logger.Redaction); err == nil {
+				annotations[constants.LoggerRedactionInternalAnnotationKey] = string(jsonRedaction)
+			}
+		}
 	}
 }
 
@@ -78,6 +91,9 @@ func addBatcherAnnotations(batcher *v1beta1.Batcher, annotations map[string]stri
 			s := strconv.Itoa(*batcher.MaxLatency)
 			annotations[constants.BatcherMaxLatencyInternalAnnotationKey] = s
 		}
+		if batcher.PartialFailure != nil {
+			annotations[constants.BatcherPartialFailureInternalAnnotationKey] = strconv.FormatBool(*batcher.PartialFailure)
+		}
 	}
 }
 
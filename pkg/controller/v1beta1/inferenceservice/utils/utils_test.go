@@ -1745,6 +1745,8 @@ func TestValidateStorageURIForDefaultStorageInitializer(t *testing.T) {
 		"http://raw.githubusercontent.com/someOrg/someRepo/model.tar.gz",
 		"hdfs://",
 		"webhdfs://",
+		"mlflow://fraud-detector/Production",
+		"models:/fraud-detector/Production",
 		"some/relative/path",
 		"/",
 		"foo",
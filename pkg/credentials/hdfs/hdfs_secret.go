@@ -28,6 +28,9 @@ const (
 	TlsCert           = "TLS_CERT"
 	TlsKey            = "TLS_KEY"
 	TlsCa             = "TLS_CA"
+	TlsSkipVerify     = "TLS_SKIP_VERIFY"
+	UserProxy         = "USER_PROXY"
+	Headers           = "HEADERS"
 	MountPath         = "/var/secrets/kserve-hdfscreds"
 	HdfsVolumeName    = "hdfs-secrets"
 )
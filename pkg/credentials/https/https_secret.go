@@ -17,7 +17,12 @@ limitations under the License.
 package https
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // Create constants -- baseURI
@@ -25,6 +30,9 @@ const (
 	HTTPSHost = "https-host"
 	HEADERS   = "headers"
 	NEWLINE   = "\n"
+	// MountPath is the directory the HTTP(S) secrets are mounted into by name
+	MountPath        = "/var/secrets/kserve-https"
+	VolumeNamePrefix = "https-secret-"
 )
 
 var (
@@ -54,3 +62,28 @@ func BuildSecretEnvs(secret *v1.Secret) []v1.EnvVar {
 
 	return envs
 }
+
+// BuildSecretVolume mounts the secret read only at MountPath/<secret name>, so that the headers of
+// several hosts can be read from their secrets.
+func BuildSecretVolume(secret *v1.Secret) (v1.Volume, v1.VolumeMount) {
+	volumeName := VolumeNamePrefix + secret.Name
+	if len(validation.IsDNS1123Label(volumeName)) != 0 {
+		// the secret names are subdomains, which may be longer than volume names or have dots
+		sum := sha256.Sum256([]byte(secret.Name))
+		volumeName = VolumeNamePrefix + hex.EncodeToString(sum[:])[:16]
+	}
+	volume := v1.Volume{
+		Name: volumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName: secret.Name,
+			},
+		},
+	}
+	volumeMount := v1.VolumeMount{
+		Name:      volumeName,
+		MountPath: path.Join(MountPath, secret.Name),
+		ReadOnly:  true,
+	}
+	return volume, volumeMount
+}
@@ -79,3 +79,40 @@ func TestHTTPSSecret(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPSSecretVolume(t *testing.T) {
+	scenarios := map[string]struct {
+		secretName string
+		volumeName string
+	}{
+		"secretName": {
+			secretName: "model-headers",
+			volumeName: VolumeNamePrefix + "model-headers",
+		},
+		"subdomainSecretName": {
+			secretName: "model.headers",
+			volumeName: VolumeNamePrefix + "b3f53213dedcbd4b",
+		},
+	}
+
+	for name, scenario := range scenarios {
+		volume, volumeMount := BuildSecretVolume(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: scenario.secretName}})
+		expectedVolume := v1.Volume{
+			Name: scenario.volumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: scenario.secretName},
+			},
+		}
+		expectedVolumeMount := v1.VolumeMount{
+			Name:      scenario.volumeName,
+			MountPath: MountPath + "/" + scenario.secretName,
+			ReadOnly:  true,
+		}
+		if diff := cmp.Diff(expectedVolume, volume); diff != "" {
+			t.Errorf("Test %q unexpected volume (-want +got): %v", name, diff)
+		}
+		if diff := cmp.Diff(expectedVolumeMount, volumeMount); diff != "" {
+			t.Errorf("Test %q unexpected volume mount (-want +got): %v", name, diff)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mlflow
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// Conforms to the environment variables of the MLflow client
+const (
+	MLflowTrackingURI      = "MLFLOW_TRACKING_URI"
+	MLflowTrackingToken    = "MLFLOW_TRACKING_TOKEN"    // #nosec G101
+	MLflowTrackingUsername = "MLFLOW_TRACKING_USERNAME" // #nosec G101
+	MLflowTrackingPassword = "MLFLOW_TRACKING_PASSWORD" // #nosec G101
+)
+
+var MLflowEnvKeys = []string{MLflowTrackingURI, MLflowTrackingToken, MLflowTrackingUsername, MLflowTrackingPassword}
+
+// BuildSecretEnvs returns the envs of the MLflow tracking server the model registry uris are
+// resolved against, leaving out the credentials which are not defined in the secret.
+func BuildSecretEnvs(secret *v1.Secret) []v1.EnvVar {
+	envs := make([]v1.EnvVar, 0, len(MLflowEnvKeys))
+	for _, k := range MLflowEnvKeys {
+		if _, ok := secret.Data[k]; !ok {
+			continue
+		}
+		envs = append(envs, v1.EnvVar{
+			Name: k,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: secret.Name,
+					},
+					Key: k,
+				},
+			},
+		})
+	}
+	return envs
+}
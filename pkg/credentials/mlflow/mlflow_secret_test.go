@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mlflow
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func secretEnv(name string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: "mlflow-secret",
+				},
+				Key: name,
+			},
+		},
+	}
+}
+
+func TestMLflowSecret(t *testing.T) {
+	scenarios := map[string]struct {
+		secret   *v1.Secret
+		expected []v1.EnvVar
+	}{
+		"TokenSecret": {
+			secret: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "mlflow-secret",
+				},
+				Data: map[string][]byte{
+					MLflowTrackingURI:   []byte("https://mlflow.example.com"),
+					MLflowTrackingToken: []byte("token"),
+				},
+			},
+			expected: []v1.EnvVar{secretEnv(MLflowTrackingURI), secretEnv(MLflowTrackingToken)},
+		},
+		"BasicAuthSecret": {
+			secret: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "mlflow-secret",
+				},
+				Data: map[string][]byte{
+					MLflowTrackingURI:      []byte("https://mlflow.example.com"),
+					MLflowTrackingUsername: []byte("user"),
+					MLflowTrackingPassword: []byte("password"),
+				},
+			},
+			expected: []v1.EnvVar{secretEnv(MLflowTrackingURI), secretEnv(MLflowTrackingUsername), secretEnv(MLflowTrackingPassword)},
+		},
+	}
+
+	for name, scenario := range scenarios {
+		envs := BuildSecretEnvs(scenario.secret)
+		if diff := cmp.Diff(scenario.expected, envs); diff != "" {
+			t.Errorf("Test %q unexpected result (-want +got): %v", name, diff)
+		}
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/kserve/kserve/pkg/credentials/gcs"
 	"github.com/kserve/kserve/pkg/credentials/hdfs"
 	"github.com/kserve/kserve/pkg/credentials/https"
+	"github.com/kserve/kserve/pkg/credentials/mlflow"
 	"github.com/kserve/kserve/pkg/credentials/s3"
 	"github.com/kserve/kserve/pkg/utils"
 )
@@ -287,8 +288,18 @@ func (c *CredentialBuilder) mountSecretCredential(secretName string, namespace s
 		container.Env = append(container.Env, envs...)
 	} else if _, ok := secret.Data[https.HTTPSHost]; ok {
 		log.Info("Setting secret volume from uri", "HTTP(S)Secret", secret.Name)
+		// the envs are kept for the storage initializer, only the model agent reads the mounted secret
 		envs := https.BuildSecretEnvs(secret)
 		container.Env = append(container.Env, envs...)
+		if container.Name == constants.AgentContainerName {
+			volume, volumeMount := https.BuildSecretVolume(secret)
+			*volumes = utils.AppendVolumeIfNotExists(*volumes, volume)
+			container.VolumeMounts = append(container.VolumeMounts, volumeMount)
+		}
+	} else if _, ok := secret.Data[mlflow.MLflowTrackingURI]; ok {
+		log.Info("Setting secret envs for the mlflow model registry", "MLflowSecret", secret.Name)
+		envs := mlflow.BuildSecretEnvs(secret)
+		container.Env = append(container.Env, envs...)
 	} else if _, ok := secret.Data[hdfs.HdfsNamenode]; ok {
 		log.Info("Setting secret for hdfs", "HdfsSecret", secret.Name)
 		volume, volumeMount := hdfs.BuildSecret(secret)
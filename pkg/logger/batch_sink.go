@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+)
+
+const CloudEventsBatchContentType = "application/cloudevents-batch+json"
+
+// BatchSink buffers the payloads and posts them as a JSON array of structured CloudEvents, once
+// BatchSize events are buffered or the oldest buffered event has waited for BatchInterval.
+type BatchSink struct {
+	Url           *url.URL
+	BatchSize     int
+	BatchInterval time.Duration
+	Client        *http.Client
+	log           *zap.SugaredLogger
+	mu            sync.Mutex
+	requests      []LogRequest
+	onFailure     BatchFailureHandler
+	quit          chan struct{}
+	done          chan struct{}
+}
+
+// BatchFailureHandler takes over the requests of a batch which failed to post.
+type BatchFailureHandler func(ctx context.Context, requests []LogRequest, err error)
+
+var _ Sink = (*BatchSink)(nil)
+
+func NewBatchSink(logUrl *url.URL, batchSize int, batchInterval time.Duration, logger *zap.SugaredLogger) *BatchSink {
+	if batchInterval <= 0 {
+		batchInterval = DefaultSinkOptions.BatchInterval
+	}
+	s := &BatchSink{
+		Url:           logUrl,
+		BatchSize:     batchSize,
+		BatchInterval: batchInterval,
+		Client:        &http.Client{},
+		log:           logger,
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go s.flushLoop()
+	return s
+}
+
+func (s *BatchSink) flushLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.BatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(context.Background()); err != nil {
+				s.log.Errorf("Failed to post event batch: %v", err)
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// HandleFailures hands the requests of the batches which fail to post to handler, whichever flush
+// failed. Without a handler the error is returned by Send and Flush, and the requests are dropped.
+func (s *BatchSink) HandleFailures(handler BatchFailureHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onFailure = handler
+}
+
+func (s *BatchSink) Send(ctx context.Context, logReq LogRequest) error {
+	if _, err := createEvent(logReq); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, logReq)
+	full := len(s.requests) >= s.BatchSize
+	s.mu.Unlock()
+	if full {
+		return s.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush posts the buffered events.
+func (s *BatchSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	requests := s.requests
+	s.requests = nil
+	onFailure := s.onFailure
+	s.mu.Unlock()
+	if len(requests) == 0 {
+		return nil
+	}
+	err := s.Post(ctx, requests)
+	if err != nil && onFailure != nil {
+		onFailure(ctx, requests, err)
+		return nil
+	}
+	return err
+}
+
+// Post posts the requests as a single batch.
+func (s *BatchSink) Post(ctx context.Context, requests []LogRequest) error {
+	events := make([]cloudevents.Event, 0, len(requests))
+	for _, logReq := range requests {
+		event, err := createEvent(logReq)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("while encoding event batch: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Url.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("while creating batch request: %w", err)
+	}
+	req.Header.Set("Content-Type", CloudEventsBatchContentType)
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("while posting %d events: %w", len(events), err)
+	}
+	defer func(Body io.ReadCloser) {
+		closeErr := Body.Close()
+		if closeErr != nil {
+			s.log.Error(closeErr, "failed to close body")
+		}
+	}(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("posting %d events returned a %d response code", len(events), resp.StatusCode)
+	}
+	return nil
+}
+
+// Close stops the flush loop and posts the remaining events.
+func (s *BatchSink) Close() error {
+	close(s.quit)
+	<-s.done
+	return s.Flush(context.Background())
+}
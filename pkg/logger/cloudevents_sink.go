@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// CloudEventsSink posts each payload as a CloudEvent to the url of the log request.
+type CloudEventsSink struct {
+	// Structured sends the events in structured mode instead of binary mode
+	Structured bool
+}
+
+var _ Sink = (*CloudEventsSink)(nil)
+
+func (s *CloudEventsSink) Send(ctx context.Context, logReq LogRequest) error {
+	t, err := cloudevents.NewHTTP(
+		cloudevents.WithTarget(logReq.Url.String()),
+	)
+	if err != nil {
+		return fmt.Errorf("while creating http transport: %w", err)
+	}
+	c, err := cloudevents.NewClient(t,
+		cloudevents.WithTimeNow(),
+	)
+	if err != nil {
+		return fmt.Errorf("while creating new cloudevents client: %w", err)
+	}
+	event, err := createEvent(logReq)
+	if err != nil {
+		return err
+	}
+	if s.Structured {
+		ctx = cloudevents.WithEncodingStructured(ctx)
+	} else {
+		ctx = cloudevents.WithEncodingBinary(ctx)
+	}
+	if result := c.Send(ctx, event); cloudevents.IsUndelivered(result) {
+		return fmt.Errorf("while sending event: %w", result)
+	}
+	return nil
+}
+
+func (s *CloudEventsSink) Close() error {
+	return nil
+}
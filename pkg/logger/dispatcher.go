@@ -17,33 +17,184 @@ limitations under the License.
 package logger
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
 	"go.uber.org/zap"
 )
 
 var WorkerQueue chan chan LogRequest
 
-func StartDispatcher(nworkers int, logger *zap.SugaredLogger) {
+// DropReportInterval is how often the log requests dropped since the previous report are logged.
+var DropReportInterval = time.Minute
+
+// Dispatcher hands the log requests queued on WorkQueue to its workers until it is shut down.
+type Dispatcher struct {
+	log         *zap.SugaredLogger
+	sink        Sink
+	workQueue   chan LogRequest
+	workerQueue chan chan LogRequest
+	workers     []Worker
+	// ctx is cancelled to abort the sends in progress once the shutdown deadline is exceeded
+	ctx      context.Context
+	cancel   context.CancelFunc
+	shutdown chan struct{}
+	drained  chan struct{}
+	// reported holds the drop counters as of the previous report
+	reported       dropCounts
+	reportInterval time.Duration
+	shutdownOnce   sync.Once
+	shutdownErr    error
+}
+
+type dropCounts struct {
+	overflow uint64
+	failure  uint64
+}
+
+func StartDispatcher(nworkers int, sink Sink, logger *zap.SugaredLogger) *Dispatcher {
 	// First, initialize the channel we are going to but the workers' work channels into.
 	WorkerQueue = make(chan chan LogRequest, nworkers)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		log:            logger,
+		sink:           sink,
+		workQueue:      WorkQueue,
+		workerQueue:    WorkerQueue,
+		ctx:            ctx,
+		cancel:         cancel,
+		shutdown:       make(chan struct{}),
+		drained:        make(chan struct{}),
+		reported:       dropCounts{overflow: DroppedOnOverflow(), failure: DroppedOnFailure()},
+		reportInterval: DropReportInterval,
+	}
 	// Now, create all of our workers.
 	for i := 0; i < nworkers; i++ {
 		logger.Info("Starting worker ", i+1)
-		worker := NewWorker(i+1, WorkerQueue, logger)
+		worker := NewWorker(ctx, i+1, WorkerQueue, sink, logger)
 		worker.Start()
+		d.workers = append(d.workers, worker)
+	}
+	go d.dispatch()
+	go d.reportDrops()
+	return d
+}
+
+// reportDrops logs the log requests dropped every DropReportInterval until the dispatcher is shut
+// down, Shutdown reports the total.
+func (d *Dispatcher) reportDrops() {
+	ticker := time.NewTicker(d.reportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			current := dropCounts{overflow: DroppedOnOverflow(), failure: DroppedOnFailure()}
+			if current != d.reported {
+				d.log.Warnf("Dropped %d log requests on queue overflow and %d on delivery failure in the last %v",
+					current.overflow-d.reported.overflow, current.failure-d.reported.failure, d.reportInterval)
+				d.reported = current
+			}
+		case <-d.shutdown:
+			return
+		}
 	}
+}
 
-	// nolint: gosimple
-	go func() {
-		for {
+func (d *Dispatcher) dispatch() {
+	defer close(d.drained)
+	// Wait for an idle worker before taking work off the queue so requests stay buffered in
+	// WorkQueue, where the overflow policy applies, while all workers are busy.
+	for {
+		var worker chan LogRequest
+		select {
+		case worker = <-d.workerQueue:
+		case <-d.shutdown:
+			d.drain()
+			return
+		}
+		select {
+		case work := <-d.workQueue:
+			worker <- work
+		case <-d.shutdown:
+			// the worker is still idle, give it back for the drain
+			d.workerQueue <- worker
+			d.drain()
+			return
+		}
+	}
+}
+
+// drain hands the requests left in the work queue to the workers until it is empty or the shutdown
+// deadline is exceeded.
+func (d *Dispatcher) drain() {
+	for {
+		var work LogRequest
+		select {
+		case work = <-d.workQueue:
+		default:
+			return
+		}
+		select {
+		case worker := <-d.workerQueue:
 			select {
-			case work := <-WorkQueue:
-				go func() {
-					worker := <-WorkerQueue
+			case worker <- work:
+				continue
+			case <-d.ctx.Done():
+			}
+		case <-d.ctx.Done():
+		}
+		droppedFailed.Add(1)
+		return
+	}
+}
 
-					worker <- work
-				}()
+// Shutdown stops taking new requests off WorkQueue once the queued requests are delivered, then
+// stops the workers and closes the sink. The log requests which are still queued or in progress
+// when ctx is done are dropped. Log requests must not be queued once Shutdown is called. Later
+// calls wait for the first one and return its error.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.shutdownOnce.Do(func() {
+		d.shutdownErr = d.stop(ctx)
+	})
+	return d.shutdownErr
+}
+
+func (d *Dispatcher) stop(ctx context.Context) error {
+	close(d.shutdown)
+	var err error
+	select {
+	case <-d.drained:
+	case <-ctx.Done():
+		d.cancel()
+		err = fmt.Errorf("while draining the log queue: %w", ctx.Err())
+	}
+	for i := range d.workers {
+		d.workers[i].Stop()
+	}
+	for _, worker := range d.workers {
+		select {
+		case <-worker.stopped:
+		case <-ctx.Done():
+			d.cancel()
+			if err == nil {
+				err = fmt.Errorf("while waiting for the log workers: %w", ctx.Err())
 			}
 		}
-	}()
+	}
+	if dropped := len(d.workQueue); err != nil && dropped > 0 {
+		droppedFailed.Add(uint64(dropped))
+		d.log.Warnf("Dropped %d queued log requests on shutdown", dropped)
+	}
+	d.cancel()
+	if closeErr := d.sink.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if overflow, failure := DroppedOnOverflow(), DroppedOnFailure(); overflow > 0 || failure > 0 {
+		d.log.Warnf("Dropped %d log requests on queue overflow and %d on delivery failure since the agent started",
+			overflow, failure)
+	}
+	return err
 }
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	pkglogging "knative.dev/pkg/logging"
+)
+
+// blockingSink blocks every send until its context is done.
+type blockingSink struct {
+	mu     sync.Mutex
+	closed bool
+}
+
+func (b *blockingSink) Send(ctx context.Context, _ LogRequest) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b *blockingSink) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
+
+func (b *blockingSink) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+func TestDispatcherShutdown(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	logger, _ := pkglogging.NewLogger("", "INFO")
+	defer func(queue chan LogRequest) {
+		WorkQueue = queue
+	}(WorkQueue)
+
+	// The queued requests are delivered before the dispatcher stops
+	WorkQueue = make(chan LogRequest, 10)
+	sink := &flakySink{}
+	dispatcher := StartDispatcher(2, sink, logger)
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		g.Expect(QueueLogRequest(newTestLogRequest(id, "{}"))).To(gomega.Succeed())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	g.Expect(dispatcher.Shutdown(ctx)).To(gomega.Succeed())
+	g.Expect(sink.sentIds()).To(gomega.ConsistOf("1", "2", "3", "4", "5"))
+	g.Expect(WorkQueue).To(gomega.BeEmpty())
+	// Shutting down again does nothing
+	g.Expect(dispatcher.Shutdown(ctx)).To(gomega.Succeed())
+}
+
+func TestDispatcherShutdownDeadline(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	logger, _ := pkglogging.NewLogger("", "INFO")
+	defer func(queue chan LogRequest) {
+		WorkQueue = queue
+	}(WorkQueue)
+
+	// The sends in progress are cancelled and the rest of the queue is dropped once the deadline
+	// is exceeded
+	WorkQueue = make(chan LogRequest, 10)
+	sink := &blockingSink{}
+	dispatcher := StartDispatcher(1, sink, logger)
+	for _, id := range []string{"1", "2", "3"} {
+		g.Expect(QueueLogRequest(newTestLogRequest(id, "{}"))).To(gomega.Succeed())
+	}
+	dropped := DroppedOnFailure()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	g.Expect(dispatcher.Shutdown(ctx)).To(gomega.MatchError(context.DeadlineExceeded))
+	g.Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second))
+	g.Expect(sink.isClosed()).To(gomega.BeTrue())
+	g.Expect(DroppedOnFailure()).To(gomega.BeNumerically(">", dropped))
+}
+
+func TestDispatcherReportDrops(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	core, logs := observer.New(zap.WarnLevel)
+	logger := zap.New(core).Sugar()
+	defer func(queue chan LogRequest, interval time.Duration) {
+		WorkQueue = queue
+		DropReportInterval = interval
+	}(WorkQueue, DropReportInterval)
+
+	// The requests which fail to be delivered are reported periodically and on shutdown
+	WorkQueue = make(chan LogRequest, 10)
+	DropReportInterval = 10 * time.Millisecond
+	dispatcher := StartDispatcher(1, &flakySink{failures: -1}, logger)
+	g.Expect(QueueLogRequest(newTestLogRequest("1", "{}"))).To(gomega.Succeed())
+	g.Eventually(func() int {
+		return logs.FilterMessageSnippet("on delivery failure in the last").Len()
+	}).Should(gomega.BeNumerically(">=", 1))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	g.Expect(dispatcher.Shutdown(ctx)).To(gomega.Succeed())
+	g.Expect(logs.FilterMessageSnippet("since the agent started").Len()).To(gomega.Equal(1))
+}
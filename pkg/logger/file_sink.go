@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileSink appends each payload as a structured CloudEvent to a JSON lines file. The file is rotated
+// once it grows past MaxSize, keeping at most MaxBackups rotated files named <path>.1 ... <path>.N.
+type FileSink struct {
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+	mu         sync.Mutex
+	file       *os.File
+	size       int64
+}
+
+var _ Sink = (*FileSink)(nil)
+
+func NewFileSink(path string, maxSize int64, maxBackups int) (*FileSink, error) {
+	s := &FileSink{
+		Path:       filepath.Clean(path),
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return fmt.Errorf("while creating log directory: %w", err)
+	}
+	file, err := os.OpenFile(s.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("while opening log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("while opening log file: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("while closing log file: %w", err)
+	}
+	if s.MaxBackups > 0 {
+		for i := s.MaxBackups - 1; i > 0; i-- {
+			backup := fmt.Sprintf("%s.%d", s.Path, i)
+			if _, err := os.Stat(backup); err == nil {
+				if err := os.Rename(backup, fmt.Sprintf("%s.%d", s.Path, i+1)); err != nil {
+					return fmt.Errorf("while rotating log file: %w", err)
+				}
+			}
+		}
+		if err := os.Rename(s.Path, s.Path+".1"); err != nil {
+			return fmt.Errorf("while rotating log file: %w", err)
+		}
+	} else if err := os.Remove(s.Path); err != nil {
+		return fmt.Errorf("while rotating log file: %w", err)
+	}
+	return s.open()
+}
+
+func (s *FileSink) Send(_ context.Context, logReq LogRequest) error {
+	event, err := createEvent(logReq)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("while encoding event: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.MaxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.MaxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("while writing event: %w", err)
+	}
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
+)
+
+// Filter decides which requests are logged, see v1beta1.LoggerFilterSpec.
+type Filter struct {
+	SamplingPercentage int32
+	Rules              []FilterRule
+}
+
+type FilterRule struct {
+	Path               *regexp.Regexp
+	StatusCodes        []string
+	Headers            map[string]*regexp.Regexp
+	JSONFieldPath      []string
+	JSONFieldValue     *regexp.Regexp
+	SamplingPercentage int32
+}
+
+// Decision is the outcome of matching a request against the filter.
+type Decision struct {
+	// Sampled is true if the request and its response are logged
+	Sampled bool
+	// LogErrors is true if the response is logged even if its status code is not 200
+	LogErrors bool
+}
+
+func NewFilter(spec *v1beta1.LoggerFilterSpec) (*Filter, error) {
+	filter := &Filter{SamplingPercentage: samplingPercentage(spec.SamplingPercentage)}
+	for _, ruleSpec := range spec.Rules {
+		rule := FilterRule{
+			StatusCodes:        ruleSpec.StatusCodes,
+			SamplingPercentage: samplingPercentage(ruleSpec.SamplingPercentage),
+		}
+		var err error
+		if ruleSpec.Path != "" {
+			if rule.Path, err = regexp.Compile(ruleSpec.Path); err != nil {
+				return nil, fmt.Errorf("invalid path regular expression %q: %w", ruleSpec.Path, err)
+			}
+		}
+		if len(ruleSpec.Headers) > 0 {
+			rule.Headers = make(map[string]*regexp.Regexp, len(ruleSpec.Headers))
+			for header, value := range ruleSpec.Headers {
+				if rule.Headers[header], err = regexp.Compile(value); err != nil {
+					return nil, fmt.Errorf("invalid header %s regular expression %q: %w", header, value, err)
+				}
+			}
+		}
+		if ruleSpec.JSONField != nil {
+			rule.JSONFieldPath = strings.Split(ruleSpec.JSONField.Path, ".")
+			if ruleSpec.JSONField.Value != "" {
+				if rule.JSONFieldValue, err = regexp.Compile(ruleSpec.JSONField.Value); err != nil {
+					return nil, fmt.Errorf("invalid json field regular expression %q: %w", ruleSpec.JSONField.Value, err)
+				}
+			}
+		}
+		filter.Rules = append(filter.Rules, rule)
+	}
+	return filter, nil
+}
+
+func samplingPercentage(percentage *int32) int32 {
+	if percentage == nil {
+		return 100
+	}
+	return *percentage
+}
+
+// NeedsStatusCode returns true if the decision depends on the response status code, in which case
+// the request can only be matched once the response is received.
+func (f *Filter) NeedsStatusCode() bool {
+	for _, rule := range f.Rules {
+		if len(rule.StatusCodes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Match matches the request and, unless statusCode is 0, its response status code against the
+// rules. The sampling is based on the request id so that the same requests are sampled by every
+// component of the InferenceService.
+func (f *Filter) Match(r *http.Request, body []byte, id string, statusCode int) Decision {
+	var payload interface{}
+	payloadDecoded := false
+	for _, rule := range f.Rules {
+		if rule.Path != nil && !rule.Path.MatchString(r.URL.Path) {
+			continue
+		}
+		if len(rule.StatusCodes) > 0 && !matchStatusCode(rule.StatusCodes, statusCode) {
+			continue
+		}
+		if !matchHeaders(rule.Headers, r.Header) {
+			continue
+		}
+		if rule.JSONFieldPath != nil {
+			if !payloadDecoded {
+				payloadDecoded = true
+				if err := json.Unmarshal(body, &payload); err != nil {
+					payload = nil
+				}
+			}
+			if !matchJSONField(payload, rule.JSONFieldPath, rule.JSONFieldValue) {
+				continue
+			}
+		}
+		return Decision{
+			Sampled:   sampled(id, rule.SamplingPercentage),
+			LogErrors: len(rule.StatusCodes) > 0,
+		}
+	}
+	return Decision{Sampled: sampled(id, f.SamplingPercentage)}
+}
+
+func sampled(id string, percentage int32) bool {
+	if percentage >= 100 {
+		return true
+	}
+	if percentage <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return h.Sum32()%10000 < uint32(percentage)*100
+}
+
+// matchStatusCode matches status codes such as "503" and classes such as "5xx".
+func matchStatusCode(patterns []string, statusCode int) bool {
+	code := strconv.Itoa(statusCode)
+	for _, pattern := range patterns {
+		if len(pattern) != len(code) {
+			continue
+		}
+		matched := true
+		for i := range pattern {
+			if pattern[i] != 'x' && pattern[i] != code[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchHeaders(headers map[string]*regexp.Regexp, header http.Header) bool {
+	for name, value := range headers {
+		values := header.Values(name)
+		if len(values) == 0 {
+			return false
+		}
+		matched := false
+		for _, v := range values {
+			if value.MatchString(v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func matchJSONField(payload interface{}, path []string, value *regexp.Regexp) bool {
+	field := payload
+	for _, segment := range path {
+		switch node := field.(type) {
+		case map[string]interface{}:
+			child, ok := node[segment]
+			if !ok {
+				return false
+			}
+			field = child
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return false
+			}
+			field = node[index]
+		default:
+			return false
+		}
+	}
+	if value == nil {
+		return true
+	}
+	if s, ok := field.(string); ok {
+		return value.MatchString(s)
+	}
+	encoded, err := json.Marshal(field)
+	if err != nil {
+		return false
+	}
+	return value.Match(encoded)
+}
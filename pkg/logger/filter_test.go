@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
+	"github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFilterMatch(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	filter, err := NewFilter(&v1beta1.LoggerFilterSpec{
+		SamplingPercentage: proto.Int32(0),
+		Rules: []v1beta1.LoggerFilterRule{
+			{
+				StatusCodes: []string{"5xx"},
+			},
+			{
+				Path:    "^/v1/models/.*:predict$",
+				Headers: map[string]string{"X-Tenant": "^gold-"},
+			},
+			{
+				JSONField: &v1beta1.LoggerJSONFieldPredicate{Path: "instances.0.country", Value: "^(FR|DE)$"},
+			},
+			{
+				JSONField: &v1beta1.LoggerJSONFieldPredicate{Path: "parameters.debug"},
+			},
+		},
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(filter.NeedsStatusCode()).To(gomega.BeTrue())
+
+	scenarios := map[string]struct {
+		path       string
+		headers    map[string]string
+		body       string
+		statusCode int
+		expected   Decision
+	}{
+		"NoRuleMatches": {
+			path:       "/v1/models/mymodel:predict",
+			body:       `{"instances":[{"country":"US"}]}`,
+			statusCode: 200,
+			expected:   Decision{},
+		},
+		"ErrorStatusCode": {
+			path:       "/v1/models/mymodel:predict",
+			body:       `{"instances":[]}`,
+			statusCode: 503,
+			expected:   Decision{Sampled: true, LogErrors: true},
+		},
+		"PathAndHeader": {
+			path:       "/v1/models/mymodel:predict",
+			headers:    map[string]string{"X-Tenant": "gold-42"},
+			statusCode: 200,
+			expected:   Decision{Sampled: true},
+		},
+		"PathWithoutHeader": {
+			path:       "/v1/models/mymodel:predict",
+			headers:    map[string]string{"X-Tenant": "silver-42"},
+			statusCode: 200,
+			expected:   Decision{},
+		},
+		"JSONFieldValue": {
+			path:       "/v2/models/mymodel/infer",
+			body:       `{"instances":[{"country":"FR"}]}`,
+			statusCode: 200,
+			expected:   Decision{Sampled: true},
+		},
+		"JSONFieldPresent": {
+			path:       "/v2/models/mymodel/infer",
+			body:       `{"parameters":{"debug":false}}`,
+			statusCode: 200,
+			expected:   Decision{Sampled: true},
+		},
+		"NotJSON": {
+			path:       "/v2/models/mymodel/infer",
+			body:       `not json`,
+			statusCode: 200,
+			expected:   Decision{},
+		},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "http://a"+scenario.path, strings.NewReader(scenario.body))
+			for k, v := range scenario.headers {
+				r.Header.Set(k, v)
+			}
+			decision := filter.Match(r, []byte(scenario.body), "id", scenario.statusCode)
+			g.Expect(decision).To(gomega.Equal(scenario.expected))
+		})
+	}
+}
+
+func TestFilterSampling(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	filter, err := NewFilter(&v1beta1.LoggerFilterSpec{SamplingPercentage: proto.Int32(10)})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(filter.NeedsStatusCode()).To(gomega.BeFalse())
+
+	r := httptest.NewRequest("POST", "http://a/v1/models/mymodel:predict", nil)
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		id := fmt.Sprintf("request-%d", i)
+		decision := filter.Match(r, nil, id, 0)
+		// the decision only depends on the request id
+		g.Expect(filter.Match(r, nil, id, 200)).To(gomega.Equal(decision))
+		if decision.Sampled {
+			sampled++
+		}
+	}
+	g.Expect(sampled).To(gomega.BeNumerically("~", 1000, 150))
+}
@@ -18,10 +18,13 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"net/http"
-	"net/http/httptest"
 	"net/url"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/go-logr/logr"
 	guuid "github.com/google/uuid"
@@ -31,6 +34,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
+// InferenceHeaderContentLength is the size of the JSON header of the v2 payloads using the binary
+// tensor extension, the binary data of the tensors follows it.
+const InferenceHeaderContentLength = "Inference-Header-Content-Length"
+
 type LoggerHandler struct {
 	log              logr.Logger
 	logUrl           *url.URL
@@ -40,11 +47,17 @@ type LoggerHandler struct {
 	namespace        string
 	component        string
 	endpoint         string
+	filter           *Filter
+	redactor         *Redactor
+	maxPayloadSize   int64
 	next             http.Handler
 }
 
-func New(logUrl *url.URL, sourceUri *url.URL, logMode v1beta1.LoggerType,
-	inferenceService string, namespace string, endpoint string, component string, next http.Handler) http.Handler {
+// New creates the logger handler. All requests are logged if filter is nil and the payloads are
+// logged as they are if redactor is nil. Logged payloads are truncated to maxPayloadSize bytes
+// unless it is 0 or less.
+func New(logUrl *url.URL, sourceUri *url.URL, logMode v1beta1.LoggerType, inferenceService string, namespace string,
+	endpoint string, component string, filter *Filter, redactor *Redactor, maxPayloadSize int64, next http.Handler) http.Handler {
 	logf.SetLogger(zap.New())
 	return &LoggerHandler{
 		log:              logf.Log.WithName("Logger"),
@@ -55,6 +68,9 @@ func New(logUrl *url.URL, sourceUri *url.URL, logMode v1beta1.LoggerType,
 		namespace:        namespace,
 		component:        component,
 		endpoint:         endpoint,
+		filter:           filter,
+		redactor:         redactor,
+		maxPayloadSize:   maxPayloadSize,
 		next:             next,
 	}
 }
@@ -67,6 +83,19 @@ func getOrCreateID(r *http.Request) string {
 	return id
 }
 
+// modelPathRegex matches the v1 and v2 inference protocol paths, e.g. /v1/models/{name}:predict
+// and /v2/models/{name}/versions/{version}/infer
+var modelPathRegex = regexp.MustCompile(`^/v[12]/models/([^/:]+)(?:/versions/([^/:]+))?`)
+
+// modelFromPath returns the model name and version of an inference protocol request path.
+func modelFromPath(path string) (string, string) {
+	match := modelPathRegex.FindStringSubmatch(path)
+	if match == nil {
+		return "", ""
+	}
+	return match[1], match[2]
+}
+
 // call svc and add send request/responses to logUrl
 func (eh *LoggerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if network.IsKubeletProbe(r) {
@@ -75,6 +104,7 @@ func (eh *LoggerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	start := time.Now()
 	// Read Payload
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -84,64 +114,144 @@ func (eh *LoggerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Get or Create an ID
 	id := getOrCreateID(r)
-	contentType := r.Header.Get("Content-Type")
+	modelName, modelVersion := modelFromPath(r.URL.Path)
+	request := LogRequest{
+		ContentType:   r.Header.Get("Content-Type"),
+		ReqType:       CEInferenceRequest,
+		Id:            id,
+		ModelName:     modelName,
+		ModelVersion:  modelVersion,
+		ContentLength: int64(len(body)),
+		Time:          start,
+	}
+	requestBody, truncated := truncate(body, eh.maxPayloadSize)
+	request.Truncated = truncated
+	requestHeaderLength := inferenceHeaderLength(r.Header)
+	logRequest := eh.logMode == v1beta1.LogAll || eh.logMode == v1beta1.LogRequest
+	logResponse := eh.logMode == v1beta1.LogAll || eh.logMode == v1beta1.LogResponse
+	logCombined := eh.logMode == v1beta1.LogCombined
+	// The decision is deferred until the response is received if it depends on the status code
+	decision := Decision{Sampled: true}
+	deferDecision := eh.filter != nil && eh.filter.NeedsStatusCode()
+	if eh.filter != nil && !deferDecision {
+		decision = eh.filter.Match(r, body, id, 0)
+	}
 	// log Request
-	if eh.logMode == v1beta1.LogAll || eh.logMode == v1beta1.LogRequest {
-		if err := QueueLogRequest(LogRequest{
-			Url:              eh.logUrl,
-			Bytes:            &body,
-			ContentType:      contentType,
-			ReqType:          CEInferenceRequest,
-			Id:               id,
-			SourceUri:        eh.sourceUri,
-			InferenceService: eh.inferenceService,
-			Namespace:        eh.namespace,
-			Endpoint:         eh.endpoint,
-			Component:        eh.component,
-		}); err != nil {
-			eh.log.Error(err, "Failed to log request")
-		}
+	if logRequest && !deferDecision && decision.Sampled {
+		eh.queue(request, requestBody, requestHeaderLength)
 	}
 
-	// Proxy Request
+	// Proxy Request, the response is streamed to the client as it is written
 	r.Body = io.NopCloser(bytes.NewBuffer(body))
-	rr := httptest.NewRecorder()
-	eh.next.ServeHTTP(rr, r)
-	responseBody := rr.Body.Bytes()
-	contentType = rr.Header().Get("Content-Type")
-	if contentType != "" {
-		w.Header().Set("Content-Type", contentType)
-	}
-	// log response if OK
-	if rr.Code == http.StatusOK {
-		if eh.logMode == v1beta1.LogAll || eh.logMode == v1beta1.LogResponse {
-			if err := QueueLogRequest(LogRequest{
-				Url:              eh.logUrl,
-				Bytes:            &responseBody,
-				ContentType:      contentType,
-				ReqType:          CEInferenceResponse,
-				Id:               id,
-				SourceUri:        eh.sourceUri,
-				InferenceService: eh.inferenceService,
-				Namespace:        eh.namespace,
-				Endpoint:         eh.endpoint,
-				Component:        eh.component,
-			}); err != nil {
-				eh.log.Error(err, "Failed to log response")
-			}
+	tw := newTeeResponseWriter(w, eh.maxPayloadSize)
+	eh.next.ServeHTTP(tw, r)
+	end := time.Now()
+	response := LogRequest{
+		ContentType:   w.Header().Get("Content-Type"),
+		ReqType:       CEInferenceResponse,
+		Id:            id,
+		ModelName:     modelName,
+		ModelVersion:  modelVersion,
+		ContentLength: tw.written,
+		Truncated:     tw.truncated,
+		StatusCode:    tw.statusCode,
+		Latency:       end.Sub(start),
+		Time:          end,
+	}
+	if deferDecision {
+		decision = eh.filter.Match(r, body, id, tw.statusCode)
+		if logRequest && decision.Sampled {
+			eh.queue(request, requestBody, requestHeaderLength)
 		}
-	} else {
-		eh.log.Info("Failed to proxy request", "status code", rr.Code)
 	}
+	responseHeaderLength := inferenceHeaderLength(w.Header())
+	// log response if OK, or if the filter asks for errors to be logged
+	if tw.statusCode == http.StatusOK || decision.LogErrors {
+		if logResponse && decision.Sampled {
+			eh.queue(response, tw.capture.Bytes(), responseHeaderLength)
+		}
+		if logCombined && decision.Sampled {
+			eh.queueCombined(request, requestBody, requestHeaderLength, response, tw.capture.Bytes(), responseHeaderLength)
+		}
+	}
+	if tw.statusCode != http.StatusOK {
+		eh.log.Info("Failed to proxy request", "status code", tw.statusCode)
+	}
+}
+
+// inferenceEvent is the payload of the combined inference events. JSON payloads are embedded as
+// they are and any other payload as a string.
+type inferenceEvent struct {
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response"`
+}
 
-	header := w.Header()
-	for k, v := range rr.Header() {
-		header[k] = v
+func embedPayload(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return body
 	}
-	w.WriteHeader(rr.Code)
-	_, err = w.Write(rr.Body.Bytes())
+	encoded, _ := json.Marshal(string(body))
+	return encoded
+}
+
+func (eh *LoggerHandler) queueCombined(request LogRequest, requestBody []byte, requestHeaderLength int,
+	response LogRequest, responseBody []byte, responseHeaderLength int) {
+	event := inferenceEvent{
+		Request:  embedPayload(eh.redact(requestBody, request.Truncated, requestHeaderLength)),
+		Response: embedPayload(eh.redact(responseBody, response.Truncated, responseHeaderLength)),
+	}
+	body, err := json.Marshal(event)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		eh.log.Error(err, "Failed to encode inference event")
 		return
 	}
+	combined := response
+	combined.ReqType = CEInference
+	combined.ContentType = "application/json"
+	combined.ContentLength = request.ContentLength + response.ContentLength
+	combined.Truncated = request.Truncated || response.Truncated
+	// the payloads are already redacted
+	eh.enqueue(combined, body)
+}
+
+// inferenceHeaderLength returns the size of the JSON header of a v2 payload using the binary tensor
+// extension, or 0 if the payload is not binary.
+func inferenceHeaderLength(header http.Header) int {
+	length, err := strconv.Atoi(header.Get(InferenceHeaderContentLength))
+	if err != nil {
+		return 0
+	}
+	return length
+}
+
+func (eh *LoggerHandler) redact(body []byte, truncated bool, headerLength int) []byte {
+	if eh.redactor == nil {
+		return body
+	}
+	if headerLength > 0 {
+		// the JSON header can be redacted as long as it is not truncated
+		return eh.redactor.RedactBinary(body, headerLength)
+	}
+	if truncated && eh.redactor.NeedsJSON() {
+		// a truncated JSON payload can not be decoded, so the fields to redact can not be found
+		return []byte{}
+	}
+	return eh.redactor.Redact(body)
+}
+
+func (eh *LoggerHandler) queue(logReq LogRequest, body []byte, headerLength int) {
+	eh.enqueue(logReq, eh.redact(body, logReq.Truncated, headerLength))
+}
+
+func (eh *LoggerHandler) enqueue(logReq LogRequest, body []byte) {
+	logReq.Url = eh.logUrl
+	logReq.Bytes = &body
+	logReq.SourceUri = eh.sourceUri
+	logReq.InferenceService = eh.inferenceService
+	logReq.Namespace = eh.namespace
+	logReq.Endpoint = eh.endpoint
+	logReq.Component = eh.component
+	if err := QueueLogRequest(logReq); err != nil {
+		eh.log.Error(err, "Failed to log event", "type", logReq.ReqType)
+	}
 }
@@ -18,12 +18,16 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
 	"github.com/onsi/gomega"
@@ -76,9 +80,9 @@ func TestLogger(t *testing.T) {
 	targetUri, err := url.Parse(predictor.URL)
 	g.Expect(err).To(gomega.BeNil())
 
-	StartDispatcher(5, logger)
+	StartDispatcher(5, &CloudEventsSink{}, logger)
 	httpProxy := httputil.NewSingleHostReverseProxy(targetUri)
-	oh := New(logSvcUrl, sourceUri, v1beta1.LogAll, "mymodel", "default", "default", "default", httpProxy)
+	oh := New(logSvcUrl, sourceUri, v1beta1.LogAll, "mymodel", "default", "default", "default", nil, nil, 0, httpProxy)
 
 	oh.ServeHTTP(w, r)
 
@@ -119,11 +123,349 @@ func TestBadResponse(t *testing.T) {
 	targetUri, err := url.Parse(predictor.URL)
 	g.Expect(err).To(gomega.BeNil())
 
-	StartDispatcher(1, logger)
+	StartDispatcher(1, &CloudEventsSink{}, logger)
 	httpProxy := httputil.NewSingleHostReverseProxy(targetUri)
-	oh := New(logSvcUrl, sourceUri, v1beta1.LogAll, "mymodel", "default", "default", "default", httpProxy)
+	oh := New(logSvcUrl, sourceUri, v1beta1.LogAll, "mymodel", "default", "default", "default", nil, nil, 0, httpProxy)
 
 	oh.ServeHTTP(w, r)
 	g.Expect(w.Code).To(gomega.Equal(400))
 	g.Expect(w.Body.String()).To(gomega.Equal(predictorResponse))
 }
+
+func TestLoggerRedaction(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	predictorRequest := []byte(`{"instances":[{"ssn":"123-45-6789","age":42}]}`)
+	predictorResponse := []byte(`{"predictions":["approved for 123-45-6789"]}`)
+
+	responseChan := make(chan string)
+	logSvc := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		b, err := io.ReadAll(req.Body)
+		g.Expect(err).To(gomega.BeNil())
+		responseChan <- string(b)
+		_, err = rw.Write([]byte(`ok`))
+		g.Expect(err).To(gomega.BeNil())
+	}))
+	defer logSvc.Close()
+
+	predictor := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		b, err := io.ReadAll(req.Body)
+		g.Expect(err).To(gomega.BeNil())
+		// the model receives the payload as it was sent
+		g.Expect(b).To(gomega.Equal(predictorRequest))
+		_, err = rw.Write(predictorResponse)
+		g.Expect(err).To(gomega.BeNil())
+	}))
+	defer predictor.Close()
+
+	reader := bytes.NewReader(predictorRequest)
+	r := httptest.NewRequest("POST", "http://a", reader)
+	w := httptest.NewRecorder()
+	logger, _ := pkglogging.NewLogger("", "INFO")
+	logSvcUrl, err := url.Parse(logSvc.URL)
+	g.Expect(err).To(gomega.BeNil())
+	sourceUri, err := url.Parse("http://localhost:9081/")
+	g.Expect(err).To(gomega.BeNil())
+	targetUri, err := url.Parse(predictor.URL)
+	g.Expect(err).To(gomega.BeNil())
+	redactor, err := NewRedactor(&v1beta1.LoggerRedactionSpec{
+		Drop:  []string{"instances.*.ssn"},
+		Masks: []v1beta1.LoggerRedactionMask{{Pattern: "[0-9]{3}-[0-9]{2}-[0-9]{4}"}},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	StartDispatcher(1, &CloudEventsSink{}, logger)
+	httpProxy := httputil.NewSingleHostReverseProxy(targetUri)
+	oh := New(logSvcUrl, sourceUri, v1beta1.LogAll, "mymodel", "default", "default", "default", nil, redactor, 0, httpProxy)
+
+	oh.ServeHTTP(w, r)
+
+	b2, _ := io.ReadAll(w.Result().Body)
+	g.Expect(b2).To(gomega.Equal(predictorResponse))
+	logged := []string{<-responseChan, <-responseChan}
+	g.Expect(logged).To(gomega.ConsistOf(`{"instances":[{"age":42}]}`, `{"predictions":["approved for ****"]}`))
+}
+
+func TestLoggerRedactionBinary(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	requestHeader := `{"inputs":[{"name":"ssn","shape":[1],"datatype":"BYTES","parameters":{"binary_data_size":15}}]}`
+	predictorRequest := []byte(requestHeader + "\x0b\x00\x00\x00123-45-6789")
+	predictorResponse := []byte("{\"outputs\":[]}")
+
+	responseChan := make(chan string)
+	logSvc := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		b, err := io.ReadAll(req.Body)
+		g.Expect(err).To(gomega.BeNil())
+		responseChan <- string(b)
+		_, err = rw.Write([]byte(`ok`))
+		g.Expect(err).To(gomega.BeNil())
+	}))
+	defer logSvc.Close()
+
+	predictor := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		b, err := io.ReadAll(req.Body)
+		g.Expect(err).To(gomega.BeNil())
+		g.Expect(b).To(gomega.Equal(predictorRequest))
+		_, err = rw.Write(predictorResponse)
+		g.Expect(err).To(gomega.BeNil())
+	}))
+	defer predictor.Close()
+
+	r := httptest.NewRequest("POST", "http://a", bytes.NewReader(predictorRequest))
+	r.Header.Set(InferenceHeaderContentLength, strconv.Itoa(len(requestHeader)))
+	w := httptest.NewRecorder()
+	logger, _ := pkglogging.NewLogger("", "INFO")
+	logSvcUrl, err := url.Parse(logSvc.URL)
+	g.Expect(err).To(gomega.BeNil())
+	sourceUri, err := url.Parse("http://localhost:9081/")
+	g.Expect(err).To(gomega.BeNil())
+	targetUri, err := url.Parse(predictor.URL)
+	g.Expect(err).To(gomega.BeNil())
+	redactor, err := NewRedactor(&v1beta1.LoggerRedactionSpec{Hash: []string{"inputs.ssn"}})
+	g.Expect(err).To(gomega.BeNil())
+
+	StartDispatcher(1, &CloudEventsSink{}, logger)
+	httpProxy := httputil.NewSingleHostReverseProxy(targetUri)
+	oh := New(logSvcUrl, sourceUri, v1beta1.LogRequest, "mymodel", "default", "default", "default", nil, redactor, 0, httpProxy)
+
+	oh.ServeHTTP(w, r)
+
+	// the binary data of the hashed tensor is withheld with the rest of the binary data
+	logged := <-responseChan
+	g.Expect(logged).NotTo(gomega.ContainSubstring("123-45-6789"))
+	g.Expect(logged).To(gomega.HavePrefix(`{"inputs":["`))
+	g.Expect(json.Valid([]byte(logged))).To(gomega.BeTrue())
+}
+
+func TestLoggerTruncation(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	predictorRequest := []byte(`{"instances":[[0,0,0]]}`)
+	responseChunks := []string{`{"predictions":`, `[[4,5,6]]}`}
+
+	type loggedEvent struct {
+		body      string
+		truncated string
+	}
+	responseChan := make(chan loggedEvent)
+	logSvc := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		b, err := io.ReadAll(req.Body)
+		g.Expect(err).To(gomega.BeNil())
+		responseChan <- loggedEvent{body: string(b), truncated: req.Header.Get("Ce-Truncated")}
+		_, err = rw.Write([]byte(`ok`))
+		g.Expect(err).To(gomega.BeNil())
+	}))
+	defer logSvc.Close()
+
+	// The model streams its response in chunks
+	predictor := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		for _, chunk := range responseChunks {
+			_, err := rw.Write([]byte(chunk))
+			g.Expect(err).To(gomega.BeNil())
+			rw.(http.Flusher).Flush()
+		}
+	})
+
+	r := httptest.NewRequest("POST", "http://a", bytes.NewReader(predictorRequest))
+	w := httptest.NewRecorder()
+	logger, _ := pkglogging.NewLogger("", "INFO")
+	logSvcUrl, err := url.Parse(logSvc.URL)
+	g.Expect(err).To(gomega.BeNil())
+	sourceUri, err := url.Parse("http://localhost:9081/")
+	g.Expect(err).To(gomega.BeNil())
+
+	StartDispatcher(1, &CloudEventsSink{}, logger)
+	oh := New(logSvcUrl, sourceUri, v1beta1.LogAll, "mymodel", "default", "default", "default", nil, nil, 20, predictor)
+
+	oh.ServeHTTP(w, r)
+
+	// The client receives the whole response as it is streamed
+	g.Expect(w.Flushed).To(gomega.BeTrue())
+	g.Expect(w.Body.String()).To(gomega.Equal(responseChunks[0] + responseChunks[1]))
+	logged := []loggedEvent{<-responseChan, <-responseChan}
+	g.Expect(logged).To(gomega.ConsistOf(
+		loggedEvent{body: `{"instances":[[0,0,0`, truncated: "true"},
+		loggedEvent{body: `{"predictions":[[4,5`, truncated: "true"},
+	))
+}
+
+func TestTeeResponseWriter(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scenarios := map[string]struct {
+		maxCapture        int64
+		writes            []string
+		expectedCapture   string
+		expectedTruncated bool
+	}{
+		"Unlimited": {
+			maxCapture:      0,
+			writes:          []string{"abc", "def"},
+			expectedCapture: "abcdef",
+		},
+		"UnderLimit": {
+			maxCapture:      6,
+			writes:          []string{"abc", "def"},
+			expectedCapture: "abcdef",
+		},
+		"OverLimit": {
+			maxCapture:        4,
+			writes:            []string{"abc", "def", "ghi"},
+			expectedCapture:   "abcd",
+			expectedTruncated: true,
+		},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tw := newTeeResponseWriter(w, scenario.maxCapture)
+			tw.WriteHeader(http.StatusAccepted)
+			for _, write := range scenario.writes {
+				n, err := tw.Write([]byte(write))
+				g.Expect(err).To(gomega.BeNil())
+				g.Expect(n).To(gomega.Equal(len(write)))
+			}
+			g.Expect(tw.statusCode).To(gomega.Equal(http.StatusAccepted))
+			g.Expect(w.Code).To(gomega.Equal(http.StatusAccepted))
+			g.Expect(w.Body.String()).To(gomega.Equal(strings.Join(scenario.writes, "")))
+			g.Expect(tw.capture.String()).To(gomega.Equal(scenario.expectedCapture))
+			g.Expect(tw.truncated).To(gomega.Equal(scenario.expectedTruncated))
+		})
+	}
+}
+
+func TestLoggerEnrichment(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	predictorRequest := []byte(`{"inputs":[{"name":"input-0","shape":[1],"datatype":"INT32","data":[1]}]}`)
+	predictorResponse := []byte(`{"outputs":[{"name":"output-0","shape":[1],"datatype":"INT32","data":[2]}]}`)
+
+	eventChan := make(chan http.Header)
+	logSvc := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, err := io.ReadAll(req.Body)
+		g.Expect(err).To(gomega.BeNil())
+		eventChan <- req.Header
+		_, err = rw.Write([]byte(`ok`))
+		g.Expect(err).To(gomega.BeNil())
+	}))
+	defer logSvc.Close()
+
+	predictor := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, err := rw.Write(predictorResponse)
+		g.Expect(err).To(gomega.BeNil())
+	})
+
+	r := httptest.NewRequest("POST", "http://a/v2/models/mymodel/versions/3/infer", bytes.NewReader(predictorRequest))
+	w := httptest.NewRecorder()
+	logger, _ := pkglogging.NewLogger("", "INFO")
+	logSvcUrl, err := url.Parse(logSvc.URL)
+	g.Expect(err).To(gomega.BeNil())
+	sourceUri, err := url.Parse("http://localhost:9081/")
+	g.Expect(err).To(gomega.BeNil())
+
+	StartDispatcher(1, &CloudEventsSink{}, logger)
+	oh := New(logSvcUrl, sourceUri, v1beta1.LogAll, "mymodel", "default", "default", "default", nil, nil, 0, predictor)
+
+	oh.ServeHTTP(w, r)
+
+	events := map[string]http.Header{}
+	for i := 0; i < 2; i++ {
+		header := <-eventChan
+		events[header.Get("Ce-Type")] = header
+	}
+	request, response := events[CEInferenceRequest], events[CEInferenceResponse]
+	g.Expect(request).NotTo(gomega.BeNil())
+	g.Expect(response).NotTo(gomega.BeNil())
+	for _, header := range []http.Header{request, response} {
+		g.Expect(header.Get("Ce-Modelname")).To(gomega.Equal("mymodel"))
+		g.Expect(header.Get("Ce-Modelversion")).To(gomega.Equal("3"))
+	}
+	g.Expect(request.Get("Ce-Contentlength")).To(gomega.Equal(strconv.Itoa(len(predictorRequest))))
+	g.Expect(request.Get("Ce-Statuscode")).To(gomega.BeEmpty())
+	g.Expect(request.Get("Ce-Latencyms")).To(gomega.BeEmpty())
+	g.Expect(response.Get("Ce-Contentlength")).To(gomega.Equal(strconv.Itoa(len(predictorResponse))))
+	g.Expect(response.Get("Ce-Statuscode")).To(gomega.Equal("200"))
+	g.Expect(response.Get("Ce-Latencyms")).NotTo(gomega.BeEmpty())
+}
+
+func TestLoggerCombined(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	predictorRequest := []byte(`{"instances":[[0,0,0]]}`)
+	predictorResponse := []byte(`not json`)
+
+	type loggedEvent struct {
+		eventType string
+		body      string
+	}
+	eventChan := make(chan loggedEvent)
+	logSvc := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		b, err := io.ReadAll(req.Body)
+		g.Expect(err).To(gomega.BeNil())
+		eventChan <- loggedEvent{eventType: req.Header.Get("Ce-Type"), body: string(b)}
+		_, err = rw.Write([]byte(`ok`))
+		g.Expect(err).To(gomega.BeNil())
+	}))
+	defer logSvc.Close()
+
+	predictor := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, err := rw.Write(predictorResponse)
+		g.Expect(err).To(gomega.BeNil())
+	})
+
+	r := httptest.NewRequest("POST", "http://a/v1/models/mymodel:predict", bytes.NewReader(predictorRequest))
+	w := httptest.NewRecorder()
+	logger, _ := pkglogging.NewLogger("", "INFO")
+	logSvcUrl, err := url.Parse(logSvc.URL)
+	g.Expect(err).To(gomega.BeNil())
+	sourceUri, err := url.Parse("http://localhost:9081/")
+	g.Expect(err).To(gomega.BeNil())
+
+	StartDispatcher(1, &CloudEventsSink{}, logger)
+	oh := New(logSvcUrl, sourceUri, v1beta1.LogCombined, "mymodel", "default", "default", "default", nil, nil, 0, predictor)
+
+	oh.ServeHTTP(w, r)
+
+	// A single event holds both the request and the response
+	g.Expect(<-eventChan).To(gomega.Equal(loggedEvent{
+		eventType: CEInference,
+		body:      `{"request":{"instances":[[0,0,0]]},"response":"not json"}`,
+	}))
+	g.Consistently(eventChan, 100*time.Millisecond).ShouldNot(gomega.Receive())
+}
+
+func TestModelFromPath(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scenarios := map[string]struct {
+		path            string
+		expectedName    string
+		expectedVersion string
+	}{
+		"V1Predict": {
+			path:         "/v1/models/sklearn-iris:predict",
+			expectedName: "sklearn-iris",
+		},
+		"V2Infer": {
+			path:         "/v2/models/sklearn-iris/infer",
+			expectedName: "sklearn-iris",
+		},
+		"V2InferWithVersion": {
+			path:            "/v2/models/sklearn-iris/versions/2/infer",
+			expectedName:    "sklearn-iris",
+			expectedVersion: "2",
+		},
+		"OtherPath": {
+			path: "/openai/v1/completions",
+		},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			modelName, modelVersion := modelFromPath(scenario.path)
+			g.Expect(modelName).To(gomega.Equal(scenario.expectedName))
+			g.Expect(modelVersion).To(gomega.Equal(scenario.expectedVersion))
+		})
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaWriter is the subset of kafka.Writer used by the sink.
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaSink produces each payload as a binary mode CloudEvent to a Kafka topic. Messages are keyed
+// by the event id so that a request and its response land on the same partition.
+type KafkaSink struct {
+	Writer KafkaWriter
+}
+
+var _ Sink = (*KafkaSink)(nil)
+
+func (s *KafkaSink) Send(ctx context.Context, logReq LogRequest) error {
+	event, err := createEvent(logReq)
+	if err != nil {
+		return err
+	}
+	// Headers follow the Kafka protocol binding of the CloudEvents spec
+	headers := []kafka.Header{
+		{Key: "ce_specversion", Value: []byte(event.SpecVersion())},
+		{Key: "ce_id", Value: []byte(event.ID())},
+		{Key: "ce_type", Value: []byte(event.Type())},
+		{Key: "ce_source", Value: []byte(event.Source())},
+		{Key: "ce_time", Value: []byte(event.Time().Format(time.RFC3339Nano))},
+		{Key: "content-type", Value: []byte(event.DataContentType())},
+	}
+	for name, value := range event.Extensions() {
+		headers = append(headers, kafka.Header{Key: "ce_" + name, Value: []byte(fmt.Sprintf("%v", value))})
+	}
+	msg := kafka.Message{
+		Key:     []byte(event.ID()),
+		Value:   event.Data(),
+		Headers: headers,
+	}
+	if err := s.Writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("while producing kafka message: %w", err)
+	}
+	return nil
+}
+
+func (s *KafkaSink) Close() error {
+	return s.Writer.Close()
+}
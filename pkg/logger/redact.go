@@ -0,0 +1,231 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
+)
+
+const DefaultRedactionReplacement = "****"
+
+// Redactor removes, hashes and masks fields of the logged payloads, see v1beta1.LoggerRedactionSpec.
+type Redactor struct {
+	Drop  [][]string
+	Hash  [][]string
+	Masks []RedactionMask
+}
+
+type RedactionMask struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+func NewRedactor(spec *v1beta1.LoggerRedactionSpec) (*Redactor, error) {
+	redactor := &Redactor{}
+	for _, path := range spec.Drop {
+		redactor.Drop = append(redactor.Drop, strings.Split(path, "."))
+	}
+	for _, path := range spec.Hash {
+		redactor.Hash = append(redactor.Hash, strings.Split(path, "."))
+	}
+	for _, maskSpec := range spec.Masks {
+		pattern, err := regexp.Compile(maskSpec.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mask regular expression %q: %w", maskSpec.Pattern, err)
+		}
+		mask := RedactionMask{Pattern: pattern, Replacement: DefaultRedactionReplacement}
+		if maskSpec.Replacement != nil {
+			mask.Replacement = *maskSpec.Replacement
+		}
+		redactor.Masks = append(redactor.Masks, mask)
+	}
+	return redactor, nil
+}
+
+// NeedsJSON returns true if fields are dropped or hashed, which can only be done on JSON payloads
+// that can be decoded.
+func (r *Redactor) NeedsJSON() bool {
+	return len(r.Drop) > 0 || len(r.Hash) > 0
+}
+
+// Redact returns a redacted copy of the payload. Payloads which are not JSON only have the masks
+// applied, unless fields are dropped or hashed: the fields can not be found in them, so they are
+// withheld and an empty payload is returned.
+func (r *Redactor) Redact(body []byte) []byte {
+	var payload interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// keep numbers as they are rather than round tripping them through float64
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil || decoder.More() {
+		if r.NeedsJSON() {
+			return []byte{}
+		}
+		return r.maskBytes(body)
+	}
+	for _, path := range r.Drop {
+		payload = redactPath(payload, path, dropField)
+	}
+	for _, path := range r.Hash {
+		payload = redactPath(payload, path, hashField)
+	}
+	if len(r.Masks) > 0 {
+		payload = r.mask(payload)
+	}
+	var redacted bytes.Buffer
+	encoder := json.NewEncoder(&redacted)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(payload); err != nil {
+		return body
+	}
+	return bytes.TrimSuffix(redacted.Bytes(), []byte("\n"))
+}
+
+// RedactBinary returns a redacted copy of a v2 payload using the binary tensor extension, made of a
+// JSON header of headerLength bytes followed by the binary data of the tensors. Only the redacted
+// header is returned: the binary data of the redacted tensors can not be told apart, and the masks
+// can not be applied to raw tensor data without corrupting it or changing the header length.
+func (r *Redactor) RedactBinary(body []byte, headerLength int) []byte {
+	if headerLength <= 0 || headerLength > len(body) {
+		return r.Redact(body)
+	}
+	return r.Redact(body[:headerLength])
+}
+
+func (r *Redactor) maskBytes(body []byte) []byte {
+	redacted := body
+	for _, mask := range r.Masks {
+		redacted = mask.Pattern.ReplaceAll(redacted, []byte(mask.Replacement))
+	}
+	return redacted
+}
+
+// redaction returns the new value of a selected field, or false if the field is removed.
+type redaction func(value interface{}) (interface{}, bool)
+
+func dropField(interface{}) (interface{}, bool) {
+	return nil, false
+}
+
+func hashField(value interface{}) (interface{}, bool) {
+	// the data of a v2 tensor is hashed element by element so the tensor stays well formed
+	if tensor, ok := value.(map[string]interface{}); ok {
+		if data, ok := tensor["data"].([]interface{}); ok {
+			if _, named := tensor["name"]; named {
+				hashed := make([]interface{}, len(data))
+				for i, element := range data {
+					hashed[i] = hashValue(element)
+				}
+				tensor["data"] = hashed
+				return tensor, true
+			}
+		}
+	}
+	return hashValue(value), true
+}
+
+func hashValue(value interface{}) string {
+	var encoded []byte
+	if s, ok := value.(string); ok {
+		encoded = []byte(s)
+	} else {
+		encoded, _ = json.Marshal(value)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// redactPath applies the redaction to the fields of node selected by path and returns the new node.
+func redactPath(node interface{}, path []string, redact redaction) interface{} {
+	segment, last := path[0], len(path) == 1
+	apply := func(value interface{}) (interface{}, bool) {
+		if last {
+			return redact(value)
+		}
+		return redactPath(value, path[1:], redact), true
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, value := range n {
+			if segment != "*" && segment != key {
+				continue
+			}
+			if redacted, keep := apply(value); keep {
+				n[key] = redacted
+			} else {
+				delete(n, key)
+			}
+		}
+		return n
+	case []interface{}:
+		index, err := strconv.Atoi(segment)
+		isIndex := err == nil
+		result := n[:0]
+		for i, element := range n {
+			selected := segment == "*" || (isIndex && i == index) || (!isIndex && hasName(element, segment))
+			if selected {
+				redacted, keep := apply(element)
+				if !keep {
+					continue
+				}
+				element = redacted
+			}
+			result = append(result, element)
+		}
+		return result
+	}
+	return node
+}
+
+// hasName returns true for objects with a name field of the given value, such as v2 named tensors.
+func hasName(element interface{}, name string) bool {
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	value, ok := object["name"].(string)
+	return ok && value == name
+}
+
+func (r *Redactor) mask(node interface{}) interface{} {
+	switch n := node.(type) {
+	case string:
+		for _, mask := range r.Masks {
+			n = mask.Pattern.ReplaceAllString(n, mask.Replacement)
+		}
+		return n
+	case map[string]interface{}:
+		for key, value := range n {
+			n[key] = r.mask(value)
+		}
+		return n
+	case []interface{}:
+		for i, element := range n {
+			n[i] = r.mask(element)
+		}
+		return n
+	}
+	return node
+}
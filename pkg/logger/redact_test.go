@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
+	"github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+)
+
+// sha256 of "123-45-6789"
+const ssnHash = "01a54629efb952287e554eb23ef69c52097a75aecc0e3a93ca0855ab6d7a31a0"
+
+func TestRedactor(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	redactor, err := NewRedactor(&v1beta1.LoggerRedactionSpec{
+		Drop: []string{"instances.*.notes", "inputs.notes", "parameters.debug"},
+		Hash: []string{"instances.*.ssn", "inputs.ssn"},
+		Masks: []v1beta1.LoggerRedactionMask{
+			{Pattern: "[0-9]{3}-[0-9]{2}-[0-9]{4}"},
+			{Pattern: `([a-z]+)@example\.com`, Replacement: proto.String("$1@redacted")},
+		},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	scenarios := map[string]struct {
+		body     string
+		expected string
+	}{
+		"V1Instances": {
+			body:     `{"instances":[{"ssn":"123-45-6789","notes":"call back","age":42.50},{"ssn":"123-45-6789"}],"parameters":{"debug":true}}`,
+			expected: `{"instances":[{"age":42.50,"ssn":"` + ssnHash + `"},{"ssn":"` + ssnHash + `"}],"parameters":{}}`,
+		},
+		"V2NamedTensors": {
+			body: `{"id":"1","inputs":[{"name":"ssn","shape":[1],"datatype":"BYTES","data":["123-45-6789"]},` +
+				`{"name":"notes","shape":[1],"datatype":"BYTES","data":["call back"]},` +
+				`{"name":"age","shape":[1],"datatype":"FP32","data":[42]}]}`,
+			expected: `{"id":"1","inputs":[{"data":["` + ssnHash + `"],"datatype":"BYTES","name":"ssn","shape":[1]},` +
+				`{"data":[42],"datatype":"FP32","name":"age","shape":[1]}]}`,
+		},
+		"MasksInStrings": {
+			body:     `{"predictions":["reach jane@example.com or 123-45-6789 <soon>"]}`,
+			expected: `{"predictions":["reach jane@redacted or **** <soon>"]}`,
+		},
+		"NotJSON": {
+			// the fields to drop and hash can not be found, so the payload is withheld
+			body:     `ssn=123-45-6789&mail=jane@example.com`,
+			expected: ``,
+		},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			g.Expect(string(redactor.Redact([]byte(scenario.body)))).To(gomega.Equal(scenario.expected))
+		})
+	}
+}
+
+func TestRedactorWithholdsPayloads(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	maskOnly, err := NewRedactor(&v1beta1.LoggerRedactionSpec{
+		Masks: []v1beta1.LoggerRedactionMask{{Pattern: "[0-9]{3}-[0-9]{2}-[0-9]{4}"}},
+	})
+	g.Expect(err).To(gomega.BeNil())
+	dropping, err := NewRedactor(&v1beta1.LoggerRedactionSpec{
+		Drop:  []string{"inputs.ssn"},
+		Masks: []v1beta1.LoggerRedactionMask{{Pattern: "[0-9]{3}-[0-9]{2}-[0-9]{4}"}},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	header := `{"inputs":[{"name":"ssn","shape":[1],"datatype":"BYTES","parameters":{"binary_data_size":15}},` +
+		`{"name":"age","shape":[1],"datatype":"FP32","data":[42]}]}`
+	binary := header + "\x0b\x00\x00\x00123-45-6789"
+
+	scenarios := map[string]struct {
+		redactor     *Redactor
+		body         string
+		headerLength int
+		expected     string
+	}{
+		"NotJSONWithMasks": {
+			redactor: maskOnly,
+			body:     `ssn=123-45-6789`,
+			expected: `ssn=****`,
+		},
+		"NotJSONWithDroppedFields": {
+			redactor: dropping,
+			body:     `ssn=123-45-6789`,
+			expected: ``,
+		},
+		"ConcatenatedJSONWithDroppedFields": {
+			redactor: dropping,
+			body:     `{"inputs":[]}{"inputs":[{"name":"ssn","data":["123-45-6789"]}]}`,
+			expected: ``,
+		},
+		"BinaryWithDroppedFields": {
+			redactor:     dropping,
+			body:         binary,
+			headerLength: len(header),
+			expected:     `{"inputs":[{"data":[42],"datatype":"FP32","name":"age","shape":[1]}]}`,
+		},
+		"BinaryWithoutHeaderLength": {
+			redactor: dropping,
+			body:     binary,
+			expected: ``,
+		},
+		"BinaryWithMasks": {
+			redactor:     maskOnly,
+			body:         binary,
+			headerLength: len(header),
+			expected: `{"inputs":[{"datatype":"BYTES","name":"ssn","parameters":{"binary_data_size":15},"shape":[1]},` +
+				`{"data":[42],"datatype":"FP32","name":"age","shape":[1]}]}`,
+		},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			g.Expect(string(scenario.redactor.RedactBinary([]byte(scenario.body), scenario.headerLength))).
+				To(gomega.Equal(scenario.expected))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// teeResponseWriter streams the response to the client while capturing its status code and up to
+// maxCapture bytes of its body for logging. The capture is unbounded if maxCapture is 0 or less.
+type teeResponseWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	maxCapture  int64
+	capture     bytes.Buffer
+	truncated   bool
+	wroteHeader bool
+	// written is the size of the whole response body
+	written int64
+}
+
+func newTeeResponseWriter(w http.ResponseWriter, maxCapture int64) *teeResponseWriter {
+	return &teeResponseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+		maxCapture:     maxCapture,
+	}
+}
+
+func (t *teeResponseWriter) WriteHeader(statusCode int) {
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		t.statusCode = statusCode
+	}
+	t.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (t *teeResponseWriter) Write(b []byte) (int, error) {
+	t.wroteHeader = true
+	t.captureBytes(b)
+	n, err := t.ResponseWriter.Write(b)
+	t.written += int64(n)
+	return n, err
+}
+
+func (t *teeResponseWriter) captureBytes(b []byte) {
+	if t.maxCapture <= 0 {
+		t.capture.Write(b)
+		return
+	}
+	remaining := t.maxCapture - int64(t.capture.Len())
+	if int64(len(b)) > remaining {
+		t.truncated = true
+		b = b[:remaining]
+	}
+	t.capture.Write(b)
+}
+
+// Flush sends any buffered data to the client so that streamed responses are not held back.
+func (t *teeResponseWriter) Flush() {
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (t *teeResponseWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// truncate returns at most maxSize bytes of body, and true if body was longer.
+func truncate(body []byte, maxSize int64) ([]byte, bool) {
+	if maxSize <= 0 || int64(len(body)) <= maxSize {
+		return body, false
+	}
+	return body[:maxSize], true
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type RetryOptions struct {
+	// MaxRetries is the number of times a failed send is retried, 0 disables retries
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry, it is doubled on every following retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between two retries
+	MaxBackoff time.Duration
+	// SpoolDir is the directory undeliverable requests are spooled to, spooling is disabled if empty
+	SpoolDir string
+	// SpoolMaxSize is the max size in bytes of the spooled requests, the oldest ones are evicted
+	// beyond it. The spool is unbounded if 0
+	SpoolMaxSize int64
+	// ReplayInterval is how often spooled requests are replayed to the sink
+	ReplayInterval time.Duration
+}
+
+var DefaultRetryOptions = RetryOptions{
+	MaxRetries:     3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	SpoolMaxSize:   100 << 20,
+	ReplayInterval: 30 * time.Second,
+}
+
+// RetrySink retries failed sends to the wrapped sink with exponential backoff. Requests which still
+// fail once the retries are exhausted are written to the spool, if one is configured, and replayed
+// every ReplayInterval. Spooled requests left over from a previous run are replayed on start. The
+// batches a wrapped BatchSink fails to post, including on its own timer, are retried and spooled
+// the same way.
+type RetrySink struct {
+	Sink    Sink
+	Options RetryOptions
+	Spool   *Spool
+	log     *zap.SugaredLogger
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+var _ Sink = (*RetrySink)(nil)
+
+func NewRetrySink(sink Sink, opts RetryOptions, logger *zap.SugaredLogger) (*RetrySink, error) {
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = DefaultRetryOptions.InitialBackoff
+	}
+	if opts.MaxBackoff < opts.InitialBackoff {
+		opts.MaxBackoff = opts.InitialBackoff
+	}
+	if opts.ReplayInterval <= 0 {
+		opts.ReplayInterval = DefaultRetryOptions.ReplayInterval
+	}
+	s := &RetrySink{
+		Sink:    sink,
+		Options: opts,
+		log:     logger,
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if opts.SpoolDir != "" {
+		spool, err := NewSpool(opts.SpoolDir, opts.SpoolMaxSize)
+		if err != nil {
+			return nil, err
+		}
+		s.Spool = spool
+	}
+	if batch, ok := sink.(*BatchSink); ok {
+		batch.HandleFailures(func(ctx context.Context, requests []LogRequest, err error) {
+			s.batchFailed(ctx, batch, requests, err)
+		})
+	}
+	if s.Spool == nil {
+		close(s.done)
+		return s, nil
+	}
+	go s.replayLoop()
+	return s, nil
+}
+
+func (s *RetrySink) replayLoop() {
+	defer close(s.done)
+	s.replay()
+	ticker := time.NewTicker(s.Options.ReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.replay()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *RetrySink) replay() {
+	sent, err := s.Spool.Replay(context.Background(), s.Sink)
+	if sent > 0 {
+		s.log.Infof("Replayed %d spooled log requests", sent)
+	}
+	if err != nil {
+		s.log.Warnf("Failed to replay spooled log requests: %v", err)
+	}
+}
+
+func (s *RetrySink) Send(ctx context.Context, logReq LogRequest) error {
+	err := s.retry(ctx, "log request "+logReq.Id, s.Sink.Send(ctx, logReq), func() error {
+		return s.Sink.Send(ctx, logReq)
+	})
+	if err == nil {
+		return nil
+	}
+	if s.Spool == nil {
+		return err
+	}
+	if !s.spool(logReq, err) {
+		return err
+	}
+	return nil
+}
+
+// batchFailed retries posting a batch which failed to post, then spools its requests. They are
+// dropped if there is no spool.
+func (s *RetrySink) batchFailed(ctx context.Context, batch *BatchSink, requests []LogRequest, err error) {
+	err = s.retry(ctx, fmt.Sprintf("batch of %d log requests", len(requests)), err, func() error {
+		return batch.Post(ctx, requests)
+	})
+	if err == nil {
+		return
+	}
+	if s.Spool == nil {
+		droppedFailed.Add(uint64(len(requests)))
+		s.log.Errorf("Dropped a batch of %d log requests after %d retries: %v", len(requests), s.Options.MaxRetries, err)
+		return
+	}
+	for _, logReq := range requests {
+		if !s.spool(logReq, err) {
+			droppedFailed.Add(1)
+		}
+	}
+}
+
+// spool writes a request which failed to send to the spool, it returns false if it could not.
+func (s *RetrySink) spool(logReq LogRequest, err error) bool {
+	s.log.Warnf("Spooling log request %s after %d retries: %v", logReq.Id, s.Options.MaxRetries, err)
+	if spoolErr := s.Spool.Write(logReq); spoolErr != nil {
+		s.log.Errorf("Failed to spool log request %s: %v", logReq.Id, spoolErr)
+		return false
+	}
+	return true
+}
+
+// retry calls send with exponential backoff until it succeeds or the retries are exhausted, err is
+// the error of the first attempt.
+func (s *RetrySink) retry(ctx context.Context, what string, err error, send func() error) error {
+	backoff := s.Options.InitialBackoff
+	for retry := 0; err != nil && retry < s.Options.MaxRetries; retry++ {
+		s.log.Debugf("Retrying %s in %v: %v", what, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+		if backoff > s.Options.MaxBackoff {
+			backoff = s.Options.MaxBackoff
+		}
+		err = send()
+	}
+	return err
+}
+
+// Close stops replaying spooled requests and closes the wrapped sink.
+func (s *RetrySink) Close() error {
+	select {
+	case <-s.quit:
+	default:
+		close(s.quit)
+	}
+	<-s.done
+	return s.Sink.Close()
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/onsi/gomega"
+	pkglogging "knative.dev/pkg/logging"
+)
+
+// flakySink fails the next failures sends, or every send if failures is negative, and records
+// the ids of the requests it delivered.
+type flakySink struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	sent     []string
+}
+
+func (f *flakySink) Send(_ context.Context, logReq LogRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.failures != 0 {
+		if f.failures > 0 {
+			f.failures--
+		}
+		return errors.New("sink unavailable")
+	}
+	f.sent = append(f.sent, logReq.Id)
+	return nil
+}
+
+func (f *flakySink) Close() error {
+	return nil
+}
+
+func (f *flakySink) setFailures(failures int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = failures
+}
+
+func (f *flakySink) sentIds() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.sent...)
+}
+
+func TestRetrySink(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	logger, _ := pkglogging.NewLogger("", "INFO")
+
+	inner := &flakySink{failures: 2}
+	sink, err := NewRetrySink(inner, RetryOptions{
+		MaxRetries:     3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+	}, logger)
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(sink.Send(context.Background(), newTestLogRequest("1", `{"instances":[1]}`))).To(gomega.Succeed())
+	g.Expect(inner.attempts).To(gomega.Equal(3))
+	g.Expect(inner.sentIds()).To(gomega.Equal([]string{"1"}))
+
+	// Without a spool the error is returned once the retries are exhausted
+	inner.setFailures(-1)
+	g.Expect(sink.Send(context.Background(), newTestLogRequest("2", `{"instances":[2]}`))).NotTo(gomega.Succeed())
+	g.Expect(inner.attempts).To(gomega.Equal(7))
+	g.Expect(sink.Close()).To(gomega.Succeed())
+}
+
+func TestRetrySinkSpool(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	logger, _ := pkglogging.NewLogger("", "INFO")
+	opts := RetryOptions{
+		MaxRetries:     1,
+		InitialBackoff: time.Millisecond,
+		SpoolDir:       t.TempDir(),
+		ReplayInterval: time.Hour,
+	}
+
+	inner := &flakySink{failures: -1}
+	sink, err := NewRetrySink(inner, opts, logger)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(sink.Send(context.Background(), newTestLogRequest("1", `{"instances":[1]}`))).To(gomega.Succeed())
+	g.Expect(sink.Send(context.Background(), newTestLogRequest("2", `{"instances":[2]}`))).To(gomega.Succeed())
+	g.Expect(sink.Spool.Len()).To(gomega.Equal(2))
+	g.Expect(sink.Close()).To(gomega.Succeed())
+
+	// A new sink replays the requests spooled by the previous one in order
+	recovered := &flakySink{}
+	sink, err = NewRetrySink(recovered, opts, logger)
+	g.Expect(err).To(gomega.BeNil())
+	g.Eventually(recovered.sentIds).Should(gomega.Equal([]string{"1", "2"}))
+	g.Eventually(sink.Spool.Len).Should(gomega.Equal(0))
+	g.Expect(sink.Close()).To(gomega.Succeed())
+}
+
+func TestRetrySinkBatchFailure(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	logger, _ := pkglogging.NewLogger("", "INFO")
+
+	var mu sync.Mutex
+	failing := true
+	batches := make(chan []cloudevents.Event, 10)
+	logSvc := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, err := io.ReadAll(req.Body)
+		g.Expect(err).To(gomega.BeNil())
+		var events []cloudevents.Event
+		g.Expect(json.Unmarshal(b, &events)).To(gomega.Succeed())
+		batches <- events
+	}))
+	defer logSvc.Close()
+	logSvcUrl, err := url.Parse(logSvc.URL)
+	g.Expect(err).To(gomega.BeNil())
+
+	// The batch which fails to post on the timer is spooled, then replayed once the service recovers
+	sink, err := NewRetrySink(NewBatchSink(logSvcUrl, 10, 10*time.Millisecond, logger), RetryOptions{
+		MaxRetries:     1,
+		InitialBackoff: time.Millisecond,
+		SpoolDir:       t.TempDir(),
+		ReplayInterval: 50 * time.Millisecond,
+	}, logger)
+	g.Expect(err).To(gomega.BeNil())
+	for _, id := range []string{"1", "2"} {
+		g.Expect(sink.Send(context.Background(), newTestLogRequest(id, `{"instances":[[1,2]]}`))).To(gomega.Succeed())
+	}
+	g.Eventually(sink.Spool.Len).Should(gomega.Equal(2))
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	var events []cloudevents.Event
+	g.Eventually(batches).Should(gomega.Receive(&events))
+	g.Expect(events).To(gomega.HaveLen(2))
+	g.Expect(events[0].ID()).To(gomega.Equal("1"))
+	g.Expect(events[1].ID()).To(gomega.Equal("2"))
+	g.Expect(sink.Spool.Len()).To(gomega.Equal(0))
+	g.Expect(sink.Close()).To(gomega.Succeed())
+
+	// Without a spool the batch is retried
+	mu.Lock()
+	failing = true
+	mu.Unlock()
+	batch := NewBatchSink(logSvcUrl, 10, 10*time.Millisecond, logger)
+	sink, err = NewRetrySink(batch, RetryOptions{
+		MaxRetries:     100,
+		InitialBackoff: 10 * time.Millisecond,
+	}, logger)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(sink.Send(context.Background(), newTestLogRequest("3", `{"instances":[[1,2]]}`))).To(gomega.Succeed())
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	g.Eventually(batches).Should(gomega.Receive(&events))
+	g.Expect(events).To(gomega.HaveLen(1))
+	g.Expect(events[0].ID()).To(gomega.Equal("3"))
+	g.Expect(sink.Close()).To(gomega.Succeed())
+}
+
+func TestSpool(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	spool, err := NewSpool(t.TempDir(), 0)
+	g.Expect(err).To(gomega.BeNil())
+	logReq := newTestLogRequest("1", `{"instances":[1]}`)
+	logReq.Time = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	g.Expect(spool.Write(logReq)).To(gomega.Succeed())
+
+	// Nothing is removed while the sink is failing
+	sent, err := spool.Replay(context.Background(), &flakySink{failures: -1})
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(sent).To(gomega.Equal(0))
+	g.Expect(spool.Len()).To(gomega.Equal(1))
+
+	sink := &recordingSink{}
+	sent, err = spool.Replay(context.Background(), sink)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(sent).To(gomega.Equal(1))
+	g.Expect(spool.Len()).To(gomega.Equal(0))
+	// The replayed request keeps the time it was received
+	g.Expect(sink.requests[0]).To(gomega.Equal(logReq))
+	event, err := createEvent(sink.requests[0])
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(event.Time()).To(gomega.Equal(logReq.Time))
+}
+
+func TestSpoolMaxSize(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	spool, err := NewSpool(t.TempDir(), 0)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(spool.Write(newTestLogRequest("1", `{"instances":[1]}`))).To(gomega.Succeed())
+	files, err := spool.files()
+	g.Expect(err).To(gomega.BeNil())
+	info, err := os.Stat(filepath.Join(spool.Dir, files[0]))
+	g.Expect(err).To(gomega.BeNil())
+
+	// The oldest requests are evicted once the spool holds more than two of them
+	spool.MaxSize = 2*info.Size() + 1
+	dropped := DroppedOnFailure()
+	for _, id := range []string{"2", "3", "4"} {
+		g.Expect(spool.Write(newTestLogRequest(id, `{"instances":[1]}`))).To(gomega.Succeed())
+	}
+	g.Expect(spool.Len()).To(gomega.Equal(2))
+	g.Expect(DroppedOnFailure()).To(gomega.Equal(dropped + 2))
+
+	sink := &recordingSink{}
+	sent, err := spool.Replay(context.Background(), sink)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(sent).To(gomega.Equal(2))
+	g.Expect(sink.requests[0].Id).To(gomega.Equal("3"))
+	g.Expect(sink.requests[1].Id).To(gomega.Equal("4"))
+}
+
+type recordingSink struct {
+	requests []LogRequest
+}
+
+func (r *recordingSink) Send(_ context.Context, logReq LogRequest) error {
+	r.requests = append(r.requests, logReq)
+	return nil
+}
+
+func (r *recordingSink) Close() error {
+	return nil
+}
+
+func TestQueueOverflowPolicy(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer func(queue chan LogRequest, policy OverflowPolicy) {
+		WorkQueue = queue
+		QueueOverflowPolicy = policy
+	}(WorkQueue, QueueOverflowPolicy)
+
+	WorkQueue = make(chan LogRequest, 2)
+	QueueOverflowPolicy = OverflowDropNewest
+	dropped := DroppedOnOverflow()
+	for _, id := range []string{"1", "2"} {
+		g.Expect(QueueLogRequest(newTestLogRequest(id, "{}"))).To(gomega.Succeed())
+	}
+	g.Expect(QueueLogRequest(newTestLogRequest("3", "{}"))).NotTo(gomega.Succeed())
+	g.Expect(DroppedOnOverflow()).To(gomega.Equal(dropped + 1))
+	g.Expect((<-WorkQueue).Id).To(gomega.Equal("1"))
+	g.Expect((<-WorkQueue).Id).To(gomega.Equal("2"))
+
+	QueueOverflowPolicy = OverflowDropOldest
+	for _, id := range []string{"1", "2"} {
+		g.Expect(QueueLogRequest(newTestLogRequest(id, "{}"))).To(gomega.Succeed())
+	}
+	g.Expect(QueueLogRequest(newTestLogRequest("3", "{}"))).NotTo(gomega.Succeed())
+	g.Expect(DroppedOnOverflow()).To(gomega.Equal(dropped + 2))
+	g.Expect((<-WorkQueue).Id).To(gomega.Equal("2"))
+	g.Expect((<-WorkQueue).Id).To(gomega.Equal("3"))
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Sink delivers logged requests and responses to their destination.
+type Sink interface {
+	Send(ctx context.Context, logReq LogRequest) error
+	Close() error
+}
+
+type SinkOptions struct {
+	// BatchSize is the max number of events posted at once by the http-batch sink
+	BatchSize int
+	// BatchInterval is the max time an event waits in the http-batch sink before it is posted
+	BatchInterval time.Duration
+	// MaxFileSize is the size in bytes at which the file sink rotates its file
+	MaxFileSize int64
+	// MaxFileBackups is the number of rotated files kept by the file sink
+	MaxFileBackups int
+}
+
+var DefaultSinkOptions = SinkOptions{
+	BatchSize:      100,
+	BatchInterval:  time.Second,
+	MaxFileSize:    100 * 1024 * 1024,
+	MaxFileBackups: 5,
+}
+
+// NewSink creates the sink of the given type sending to logUrl.
+func NewSink(sinkType v1beta1.LoggerSinkType, logUrl *url.URL, opts SinkOptions, logger *zap.SugaredLogger) (Sink, error) {
+	switch sinkType {
+	case "", v1beta1.LoggerSinkCloudEvents:
+		return &CloudEventsSink{}, nil
+	case v1beta1.LoggerSinkCloudEventsStructured:
+		return &CloudEventsSink{Structured: true}, nil
+	case v1beta1.LoggerSinkHTTPBatch:
+		return NewBatchSink(logUrl, opts.BatchSize, opts.BatchInterval, logger), nil
+	case v1beta1.LoggerSinkKafka:
+		topic := strings.TrimPrefix(logUrl.Path, "/")
+		if logUrl.Host == "" || topic == "" {
+			return nil, fmt.Errorf("kafka log url must be of the form kafka://<broker>[,<broker>]/<topic>, got %s", logUrl)
+		}
+		return &KafkaSink{
+			Writer: &kafka.Writer{
+				Addr:     kafka.TCP(strings.Split(logUrl.Host, ",")...),
+				Topic:    topic,
+				Balancer: &kafka.Hash{},
+			},
+		}, nil
+	case v1beta1.LoggerSinkFile:
+		if logUrl.Path == "" {
+			return nil, fmt.Errorf("file log url must be of the form file:///<path>, got %s", logUrl)
+		}
+		return NewFileSink(logUrl.Path, opts.MaxFileSize, opts.MaxFileBackups)
+	}
+	return nil, fmt.Errorf("unsupported logger sink %s", sinkType)
+}
+
+// createEvent builds the CloudEvent for the logged payload.
+func createEvent(logReq LogRequest) (cloudevents.Event, error) {
+	event := cloudevents.NewEvent(cloudevents.VersionV1)
+	event.SetID(logReq.Id)
+	event.SetType(logReq.ReqType)
+	if logReq.Time.IsZero() {
+		event.SetTime(time.Now())
+	} else {
+		event.SetTime(logReq.Time)
+	}
+	event.SetExtension(InferenceServiceAttr, logReq.InferenceService)
+	event.SetExtension(NamespaceAttr, logReq.Namespace)
+	event.SetExtension(ComponentAttr, logReq.Component)
+	event.SetExtension(EndpointAttr, logReq.Endpoint)
+	if logReq.ModelName != "" {
+		event.SetExtension(ModelNameAttr, logReq.ModelName)
+	}
+	if logReq.ModelVersion != "" {
+		event.SetExtension(ModelVersionAttr, logReq.ModelVersion)
+	}
+	if logReq.ContentLength > 0 {
+		setIntegerExtension(&event, ContentLengthAttr, logReq.ContentLength)
+	}
+	if logReq.Truncated {
+		event.SetExtension(TruncatedAttr, true)
+	}
+	if logReq.StatusCode != 0 {
+		event.SetExtension(StatusCodeAttr, int32(logReq.StatusCode))
+	}
+	if logReq.ReqType != CEInferenceRequest {
+		setIntegerExtension(&event, LatencyAttr, logReq.Latency.Milliseconds())
+	}
+	event.SetSource(logReq.SourceUri.String())
+	if err := event.SetData(logReq.ContentType, *logReq.Bytes); err != nil {
+		return event, fmt.Errorf("while setting cloudevents data: %w", err)
+	}
+	return event, nil
+}
+
+// setIntegerExtension sets an integer extension, falling back to a string for values which do not
+// fit the 32 bit CloudEvents integer type.
+func setIntegerExtension(event *cloudevents.Event, name string, value int64) {
+	if value >= math.MinInt32 && value <= math.MaxInt32 {
+		event.SetExtension(name, int32(value))
+		return
+	}
+	event.SetExtension(name, strconv.FormatInt(value, 10))
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
+	"github.com/onsi/gomega"
+	"github.com/segmentio/kafka-go"
+	pkglogging "knative.dev/pkg/logging"
+)
+
+type fakeKafkaWriter struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+	closed   bool
+}
+
+func (f *fakeKafkaWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = append(f.messages, msgs...)
+	return nil
+}
+
+func (f *fakeKafkaWriter) Close() error {
+	f.closed = true
+	return nil
+}
+
+func newTestLogRequest(id string, body string) LogRequest {
+	sourceUri, _ := url.Parse("http://localhost:9081/")
+	bytes := []byte(body)
+	return LogRequest{
+		Bytes:            &bytes,
+		ContentType:      "application/json",
+		ReqType:          CEInferenceRequest,
+		Id:               id,
+		SourceUri:        sourceUri,
+		InferenceService: "mymodel",
+		Namespace:        "default",
+		Endpoint:         "default",
+		Component:        "predictor",
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	logger, _ := pkglogging.NewLogger("", "INFO")
+
+	kafkaUrl, _ := url.Parse("kafka://broker-1:9092,broker-2:9092/payloads")
+	sink, err := NewSink(v1beta1.LoggerSinkKafka, kafkaUrl, DefaultSinkOptions, logger)
+	g.Expect(err).To(gomega.BeNil())
+	writer := sink.(*KafkaSink).Writer.(*kafka.Writer)
+	g.Expect(writer.Topic).To(gomega.Equal("payloads"))
+	g.Expect(writer.Addr.String()).To(gomega.Equal("broker-1:9092,broker-2:9092"))
+
+	noTopicUrl, _ := url.Parse("kafka://broker-1:9092")
+	_, err = NewSink(v1beta1.LoggerSinkKafka, noTopicUrl, DefaultSinkOptions, logger)
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	_, err = NewSink("unknown", kafkaUrl, DefaultSinkOptions, logger)
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestKafkaSink(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	writer := &fakeKafkaWriter{}
+	sink := &KafkaSink{Writer: writer}
+	err := sink.Send(context.Background(), newTestLogRequest("1", `{"instances":[[1,2]]}`))
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(sink.Close()).To(gomega.Succeed())
+
+	g.Expect(writer.closed).To(gomega.BeTrue())
+	g.Expect(writer.messages).To(gomega.HaveLen(1))
+	msg := writer.messages[0]
+	g.Expect(string(msg.Key)).To(gomega.Equal("1"))
+	g.Expect(string(msg.Value)).To(gomega.Equal(`{"instances":[[1,2]]}`))
+	headers := map[string]string{}
+	for _, header := range msg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	g.Expect(headers).To(gomega.HaveKeyWithValue("ce_id", "1"))
+	g.Expect(headers).To(gomega.HaveKeyWithValue("ce_type", CEInferenceRequest))
+	g.Expect(headers).To(gomega.HaveKeyWithValue("ce_"+InferenceServiceAttr, "mymodel"))
+	g.Expect(headers).To(gomega.HaveKeyWithValue("content-type", "application/json"))
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tmpDir, err := os.MkdirTemp("", "file-sink")
+	g.Expect(err).To(gomega.BeNil())
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "logs", "events.jsonl")
+	sink, err := NewFileSink(path, 512, 2)
+	g.Expect(err).To(gomega.BeNil())
+	for i := 0; i < 10; i++ {
+		g.Expect(sink.Send(context.Background(), newTestLogRequest(fmt.Sprint(i), `{"instances":[[1,2]]}`))).To(gomega.Succeed())
+	}
+	g.Expect(sink.Close()).To(gomega.Succeed())
+
+	g.Expect(path).To(gomega.BeARegularFile())
+	g.Expect(path + ".1").To(gomega.BeARegularFile())
+	g.Expect(path + ".2").To(gomega.BeARegularFile())
+	g.Expect(path + ".3").NotTo(gomega.BeAnExistingFile())
+
+	// every line of the active file is a structured CloudEvent
+	file, err := os.Open(path)
+	g.Expect(err).To(gomega.BeNil())
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	lines := 0
+	for scanner.Scan() {
+		event := cloudevents.NewEvent()
+		g.Expect(json.Unmarshal(scanner.Bytes(), &event)).To(gomega.Succeed())
+		g.Expect(event.Type()).To(gomega.Equal(CEInferenceRequest))
+		lines++
+	}
+	g.Expect(lines).To(gomega.BeNumerically(">", 0))
+	info, err := os.Stat(path)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(info.Size()).To(gomega.BeNumerically("<=", 512))
+}
+
+func TestBatchSink(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	logger, _ := pkglogging.NewLogger("", "INFO")
+
+	batches := make(chan []cloudevents.Event, 10)
+	logSvc := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		g.Expect(req.Header.Get("Content-Type")).To(gomega.Equal(CloudEventsBatchContentType))
+		b, err := io.ReadAll(req.Body)
+		g.Expect(err).To(gomega.BeNil())
+		var events []cloudevents.Event
+		g.Expect(json.Unmarshal(b, &events)).To(gomega.Succeed())
+		batches <- events
+	}))
+	defer logSvc.Close()
+	logSvcUrl, err := url.Parse(logSvc.URL)
+	g.Expect(err).To(gomega.BeNil())
+
+	sink := NewBatchSink(logSvcUrl, 3, time.Hour, logger)
+	for i := 0; i < 4; i++ {
+		g.Expect(sink.Send(context.Background(), newTestLogRequest(fmt.Sprint(i), `{"instances":[[1,2]]}`))).To(gomega.Succeed())
+	}
+	// the first three events fill a batch
+	g.Expect(<-batches).To(gomega.HaveLen(3))
+	// the remaining event is flushed on close
+	g.Expect(sink.Close()).To(gomega.Succeed())
+	events := <-batches
+	g.Expect(events).To(gomega.HaveLen(1))
+	g.Expect(events[0].ID()).To(gomega.Equal("3"))
+}
+
+func TestBatchSinkTimedFlushFailure(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	logger, _ := pkglogging.NewLogger("", "INFO")
+
+	logSvc := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer logSvc.Close()
+	logSvcUrl, err := url.Parse(logSvc.URL)
+	g.Expect(err).To(gomega.BeNil())
+
+	// The requests of the batch which fails to post on the timer are handed to the failure handler
+	failed := make(chan []LogRequest, 1)
+	sink := NewBatchSink(logSvcUrl, 10, 10*time.Millisecond, logger)
+	sink.HandleFailures(func(ctx context.Context, requests []LogRequest, err error) {
+		g.Expect(err).To(gomega.HaveOccurred())
+		failed <- requests
+	})
+	for _, id := range []string{"1", "2"} {
+		g.Expect(sink.Send(context.Background(), newTestLogRequest(id, `{"instances":[[1,2]]}`))).To(gomega.Succeed())
+	}
+	var requests []LogRequest
+	g.Eventually(failed).Should(gomega.Receive(&requests))
+	g.Expect(requests).To(gomega.HaveLen(2))
+	g.Expect(requests[0].Id).To(gomega.Equal("1"))
+	g.Expect(requests[1].Id).To(gomega.Equal("2"))
+	g.Expect(sink.Close()).To(gomega.Succeed())
+}
+
+func TestStructuredCloudEventsSink(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	contentTypes := make(chan string, 1)
+	logSvc := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		contentTypes <- req.Header.Get("Content-Type")
+	}))
+	defer logSvc.Close()
+	logSvcUrl, err := url.Parse(logSvc.URL)
+	g.Expect(err).To(gomega.BeNil())
+
+	logReq := newTestLogRequest("1", `{"instances":[[1,2]]}`)
+	logReq.Url = logSvcUrl
+	sink := &CloudEventsSink{Structured: true}
+	g.Expect(sink.Send(context.Background(), logReq)).To(gomega.Succeed())
+	g.Expect(<-contentTypes).To(gomega.HavePrefix("application/cloudevents+json"))
+}
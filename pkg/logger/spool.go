@@ -0,0 +1,254 @@
+/*
+Copyright 2024 The KServe Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const spoolFileSuffix = ".json"
+
+// Spool persists log requests which could not be delivered to a directory, one file per request,
+// so they can be replayed once the sink recovers, including after a restart of the agent if the
+// directory outlives it. Once the spooled requests exceed MaxSize bytes the oldest ones are evicted and counted as dropped on failure.
+type Spool struct {
+	Dir string
+	// MaxSize is the max size in bytes of the spooled requests, unlimited if 0
+	MaxSize int64
+	mu      sync.Mutex
+	// evictMu serializes the evictions, which may run while the requests are replayed
+	evictMu sync.Mutex
+	seq     atomic.Uint64
+}
+
+// spoolRecord is the on-disk form of a LogRequest.
+type spoolRecord struct {
+	Url              string `json:"url,omitempty"`
+	Bytes            []byte `json:"bytes"`
+	ContentType      string `json:"contentType,omitempty"`
+	ReqType          string `json:"reqType"`
+	Id               string `json:"id"`
+	SourceUri        string `json:"sourceUri,omitempty"`
+	InferenceService string `json:"inferenceService,omitempty"`
+	Namespace        string `json:"namespace,omitempty"`
+	Component        string `json:"component,omitempty"`
+	Endpoint         string `json:"endpoint,omitempty"`
+	ModelName        string `json:"modelName,omitempty"`
+	ModelVersion     string `json:"modelVersion,omitempty"`
+	ContentLength    int64  `json:"contentLength,omitempty"`
+	Truncated        bool   `json:"truncated,omitempty"`
+	StatusCode       int    `json:"statusCode,omitempty"`
+	// Latency is in nanoseconds
+	Latency int64 `json:"latency,omitempty"`
+	// Time is when the request or the response was received, so replayed events keep their time
+	Time time.Time `json:"time"`
+}
+
+func NewSpool(dir string, maxSize int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("while creating spool directory: %w", err)
+	}
+	return &Spool{Dir: filepath.Clean(dir), MaxSize: maxSize}, nil
+}
+
+// Write persists the log request. The file is written under a temporary name and renamed once
+// complete so a partially written request is never replayed.
+func (s *Spool) Write(logReq LogRequest) error {
+	record := spoolRecord{
+		ContentType:      logReq.ContentType,
+		ReqType:          logReq.ReqType,
+		Id:               logReq.Id,
+		InferenceService: logReq.InferenceService,
+		Namespace:        logReq.Namespace,
+		Component:        logReq.Component,
+		Endpoint:         logReq.Endpoint,
+		ModelName:        logReq.ModelName,
+		ModelVersion:     logReq.ModelVersion,
+		ContentLength:    logReq.ContentLength,
+		Truncated:        logReq.Truncated,
+		StatusCode:       logReq.StatusCode,
+		Latency:          int64(logReq.Latency),
+		Time:             logReq.Time,
+	}
+	if logReq.Url != nil {
+		record.Url = logReq.Url.String()
+	}
+	if logReq.SourceUri != nil {
+		record.SourceUri = logReq.SourceUri.String()
+	}
+	if logReq.Bytes != nil {
+		record.Bytes = *logReq.Bytes
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("while encoding spooled request: %w", err)
+	}
+	// The timestamp prefix keeps the replay in the order the requests were spooled
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), s.seq.Add(1)%1000000, spoolFileSuffix)
+	tmp := filepath.Join(s.Dir, "."+name)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("while writing spooled request: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.Dir, name)); err != nil {
+		return fmt.Errorf("while writing spooled request: %w", err)
+	}
+	if s.MaxSize > 0 {
+		return s.evict()
+	}
+	return nil
+}
+
+// evict removes the oldest spooled requests until they fit in MaxSize.
+func (s *Spool) evict() error {
+	s.evictMu.Lock()
+	defer s.evictMu.Unlock()
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	sizes := make([]int64, len(files))
+	var total int64
+	for i, name := range files {
+		info, err := os.Stat(filepath.Join(s.Dir, name))
+		if err != nil {
+			// the request was replayed in the meantime
+			continue
+		}
+		sizes[i] = info.Size()
+		total += sizes[i]
+	}
+	for i := 0; i < len(files) && total > s.MaxSize; i++ {
+		if err := os.Remove(filepath.Join(s.Dir, files[i])); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("while evicting spooled request: %w", err)
+		}
+		total -= sizes[i]
+		droppedFailed.Add(1)
+	}
+	return nil
+}
+
+// Len returns the number of spooled requests.
+func (s *Spool) Len() (int, error) {
+	files, err := s.files()
+	return len(files), err
+}
+
+func (s *Spool) files() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("while reading spool directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, spoolFileSuffix) {
+			continue
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Replay sends the spooled requests to the sink, oldest first, removing each one once it has
+// been delivered. It stops at the first request which fails to send. Spooled requests which
+// can not be decoded are removed, the ones evicted in the meantime are skipped.
+func (s *Spool) Replay(ctx context.Context, sink Sink) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, err := s.files()
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, name := range files {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		path := filepath.Join(s.Dir, name)
+		logReq, err := readSpoolFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			_ = os.Remove(path)
+			return sent, err
+		}
+		if err := sink.Send(ctx, logReq); err != nil {
+			return sent, err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return sent, fmt.Errorf("while removing spooled request: %w", err)
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func readSpoolFile(path string) (LogRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return LogRequest{}, fmt.Errorf("while reading spooled request: %w", err)
+	}
+	record := spoolRecord{}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return LogRequest{}, fmt.Errorf("while decoding spooled request %s: %w", filepath.Base(path), err)
+	}
+	logReq := LogRequest{
+		Bytes:            &record.Bytes,
+		ContentType:      record.ContentType,
+		ReqType:          record.ReqType,
+		Id:               record.Id,
+		InferenceService: record.InferenceService,
+		Namespace:        record.Namespace,
+		Component:        record.Component,
+		Endpoint:         record.Endpoint,
+		ModelName:        record.ModelName,
+		ModelVersion:     record.ModelVersion,
+		ContentLength:    record.ContentLength,
+		Truncated:        record.Truncated,
+		StatusCode:       record.StatusCode,
+		Latency:          time.Duration(record.Latency),
+		Time:             record.Time,
+	}
+	if record.Url != "" {
+		if logReq.Url, err = url.Parse(record.Url); err != nil {
+			return LogRequest{}, fmt.Errorf("while decoding spooled request %s: %w", filepath.Base(path), err)
+		}
+	}
+	if record.SourceUri != "" {
+		if logReq.SourceUri, err = url.Parse(record.SourceUri); err != nil {
+			return LogRequest{}, fmt.Errorf("while decoding spooled request %s: %w", filepath.Base(path), err)
+		}
+	}
+	return logReq, nil
+}
@@ -18,6 +18,7 @@ package logger
 
 import (
 	"net/url"
+	"time"
 )
 
 type LogRequest struct {
@@ -31,4 +32,16 @@ type LogRequest struct {
 	Namespace        string
 	Component        string
 	Endpoint         string
+	ModelName        string
+	ModelVersion     string
+	// ContentLength is the size of the payload before truncation
+	ContentLength int64
+	// Truncated is true if Bytes only holds the start of the payload
+	Truncated bool
+	// StatusCode and Latency are only set on response and combined inference events
+	StatusCode int
+	Latency    time.Duration
+	// Time is when the request or the response was received, the event is stamped with the time it
+	// is sent if zero
+	Time time.Time
 }
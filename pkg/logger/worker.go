@@ -19,14 +19,16 @@ package logger
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 
-	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"go.uber.org/zap"
 )
 
 const (
 	CEInferenceRequest  = "org.kubeflow.serving.inference.request"
 	CEInferenceResponse = "org.kubeflow.serving.inference.response"
+	// CEInference events hold both the request and the response, see v1beta1.LogCombined
+	CEInference = "org.kubeflow.serving.inference"
 
 	// cloud events extension attributes have to be lowercase alphanumeric
 	//TODO: ideally request id would have its own header but make do with ce-id for now
@@ -35,23 +37,90 @@ const (
 	ComponentAttr        = "component"
 	//endpoint would be either default or canary
 	EndpointAttr = "endpoint"
+	// set on events whose payload was truncated to the maximum logged payload size
+	TruncatedAttr     = "truncated"
+	ModelNameAttr     = "modelname"
+	ModelVersionAttr  = "modelversion"
+	ContentLengthAttr = "contentlength"
+	StatusCodeAttr    = "statuscode"
+	// latency in milliseconds between receiving the request and sending the whole response
+	LatencyAttr = "latencyms"
 
 	LoggerWorkerQueueSize = 100
 	CloudEventsIdHeader   = "Ce-Id"
 )
 
+// OverflowPolicy controls what happens to a log request when the work queue is full
+type OverflowPolicy string
+
+const (
+	// OverflowBlock blocks the inference request until there is room in the queue
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest drops the oldest queued log request to make room
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowDropNewest drops the log request being queued
+	OverflowDropNewest OverflowPolicy = "drop-newest"
+)
+
 // A buffered channel that we can send work requests on.
 var WorkQueue = make(chan LogRequest, LoggerWorkerQueueSize)
 
+// QueueOverflowPolicy is applied by QueueLogRequest once WorkQueue is full.
+var QueueOverflowPolicy = OverflowBlock
+
+var (
+	droppedOverflow atomic.Uint64
+	droppedFailed   atomic.Uint64
+)
+
+// DroppedOnOverflow returns the number of log requests dropped because the work queue was full.
+func DroppedOnOverflow() uint64 {
+	return droppedOverflow.Load()
+}
+
+// DroppedOnFailure returns the number of log requests dropped because they could not be delivered.
+func DroppedOnFailure() uint64 {
+	return droppedFailed.Load()
+}
+
 func QueueLogRequest(req LogRequest) error {
-	WorkQueue <- req
-	return nil
+	switch QueueOverflowPolicy {
+	case OverflowDropNewest:
+		select {
+		case WorkQueue <- req:
+			return nil
+		default:
+			droppedOverflow.Add(1)
+			return fmt.Errorf("log queue is full, dropped log request %s", req.Id)
+		}
+	case OverflowDropOldest:
+		var dropped []string
+		for {
+			select {
+			case WorkQueue <- req:
+				if len(dropped) > 0 {
+					return fmt.Errorf("log queue is full, dropped log requests %v", dropped)
+				}
+				return nil
+			default:
+			}
+			select {
+			case oldest := <-WorkQueue:
+				droppedOverflow.Add(1)
+				dropped = append(dropped, oldest.Id)
+			default:
+			}
+		}
+	default:
+		WorkQueue <- req
+		return nil
+	}
 }
 
-// NewWorker creates, and returns a new Worker object. Its only argument
-// is a channel that the worker can add itself to whenever it is done its
-// work.
-func NewWorker(id int, workerQueue chan chan LogRequest, logger *zap.SugaredLogger) Worker {
+// NewWorker creates, and returns a new Worker object. workerQueue is a
+// channel that the worker can add itself to whenever it is done its work,
+// and ctx is passed to the sink for every log request the worker sends.
+func NewWorker(ctx context.Context, id int, workerQueue chan chan LogRequest, sink Sink, logger *zap.SugaredLogger) Worker {
 	// Create, and return the worker.
 	return Worker{
 		Log:         logger,
@@ -59,7 +128,9 @@ func NewWorker(id int, workerQueue chan chan LogRequest, logger *zap.SugaredLogg
 		Work:        make(chan LogRequest),
 		WorkerQueue: workerQueue,
 		QuitChan:    make(chan bool),
-		CeCtx:       cloudevents.WithEncodingBinary(context.Background()),
+		Sink:        sink,
+		ctx:         ctx,
+		stopped:     make(chan struct{}),
 	}
 }
 
@@ -69,48 +140,17 @@ type Worker struct {
 	Work        chan LogRequest
 	WorkerQueue chan chan LogRequest
 	QuitChan    chan bool
-	CeCtx       context.Context
-}
-
-func (w *Worker) sendCloudEvent(logReq LogRequest) error {
-	t, err := cloudevents.NewHTTP(
-		cloudevents.WithTarget(logReq.Url.String()),
-	)
-
-	if err != nil {
-		return fmt.Errorf("while creating http transport: %w", err)
-	}
-
-	c, err := cloudevents.NewClient(t,
-		cloudevents.WithTimeNow(),
-	)
-	if err != nil {
-		return fmt.Errorf("while creating new cloudevents client: %w", err)
-	}
-	event := cloudevents.NewEvent(cloudevents.VersionV1)
-	event.SetID(logReq.Id)
-	event.SetType(logReq.ReqType)
-
-	event.SetExtension(InferenceServiceAttr, logReq.InferenceService)
-	event.SetExtension(NamespaceAttr, logReq.Namespace)
-	event.SetExtension(ComponentAttr, logReq.Component)
-	event.SetExtension(EndpointAttr, logReq.Endpoint)
-
-	event.SetSource(logReq.SourceUri.String())
-	if err := event.SetData(logReq.ContentType, *logReq.Bytes); err != nil {
-		return fmt.Errorf("while setting cloudevents data: %w", err)
-	}
-
-	if result := c.Send(w.CeCtx, event); cloudevents.IsUndelivered(result) {
-		return fmt.Errorf("while sending event: %w", result)
-	}
-	return nil
+	Sink        Sink
+	ctx         context.Context
+	// stopped is closed once the worker has stopped
+	stopped chan struct{}
 }
 
 // This function "starts" the worker by starting a goroutine, that is
 // an infinite "for-select" loop.
 func (w *Worker) Start() {
 	go func() {
+		defer close(w.stopped)
 		for {
 			// Add ourselves into the worker queue.
 			w.WorkerQueue <- w.Work
@@ -118,15 +158,16 @@ func (w *Worker) Start() {
 			select {
 			case work := <-w.Work:
 				// Receive a work request.
-				w.Log.Infof("Received work request %d, url: %s, requestId: %s", w.ID, work.Url.String(), work.Id)
+				w.Log.Infof("Received work request %d, url: %s, requestId: %s", w.ID, work.Url, work.Id)
 
-				if err := w.sendCloudEvent(work); err != nil {
-					w.Log.Error(err, "Failed to send cloud event, url: %s", work.Url.String())
+				if err := w.Sink.Send(w.ctx, work); err != nil {
+					droppedFailed.Add(1)
+					w.Log.Errorf("Failed to send cloud event, url: %s: %v", work.Url, err)
 				}
 
 			case <-w.QuitChan:
 				// We have been asked to stop.
-				fmt.Printf("worker %d stopping\n", w.ID)
+				w.Log.Infof("Worker %d stopping", w.ID)
 				return
 			}
 		}
@@ -19,10 +19,14 @@ package pod
 import (
 	"encoding/json"
 	"fmt"
+	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/intstr"
 
+	"github.com/kserve/kserve/pkg/agent/storage"
 	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
 	"github.com/kserve/kserve/pkg/constants"
 	"github.com/kserve/kserve/pkg/credentials"
@@ -39,6 +43,30 @@ const (
 	LoggerArgumentNamespace        = "--namespace"
 	LoggerArgumentEndpoint         = "--endpoint"
 	LoggerArgumentComponent        = "--component"
+	LoggerArgumentSinkType         = "--log-sink"
+	LoggerArgumentFilter           = "--log-filter"
+	LoggerArgumentRedaction        = "--log-redaction"
+	LoggerArgumentMaxRetries       = "--log-max-retries"
+	LoggerArgumentSpoolDir         = "--log-spool-dir"
+	LoggerArgumentSpoolMaxSize     = "--log-spool-max-size"
+	LoggerArgumentOverflowPolicy   = "--log-overflow-policy"
+	LoggerArgumentMaxPayloadSize   = "--log-max-payload-size"
+)
+
+const (
+	AgentArgumentModelRepository         = "--model-repository"
+	AgentArgumentModelRepositoryEndpoint = "--model-repository-endpoint"
+	AgentArgumentModelCacheDir           = "--model-cache-dir"
+	AgentArgumentModelCacheSize          = "--model-cache-size"
+	AgentArgumentDownloadConcurrency     = "--download-concurrency"
+	AgentArgumentDownloadOrder           = "--download-order"
+	AgentArgumentBandwidthLimits         = "--download-bandwidth-limits"
+	AgentArgumentModelMaxAttempts        = "--model-max-attempts"
+	AgentArgumentModelInitialBackoff     = "--model-initial-backoff"
+	AgentArgumentModelMaxBackoff         = "--model-max-backoff"
+	AgentArgumentArchiveMaxSize          = "--archive-max-size"
+	AgentArgumentArchiveMaxFiles         = "--archive-max-files"
+	AgentArgumentFileRoot                = "--file-root"
 )
 
 type AgentConfig struct {
@@ -47,6 +75,29 @@ type AgentConfig struct {
 	CpuLimit      string `json:"cpuLimit"`
 	MemoryRequest string `json:"memoryRequest"`
 	MemoryLimit   string `json:"memoryLimit"`
+	// StoragePlugins provide the custom storage protocols of the models pulled by the agent
+	StoragePlugins []storage.PluginConfig `json:"storagePlugins,omitempty"`
+	// ModelCacheDir is the directory of the cache of the artifacts shared by the models, under the
+	// model directory. The cache is disabled if it is empty.
+	ModelCacheDir string `json:"modelCacheDir,omitempty"`
+	// ModelCacheSize is the size budget of the model cache, e.g. 10Gi
+	ModelCacheSize string `json:"modelCacheSize,omitempty"`
+	// DownloadConcurrency is the max number of models downloaded at once
+	DownloadConcurrency int `json:"downloadConcurrency,omitempty"`
+	// DownloadOrder is the order the waiting models are downloaded in, one of fifo, smallest-first or priority
+	DownloadOrder string `json:"downloadOrder,omitempty"`
+	// DownloadBandwidthLimits are the download throughput limits per storage provider, e.g. s3=100Mi,gs=50Mi
+	DownloadBandwidthLimits string `json:"downloadBandwidthLimits,omitempty"`
+	// ModelMaxAttempts is the number of times a model download and load is attempted
+	ModelMaxAttempts int `json:"modelMaxAttempts,omitempty"`
+	// ModelInitialBackoff and ModelMaxBackoff bound the backoff between the attempts, e.g. 10s
+	ModelInitialBackoff string `json:"modelInitialBackoff,omitempty"`
+	ModelMaxBackoff     string `json:"modelMaxBackoff,omitempty"`
+	// ArchiveMaxSize and ArchiveMaxFiles limit the files extracted from a model archive or modelcar image
+	ArchiveMaxSize  string `json:"archiveMaxSize,omitempty"`
+	ArchiveMaxFiles int    `json:"archiveMaxFiles,omitempty"`
+	// FileRoot is the directory the file:// models must be under, file:// models are disabled if it is empty
+	FileRoot string `json:"fileRoot,omitempty"`
 }
 
 type LoggerConfig struct {
@@ -56,6 +107,17 @@ type LoggerConfig struct {
 	MemoryRequest string `json:"memoryRequest"`
 	MemoryLimit   string `json:"memoryLimit"`
 	DefaultUrl    string `json:"defaultUrl"`
+	// MaxRetries is the number of times a failed log event delivery is retried, the agent default is used if nil
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+	// SpoolDir is the directory undeliverable log events are spooled to, spooling is disabled if empty.
+	// It is an emptyDir volume, which is lost when the pod is deleted or rescheduled
+	SpoolDir string `json:"spoolDir,omitempty"`
+	// SpoolMaxSize is the max size in bytes of the spooled log events, the agent default is used if 0
+	SpoolMaxSize int64 `json:"spoolMaxSize,omitempty"`
+	// OverflowPolicy is applied once the agent log queue is full, one of block, drop-oldest or drop-newest
+	OverflowPolicy string `json:"overflowPolicy,omitempty"`
+	// MaxPayloadSize is the max size in bytes of the logged payloads, larger payloads are truncated, unlimited if 0
+	MaxPayloadSize int64 `json:"maxPayloadSize,omitempty"`
 }
 
 type AgentInjector struct {
@@ -87,6 +149,27 @@ func getAgentConfigs(configMap *v1.ConfigMap) (*AgentConfig, error) {
 				constants.AgentConfigMapKeyName, err.Error())
 		}
 	}
+	for _, plugin := range agentConfig.StoragePlugins {
+		if err := plugin.Validate(); err != nil {
+			return agentConfig, fmt.Errorf("invalid storage plugin configuration for %q: %w", constants.AgentConfigMapKeyName, err)
+		}
+	}
+	for _, size := range []string{agentConfig.ModelCacheSize, agentConfig.ArchiveMaxSize} {
+		if size == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(size); err != nil {
+			return agentConfig, fmt.Errorf("failed to parse size configuration for %q: %w", constants.AgentConfigMapKeyName, err)
+		}
+	}
+	for _, backoff := range []string{agentConfig.ModelInitialBackoff, agentConfig.ModelMaxBackoff} {
+		if backoff == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(backoff); err != nil {
+			return agentConfig, fmt.Errorf("failed to parse backoff configuration for %q: %w", constants.AgentConfigMapKeyName, err)
+		}
+	}
 
 	return agentConfig, nil
 }
@@ -116,6 +199,49 @@ func getLoggerConfigs(configMap *v1.ConfigMap) (*LoggerConfig, error) {
 	return loggerConfig, nil
 }
 
+// pullerArgs returns the arguments of the model puller set in the agent config, and the repository
+// API of the model server set on the InferenceService.
+func (ag *AgentInjector) pullerArgs(pod *v1.Pod) []string {
+	var args []string
+	if repository, ok := pod.ObjectMeta.Annotations[constants.ModelRepositoryAnnotationKey]; ok {
+		args = append(args, AgentArgumentModelRepository, repository)
+	}
+	if endpoint, ok := pod.ObjectMeta.Annotations[constants.ModelRepositoryEndpointAnnotationKey]; ok {
+		args = append(args, AgentArgumentModelRepositoryEndpoint, endpoint)
+	}
+	config := ag.agentConfig
+	for _, arg := range []struct {
+		name  string
+		value string
+	}{
+		{AgentArgumentModelCacheDir, config.ModelCacheDir},
+		{AgentArgumentModelCacheSize, config.ModelCacheSize},
+		{AgentArgumentDownloadOrder, config.DownloadOrder},
+		{AgentArgumentBandwidthLimits, config.DownloadBandwidthLimits},
+		{AgentArgumentModelInitialBackoff, config.ModelInitialBackoff},
+		{AgentArgumentModelMaxBackoff, config.ModelMaxBackoff},
+		{AgentArgumentArchiveMaxSize, config.ArchiveMaxSize},
+		{AgentArgumentFileRoot, config.FileRoot},
+	} {
+		if arg.value != "" {
+			args = append(args, arg.name, arg.value)
+		}
+	}
+	for _, arg := range []struct {
+		name  string
+		value int
+	}{
+		{AgentArgumentDownloadConcurrency, config.DownloadConcurrency},
+		{AgentArgumentModelMaxAttempts, config.ModelMaxAttempts},
+		{AgentArgumentArchiveMaxFiles, config.ArchiveMaxFiles},
+	} {
+		if arg.value > 0 {
+			args = append(args, arg.name, strconv.Itoa(arg.value))
+		}
+	}
+	return args
+}
+
 func (ag *AgentInjector) InjectAgent(pod *v1.Pod) error {
 	// Only inject the model agent sidecar if the required annotations are set
 	_, injectLogger := pod.ObjectMeta.Annotations[constants.LoggerInternalAnnotationKey]
@@ -147,6 +273,14 @@ func (ag *AgentInjector) InjectAgent(pod *v1.Pod) error {
 			args = append(args, constants.AgentModelDirArgName)
 			args = append(args, modelDir)
 		}
+		if len(ag.agentConfig.StoragePlugins) != 0 {
+			plugins, err := json.Marshal(ag.agentConfig.StoragePlugins)
+			if err != nil {
+				return err
+			}
+			args = append(args, constants.AgentStoragePluginsArgName, string(plugins))
+		}
+		args = append(args, ag.pullerArgs(pod)...)
 	}
 	// Only inject if the batcher required annotations are set
 	if injectBatcher {
@@ -162,6 +296,10 @@ func (ag *AgentInjector) InjectAgent(pod *v1.Pod) error {
 			args = append(args, BatcherArgumentMaxLatency)
 			args = append(args, maxLatency)
 		}
+
+		if pod.ObjectMeta.Annotations[constants.BatcherPartialFailureInternalAnnotationKey] == "true" {
+			args = append(args, BatcherArgumentPartialFailure)
+		}
 	}
 	// Only inject if the logger required annotations are set
 	if injectLogger {
@@ -196,6 +334,30 @@ func (ag *AgentInjector) InjectAgent(pod *v1.Pod) error {
 			LoggerArgumentComponent,
 			component,
 		}
+		if sinkType, ok := pod.ObjectMeta.Annotations[constants.LoggerSinkTypeInternalAnnotationKey]; ok {
+			loggerArgs = append(loggerArgs, LoggerArgumentSinkType, sinkType)
+		}
+		if filter, ok := pod.ObjectMeta.Annotations[constants.LoggerFilterInternalAnnotationKey]; ok {
+			loggerArgs = append(loggerArgs, LoggerArgumentFilter, filter)
+		}
+		if redaction, ok := pod.ObjectMeta.Annotations[constants.LoggerRedactionInternalAnnotationKey]; ok {
+			loggerArgs = append(loggerArgs, LoggerArgumentRedaction, redaction)
+		}
+		if ag.loggerConfig.MaxRetries != nil {
+			loggerArgs = append(loggerArgs, LoggerArgumentMaxRetries, strconv.Itoa(int(*ag.loggerConfig.MaxRetries)))
+		}
+		if ag.loggerConfig.SpoolDir != "" {
+			loggerArgs = append(loggerArgs, LoggerArgumentSpoolDir, ag.loggerConfig.SpoolDir)
+			if ag.loggerConfig.SpoolMaxSize > 0 {
+				loggerArgs = append(loggerArgs, LoggerArgumentSpoolMaxSize, strconv.FormatInt(ag.loggerConfig.SpoolMaxSize, 10))
+			}
+		}
+		if ag.loggerConfig.OverflowPolicy != "" {
+			loggerArgs = append(loggerArgs, LoggerArgumentOverflowPolicy, ag.loggerConfig.OverflowPolicy)
+		}
+		if ag.loggerConfig.MaxPayloadSize > 0 {
+			loggerArgs = append(loggerArgs, LoggerArgumentMaxPayloadSize, strconv.FormatInt(ag.loggerConfig.MaxPayloadSize, 10))
+		}
 		args = append(args, loggerArgs...)
 	}
 
@@ -293,6 +455,18 @@ func (ag *AgentInjector) InjectAgent(pod *v1.Pod) error {
 	// Add container to the spec
 	pod.Spec.Containers = append(pod.Spec.Containers, *agentContainer)
 
+	if injectLogger && ag.loggerConfig.SpoolDir != "" {
+		// Mount an emptyDir volume for the log spool, so spooled events survive the restarts of the agent
+		// container, but not the rescheduling of the pod
+		spoolVolume := v1.Volume{
+			Name: constants.LoggerSpoolVolumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		}
+		mountVolumeToContainer(constants.AgentContainerName, pod, spoolVolume, ag.loggerConfig.SpoolDir)
+	}
+
 	if _, ok := pod.ObjectMeta.Annotations[constants.AgentShouldInjectAnnotationKey]; ok {
 		// Mount the modelDir volume to the pod and model agent container
 		err := mountModelDir(pod)
@@ -304,6 +478,10 @@ func (ag *AgentInjector) InjectAgent(pod *v1.Pod) error {
 		if err != nil {
 			return err
 		}
+		// Mount the PVCs of the pvc:// models to the pod, model agent and model server containers
+		mountModelPvcs(pod)
+		// Mount the image pull secrets of the pod to the model agent container to pull the oci:// models
+		mountOCIPullSecrets(pod)
 	}
 
 	return nil
@@ -344,6 +522,72 @@ func mountModelConfig(pod *v1.Pod) error {
 	return fmt.Errorf("can not find %v label", constants.AgentModelConfigVolumeNameAnnotationKey)
 }
 
+// mountModelPvcs mounts the PVCs listed in the model PVCs annotation read only at the same path into the
+// model agent and model server containers, so that the models linked by the agent resolve in both.
+func mountModelPvcs(pod *v1.Pod) {
+	pvcs, ok := pod.ObjectMeta.Annotations[constants.ModelPvcsAnnotationKey]
+	if !ok {
+		return
+	}
+	index := 0
+	for _, pvcName := range strings.Split(pvcs, ",") {
+		pvcName = strings.TrimSpace(pvcName)
+		if pvcName == "" {
+			continue
+		}
+		pvcVolume := v1.Volume{
+			Name: fmt.Sprintf("%s%d", constants.ModelPvcVolumeNamePrefix, index),
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: pvcName,
+					ReadOnly:  true,
+				},
+			},
+		}
+		index++
+		mountPath := path.Join(constants.ModelPvcMountRoot, pvcName)
+		pod.Spec.Volumes = appendVolume(pod.Spec.Volumes, pvcVolume)
+		for i := range pod.Spec.Containers {
+			container := &pod.Spec.Containers[i]
+			if container.Name == constants.AgentContainerName || container.Name == constants.InferenceServiceContainerName {
+				container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+					Name:      pvcVolume.Name,
+					ReadOnly:  true,
+					MountPath: mountPath,
+				})
+			}
+		}
+	}
+}
+
+// mountOCIPullSecrets mounts the image pull secrets of the pod read only by name into the model agent container.
+func mountOCIPullSecrets(pod *v1.Pod) {
+	for i, pullSecret := range pod.Spec.ImagePullSecrets {
+		if pullSecret.Name == "" {
+			continue
+		}
+		secretVolume := v1.Volume{
+			Name: fmt.Sprintf("%s%d", constants.OCIPullSecretVolumeNamePrefix, i),
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: pullSecret.Name,
+				},
+			},
+		}
+		pod.Spec.Volumes = appendVolume(pod.Spec.Volumes, secretVolume)
+		for j := range pod.Spec.Containers {
+			container := &pod.Spec.Containers[j]
+			if container.Name == constants.AgentContainerName {
+				container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+					Name:      secretVolume.Name,
+					ReadOnly:  true,
+					MountPath: path.Join(constants.OCIPullSecretsMountRoot, pullSecret.Name),
+				})
+			}
+		}
+	}
+}
+
 func mountVolumeToContainer(containerName string, pod *v1.Pod, additionalVolume v1.Volume, mountPath string) {
 	pod.Spec.Volumes = appendVolume(pod.Spec.Volumes, additionalVolume)
 	mountedContainers := make([]v1.Container, 0, len(pod.Spec.Containers))
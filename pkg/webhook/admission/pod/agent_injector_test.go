@@ -22,13 +22,16 @@ import (
 
 	"github.com/kserve/kserve/pkg/apis/serving/v1beta1"
 	"github.com/kserve/kserve/pkg/credentials"
+	"github.com/kserve/kserve/pkg/credentials/https"
 	"github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
+	"google.golang.org/protobuf/proto"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"knative.dev/pkg/kmp"
 
+	"github.com/kserve/kserve/pkg/agent/storage"
 	"github.com/kserve/kserve/pkg/constants"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -350,6 +353,141 @@ func TestAgentInjector(t *testing.T) {
 				},
 			},
 		},
+		"AddLoggerWithSink": {
+			original: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deployment",
+					Namespace: "default",
+					Annotations: map[string]string{
+						constants.LoggerInternalAnnotationKey:          "true",
+						constants.LoggerSinkUrlInternalAnnotationKey:   "kafka://broker:9092/payloads",
+						constants.LoggerModeInternalAnnotationKey:      string(v1beta1.LogAll),
+						constants.LoggerSinkTypeInternalAnnotationKey:  string(v1beta1.LoggerSinkKafka),
+						constants.LoggerFilterInternalAnnotationKey:    `{"samplingPercentage":1}`,
+						constants.LoggerRedactionInternalAnnotationKey: `{"drop":["instances.*.notes"]}`,
+					},
+					Labels: map[string]string{
+						"serving.kserve.io/inferenceservice": "sklearn",
+						constants.KServiceModelLabel:         "sklearn",
+						constants.KServiceEndpointLabel:      "default",
+						constants.KServiceComponentLabel:     "predictor",
+					},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name: "sklearn",
+							ReadinessProbe: &v1.Probe{
+								ProbeHandler: v1.ProbeHandler{
+									TCPSocket: &v1.TCPSocketAction{
+										Port: intstr.IntOrString{
+											IntVal: 8080,
+										},
+									},
+								},
+								InitialDelaySeconds: 0,
+								TimeoutSeconds:      1,
+								PeriodSeconds:       10,
+								SuccessThreshold:    1,
+								FailureThreshold:    3,
+							},
+						},
+						{
+							Name: "queue-proxy",
+							Env:  []v1.EnvVar{{Name: "SERVING_READINESS_PROBE", Value: "{\"tcpSocket\":{\"port\":8080},\"timeoutSeconds\":1,\"periodSeconds\":10,\"successThreshold\":1,\"failureThreshold\":3}"}},
+						},
+					},
+				},
+			},
+			expected: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "deployment",
+					Annotations: map[string]string{
+						constants.LoggerInternalAnnotationKey:          "true",
+						constants.LoggerSinkUrlInternalAnnotationKey:   "kafka://broker:9092/payloads",
+						constants.LoggerModeInternalAnnotationKey:      string(v1beta1.LogAll),
+						constants.LoggerSinkTypeInternalAnnotationKey:  string(v1beta1.LoggerSinkKafka),
+						constants.LoggerFilterInternalAnnotationKey:    `{"samplingPercentage":1}`,
+						constants.LoggerRedactionInternalAnnotationKey: `{"drop":["instances.*.notes"]}`,
+					},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name: "sklearn",
+							ReadinessProbe: &v1.Probe{
+								ProbeHandler: v1.ProbeHandler{
+									TCPSocket: &v1.TCPSocketAction{
+										Port: intstr.IntOrString{
+											IntVal: 8080,
+										},
+									},
+								},
+								InitialDelaySeconds: 0,
+								TimeoutSeconds:      1,
+								PeriodSeconds:       10,
+								SuccessThreshold:    1,
+								FailureThreshold:    3,
+							},
+						},
+						{
+							Name: "queue-proxy",
+							Env:  []v1.EnvVar{{Name: "SERVING_READINESS_PROBE", Value: "{\"tcpSocket\":{\"port\":8080},\"timeoutSeconds\":1,\"periodSeconds\":10,\"successThreshold\":1,\"failureThreshold\":3}"}},
+						},
+						{
+							Name:  constants.AgentContainerName,
+							Image: loggerConfig.Image,
+							Args: []string{
+								LoggerArgumentLogUrl,
+								"kafka://broker:9092/payloads",
+								LoggerArgumentSourceUri,
+								"deployment",
+								LoggerArgumentMode,
+								"all",
+								LoggerArgumentInferenceService,
+								"sklearn",
+								LoggerArgumentNamespace,
+								"default",
+								LoggerArgumentEndpoint,
+								"default",
+								LoggerArgumentComponent,
+								"predictor",
+								LoggerArgumentSinkType,
+								"kafka",
+								LoggerArgumentFilter,
+								`{"samplingPercentage":1}`,
+								LoggerArgumentRedaction,
+								`{"drop":["instances.*.notes"]}`,
+							},
+							Ports: []v1.ContainerPort{
+								{
+									Name:          "agent-port",
+									ContainerPort: constants.InferenceServiceDefaultAgentPort,
+									Protocol:      "TCP",
+								},
+							},
+							Env:       []v1.EnvVar{{Name: "SERVING_READINESS_PROBE", Value: "{\"tcpSocket\":{\"port\":8080},\"timeoutSeconds\":1,\"periodSeconds\":10,\"successThreshold\":1,\"failureThreshold\":3}"}},
+							Resources: agentResourceRequirement,
+							ReadinessProbe: &v1.Probe{
+								ProbeHandler: v1.ProbeHandler{
+									HTTPGet: &v1.HTTPGetAction{
+										HTTPHeaders: []v1.HTTPHeader{
+											{
+												Name:  "K-Network-Probe",
+												Value: "queue",
+											},
+										},
+										Port:   intstr.FromInt(9081),
+										Path:   "/",
+										Scheme: "HTTP",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		"DoNotAddLogger": {
 			original: &v1.Pod{
 				ObjectMeta: metav1.ObjectMeta{
@@ -486,6 +624,123 @@ func TestAgentInjector(t *testing.T) {
 				},
 			},
 		},
+		"AddBatcherWithPartialFailure": {
+			original: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deployment",
+					Namespace: "default",
+					Annotations: map[string]string{
+						constants.BatcherInternalAnnotationKey:               "true",
+						constants.BatcherMaxLatencyInternalAnnotationKey:     "100",
+						constants.BatcherMaxBatchSizeInternalAnnotationKey:   "30",
+						constants.BatcherPartialFailureInternalAnnotationKey: "true",
+					},
+					Labels: map[string]string{
+						"serving.kserve.io/inferenceservice": "sklearn",
+						constants.KServiceModelLabel:         "sklearn",
+						constants.KServiceEndpointLabel:      "default",
+						constants.KServiceComponentLabel:     "predictor",
+					},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name: "sklearn",
+							ReadinessProbe: &v1.Probe{
+								ProbeHandler: v1.ProbeHandler{
+									TCPSocket: &v1.TCPSocketAction{
+										Port: intstr.IntOrString{
+											IntVal: 8080,
+										},
+									},
+								},
+								InitialDelaySeconds: 0,
+								TimeoutSeconds:      1,
+								PeriodSeconds:       10,
+								SuccessThreshold:    1,
+								FailureThreshold:    3,
+							},
+						},
+						{
+							Name: "queue-proxy",
+							Env:  []v1.EnvVar{{Name: "SERVING_READINESS_PROBE", Value: "{\"tcpSocket\":{\"port\":8080},\"timeoutSeconds\":1,\"periodSeconds\":10,\"successThreshold\":1,\"failureThreshold\":3}"}},
+						},
+					},
+				},
+			},
+			expected: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "deployment",
+					Annotations: map[string]string{
+						constants.BatcherInternalAnnotationKey:               "true",
+						constants.BatcherMaxLatencyInternalAnnotationKey:     "100",
+						constants.BatcherMaxBatchSizeInternalAnnotationKey:   "30",
+						constants.BatcherPartialFailureInternalAnnotationKey: "true",
+					},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name: "sklearn",
+							ReadinessProbe: &v1.Probe{
+								ProbeHandler: v1.ProbeHandler{
+									TCPSocket: &v1.TCPSocketAction{
+										Port: intstr.IntOrString{
+											IntVal: 8080,
+										},
+									},
+								},
+								InitialDelaySeconds: 0,
+								TimeoutSeconds:      1,
+								PeriodSeconds:       10,
+								SuccessThreshold:    1,
+								FailureThreshold:    3,
+							},
+						},
+						{
+							Name: "queue-proxy",
+							Env:  []v1.EnvVar{{Name: "SERVING_READINESS_PROBE", Value: "{\"tcpSocket\":{\"port\":8080},\"timeoutSeconds\":1,\"periodSeconds\":10,\"successThreshold\":1,\"failureThreshold\":3}"}},
+						},
+						{
+							Name:  constants.AgentContainerName,
+							Image: loggerConfig.Image,
+							Args: []string{
+								BatcherEnableFlag,
+								BatcherArgumentMaxBatchSize,
+								"30",
+								BatcherArgumentMaxLatency,
+								"100",
+								BatcherArgumentPartialFailure,
+							},
+							Ports: []v1.ContainerPort{
+								{
+									Name:          "agent-port",
+									ContainerPort: constants.InferenceServiceDefaultAgentPort,
+									Protocol:      "TCP",
+								},
+							},
+							Env:       []v1.EnvVar{{Name: "SERVING_READINESS_PROBE", Value: "{\"tcpSocket\":{\"port\":8080},\"timeoutSeconds\":1,\"periodSeconds\":10,\"successThreshold\":1,\"failureThreshold\":3}"}},
+							Resources: agentResourceRequirement,
+							ReadinessProbe: &v1.Probe{
+								ProbeHandler: v1.ProbeHandler{
+									HTTPGet: &v1.HTTPGetAction{
+										HTTPHeaders: []v1.HTTPHeader{
+											{
+												Name:  "K-Network-Probe",
+												Value: "queue",
+											},
+										},
+										Port:   intstr.FromInt(9081),
+										Path:   "/",
+										Scheme: "HTTP",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		"DoNotAddBatcher": {
 			original: &v1.Pod{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1154,6 +1409,43 @@ func TestGetLoggerConfigs(t *testing.T) {
 				gomega.BeNil(),
 			},
 		},
+		{
+			name: "Valid Logger Config With Delivery Options",
+			configMap: &v1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{},
+				ObjectMeta: metav1.ObjectMeta{},
+				Data: map[string]string{
+					LoggerConfigMapKeyName: `{
+						"Image":          "gcr.io/kfserving/logger:latest",
+						"CpuRequest":     "100m",
+						"CpuLimit":       "1",
+						"MemoryRequest":  "200Mi",
+						"MemoryLimit":    "1Gi",
+						"maxRetries":     5,
+						"spoolDir":       "/var/spool/kserve-logger",
+						"spoolMaxSize":   52428800,
+						"overflowPolicy": "drop-oldest",
+						"maxPayloadSize": 1048576
+					}`,
+				},
+				BinaryData: map[string][]byte{},
+			},
+			matchers: []types.GomegaMatcher{
+				gomega.Equal(&LoggerConfig{
+					Image:          "gcr.io/kfserving/logger:latest",
+					CpuRequest:     "100m",
+					CpuLimit:       "1",
+					MemoryRequest:  "200Mi",
+					MemoryLimit:    "1Gi",
+					MaxRetries:     proto.Int32(5),
+					SpoolDir:       "/var/spool/kserve-logger",
+					SpoolMaxSize:   52428800,
+					OverflowPolicy: "drop-oldest",
+					MaxPayloadSize: 1048576,
+				}),
+				gomega.BeNil(),
+			},
+		},
 		{
 			name: "Invalid Resource Value",
 			configMap: &v1.ConfigMap{
@@ -1251,6 +1543,122 @@ func TestGetAgentConfigs(t *testing.T) {
 				gomega.HaveOccurred(),
 			},
 		},
+		{
+			name: "Valid Storage Plugins",
+			configMap: &v1.ConfigMap{
+				Data: map[string]string{
+					constants.AgentConfigMapKeyName: `{
+						"Image":         "gcr.io/kfserving/agent:latest",
+						"CpuRequest":    "100m",
+						"CpuLimit":      "1",
+						"MemoryRequest": "200Mi",
+						"MemoryLimit":   "1Gi",
+						"storagePlugins": [
+							{"protocol": "artifactory://", "command": ["/plugins/artifactory"], "timeout": "30m"},
+							{"protocol": "registry://", "endpoint": "http://localhost:9500"}
+						]
+					}`,
+				},
+			},
+			matchers: []types.GomegaMatcher{
+				gomega.Equal(&AgentConfig{
+					Image:         "gcr.io/kfserving/agent:latest",
+					CpuRequest:    "100m",
+					CpuLimit:      "1",
+					MemoryRequest: "200Mi",
+					MemoryLimit:   "1Gi",
+					StoragePlugins: []storage.PluginConfig{
+						{Protocol: "artifactory://", Command: []string{"/plugins/artifactory"}, Timeout: "30m"},
+						{Protocol: "registry://", Endpoint: "http://localhost:9500"},
+					},
+				}),
+				gomega.BeNil(),
+			},
+		},
+		{
+			name: "Invalid Storage Plugin",
+			configMap: &v1.ConfigMap{
+				Data: map[string]string{
+					constants.AgentConfigMapKeyName: `{
+						"Image":         "gcr.io/kfserving/agent:latest",
+						"CpuRequest":    "100m",
+						"CpuLimit":      "1",
+						"MemoryRequest": "200Mi",
+						"MemoryLimit":   "1Gi",
+						"storagePlugins": [{"protocol": "artifactory://"}]
+					}`,
+				},
+			},
+			matchers: []types.GomegaMatcher{
+				gomega.Equal(&AgentConfig{
+					Image:          "gcr.io/kfserving/agent:latest",
+					CpuRequest:     "100m",
+					CpuLimit:       "1",
+					MemoryRequest:  "200Mi",
+					MemoryLimit:    "1Gi",
+					StoragePlugins: []storage.PluginConfig{{Protocol: "artifactory://"}},
+				}),
+				gomega.HaveOccurred(),
+			},
+		},
+		{
+			name: "Valid Puller Options",
+			configMap: &v1.ConfigMap{
+				Data: map[string]string{
+					constants.AgentConfigMapKeyName: `{
+						"Image":         "gcr.io/kfserving/agent:latest",
+						"CpuRequest":    "100m",
+						"CpuLimit":      "1",
+						"MemoryRequest": "200Mi",
+						"MemoryLimit":   "1Gi",
+						"modelCacheDir": "/mnt/models/.cache",
+						"modelCacheSize": "10Gi",
+						"downloadConcurrency": 4,
+						"modelInitialBackoff": "10s"
+					}`,
+				},
+			},
+			matchers: []types.GomegaMatcher{
+				gomega.Equal(&AgentConfig{
+					Image:               "gcr.io/kfserving/agent:latest",
+					CpuRequest:          "100m",
+					CpuLimit:            "1",
+					MemoryRequest:       "200Mi",
+					MemoryLimit:         "1Gi",
+					ModelCacheDir:       "/mnt/models/.cache",
+					ModelCacheSize:      "10Gi",
+					DownloadConcurrency: 4,
+					ModelInitialBackoff: "10s",
+				}),
+				gomega.BeNil(),
+			},
+		},
+		{
+			name: "Invalid Puller Backoff",
+			configMap: &v1.ConfigMap{
+				Data: map[string]string{
+					constants.AgentConfigMapKeyName: `{
+						"Image":         "gcr.io/kfserving/agent:latest",
+						"CpuRequest":    "100m",
+						"CpuLimit":      "1",
+						"MemoryRequest": "200Mi",
+						"MemoryLimit":   "1Gi",
+						"modelMaxBackoff": "10"
+					}`,
+				},
+			},
+			matchers: []types.GomegaMatcher{
+				gomega.Equal(&AgentConfig{
+					Image:           "gcr.io/kfserving/agent:latest",
+					CpuRequest:      "100m",
+					CpuLimit:        "1",
+					MemoryRequest:   "200Mi",
+					MemoryLimit:     "1Gi",
+					ModelMaxBackoff: "10",
+				}),
+				gomega.HaveOccurred(),
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -1259,3 +1667,169 @@ func TestGetAgentConfigs(t *testing.T) {
 		g.Expect(loggerConfigs).Should(tc.matchers[0])
 	}
 }
+
+func TestPullerArgs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	injector := &AgentInjector{agentConfig: &AgentConfig{
+		ModelCacheDir:       "/mnt/models/.cache",
+		DownloadConcurrency: 4,
+		DownloadOrder:       "priority",
+		ModelMaxAttempts:    3,
+		ArchiveMaxSize:      "10Gi",
+		FileRoot:            "/mnt/shared",
+	}}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				constants.ModelRepositoryAnnotationKey:         "torchserve",
+				constants.ModelRepositoryEndpointAnnotationKey: "http://localhost:8085",
+			},
+		},
+	}
+	g.Expect(injector.pullerArgs(pod)).To(gomega.Equal([]string{
+		"--model-repository", "torchserve",
+		"--model-repository-endpoint", "http://localhost:8085",
+		"--model-cache-dir", "/mnt/models/.cache",
+		"--download-order", "priority",
+		"--archive-max-size", "10Gi",
+		"--file-root", "/mnt/shared",
+		"--download-concurrency", "4",
+		"--model-max-attempts", "3",
+	}))
+
+	injector = &AgentInjector{agentConfig: &AgentConfig{}}
+	g.Expect(injector.pullerArgs(&v1.Pod{})).To(gomega.BeEmpty())
+}
+
+func TestMountModelPvcs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				constants.ModelPvcsAnnotationKey: "models, shared-models",
+			},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: constants.InferenceServiceContainerName},
+				{Name: constants.AgentContainerName},
+				{Name: "sidecar"},
+			},
+		},
+	}
+	mountModelPvcs(pod)
+
+	g.Expect(pod.Spec.Volumes).To(gomega.Equal([]v1.Volume{
+		{
+			Name: "model-pvc-0",
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "models", ReadOnly: true},
+			},
+		},
+		{
+			Name: "model-pvc-1",
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "shared-models", ReadOnly: true},
+			},
+		},
+	}))
+	expectedMounts := []v1.VolumeMount{
+		{Name: "model-pvc-0", ReadOnly: true, MountPath: "/mnt/pvc/models"},
+		{Name: "model-pvc-1", ReadOnly: true, MountPath: "/mnt/pvc/shared-models"},
+	}
+	g.Expect(pod.Spec.Containers[0].VolumeMounts).To(gomega.Equal(expectedMounts))
+	g.Expect(pod.Spec.Containers[1].VolumeMounts).To(gomega.Equal(expectedMounts))
+	g.Expect(pod.Spec.Containers[2].VolumeMounts).To(gomega.BeEmpty())
+}
+
+func TestInjectAgentHTTPSSecret(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	clientset := fakeclientset.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "https-creds", Namespace: "default"},
+			Data: map[string][]byte{
+				https.HTTPSHost: []byte("example.com"),
+				https.HEADERS:   []byte("Authorization: Bearer token"),
+			},
+		},
+		&v1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: "sa", Namespace: "default"},
+			Secrets:    []v1.ObjectReference{{Name: "https-creds"}},
+		},
+	)
+	credentialBuilder := credentials.NewCredentialBuilder(c, clientset, &v1.ConfigMap{
+		Data: map[string]string{},
+	})
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "deployment",
+			Namespace: "default",
+			Annotations: map[string]string{
+				constants.AgentShouldInjectAnnotationKey:          "true",
+				constants.AgentModelConfigVolumeNameAnnotationKey: "modelconfig-deployment-0",
+				constants.AgentModelDirAnnotationKey:              "/mnt/models",
+				constants.AgentModelConfigMountPathAnnotationKey:  "/mnt/configs",
+			},
+		},
+		Spec: v1.PodSpec{
+			ServiceAccountName: "sa",
+			Containers:         []v1.Container{{Name: "sklearn"}},
+		},
+	}
+	injector := &AgentInjector{credentialBuilder, agentConfig, loggerConfig, batcherTestConfig}
+	g.Expect(injector.InjectAgent(pod)).To(gomega.Succeed())
+
+	// The secret is only mounted into the agent container
+	g.Expect(pod.Spec.Volumes).To(gomega.ContainElement(v1.Volume{
+		Name: "https-secret-https-creds",
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{SecretName: "https-creds"},
+		},
+	}))
+	g.Expect(pod.Spec.Containers[0].VolumeMounts).NotTo(gomega.ContainElement(
+		gomega.HaveField("Name", "https-secret-https-creds")))
+	agent := pod.Spec.Containers[len(pod.Spec.Containers)-1]
+	g.Expect(agent.Name).To(gomega.Equal(constants.AgentContainerName))
+	g.Expect(agent.VolumeMounts).To(gomega.ContainElement(v1.VolumeMount{
+		Name:      "https-secret-https-creds",
+		ReadOnly:  true,
+		MountPath: "/var/secrets/kserve-https/https-creds",
+	}))
+
+	// The storage initializer reads the headers from its envs
+	storageInitializer := &v1.Container{Name: StorageInitializerContainerName}
+	var volumes []v1.Volume
+	g.Expect(credentialBuilder.CreateSecretVolumeAndEnv("default", nil, "sa", storageInitializer, &volumes)).To(gomega.Succeed())
+	g.Expect(storageInitializer.Env).To(gomega.Equal([]v1.EnvVar{
+		{Name: "example.com-headers", Value: "Authorization: Bearer token"},
+	}))
+	g.Expect(storageInitializer.VolumeMounts).To(gomega.BeEmpty())
+	g.Expect(volumes).To(gomega.BeEmpty())
+}
+
+func TestMountOCIPullSecrets(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			ImagePullSecrets: []v1.LocalObjectReference{{Name: "registry-credentials"}},
+			Containers: []v1.Container{
+				{Name: constants.InferenceServiceContainerName},
+				{Name: constants.AgentContainerName},
+			},
+		},
+	}
+	mountOCIPullSecrets(pod)
+
+	g.Expect(pod.Spec.Volumes).To(gomega.Equal([]v1.Volume{
+		{
+			Name: "oci-pull-secret-0",
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: "registry-credentials"},
+			},
+		},
+	}))
+	g.Expect(pod.Spec.Containers[0].VolumeMounts).To(gomega.BeEmpty())
+	g.Expect(pod.Spec.Containers[1].VolumeMounts).To(gomega.Equal([]v1.VolumeMount{
+		{Name: "oci-pull-secret-0", ReadOnly: true, MountPath: "/mnt/oci-pull-secrets/registry-credentials"},
+	}))
+}
@@ -32,6 +32,8 @@ const (
 	BatcherEnableFlag           = "--enable-batcher"
 	BatcherArgumentMaxBatchSize = "--max-batchsize"
 	BatcherArgumentMaxLatency   = "--max-latency"
+	// BatcherArgumentPartialFailure is only supported by the batcher of the agent
+	BatcherArgumentPartialFailure = "--batch-partial-failure"
 )
 
 type BatcherConfig struct {
@@ -85,6 +85,11 @@ from .models.v1beta1_inference_service_status import V1beta1InferenceServiceStat
 from .models.v1beta1_inference_services_config import V1beta1InferenceServicesConfig
 from .models.v1beta1_ingress_config import V1beta1IngressConfig
 from .models.v1beta1_light_gbm_spec import V1beta1LightGBMSpec
+from .models.v1beta1_logger_filter_rule import V1beta1LoggerFilterRule
+from .models.v1beta1_logger_filter_spec import V1beta1LoggerFilterSpec
+from .models.v1beta1_logger_json_field_predicate import V1beta1LoggerJSONFieldPredicate
+from .models.v1beta1_logger_redaction_mask import V1beta1LoggerRedactionMask
+from .models.v1beta1_logger_redaction_spec import V1beta1LoggerRedactionSpec
 from .models.v1beta1_logger_spec import V1beta1LoggerSpec
 from .models.v1beta1_model_format import V1beta1ModelFormat
 from .models.v1beta1_model_spec import V1beta1ModelSpec
@@ -73,6 +73,11 @@ from kserve.models.v1beta1_inference_service_status import V1beta1InferenceServi
 from kserve.models.v1beta1_inference_services_config import V1beta1InferenceServicesConfig
 from kserve.models.v1beta1_ingress_config import V1beta1IngressConfig
 from kserve.models.v1beta1_light_gbm_spec import V1beta1LightGBMSpec
+from kserve.models.v1beta1_logger_filter_rule import V1beta1LoggerFilterRule
+from kserve.models.v1beta1_logger_filter_spec import V1beta1LoggerFilterSpec
+from kserve.models.v1beta1_logger_json_field_predicate import V1beta1LoggerJSONFieldPredicate
+from kserve.models.v1beta1_logger_redaction_mask import V1beta1LoggerRedactionMask
+from kserve.models.v1beta1_logger_redaction_spec import V1beta1LoggerRedactionSpec
 from kserve.models.v1beta1_logger_spec import V1beta1LoggerSpec
 from kserve.models.v1beta1_model_copies import V1beta1ModelCopies
 from kserve.models.v1beta1_model_format import V1beta1ModelFormat
@@ -47,32 +47,93 @@ class V1alpha1ModelSpec(object):
                             and the value is json key in definition.
     """
     openapi_types = {
+        'digest': 'str',
+        'file_digests': 'dict(str, str)',
         'framework': 'str',
         'memory': 'ResourceQuantity',
+        'priority': 'int',
         'storage_uri': 'str'
     }
 
     attribute_map = {
+        'digest': 'digest',
+        'file_digests': 'fileDigests',
         'framework': 'framework',
         'memory': 'memory',
+        'priority': 'priority',
         'storage_uri': 'storageUri'
     }
 
-    def __init__(self, framework='', memory=None, storage_uri='', local_vars_configuration=None):  # noqa: E501
+    def __init__(self, digest=None, file_digests=None, framework='', memory=None, priority=None, storage_uri='', local_vars_configuration=None):  # noqa: E501
         """V1alpha1ModelSpec - a model defined in OpenAPI"""  # noqa: E501
         if local_vars_configuration is None:
             local_vars_configuration = Configuration()
         self.local_vars_configuration = local_vars_configuration
 
+        self._digest = None
+        self._file_digests = None
         self._framework = None
         self._memory = None
+        self._priority = None
         self._storage_uri = None
         self.discriminator = None
 
+        if digest is not None:
+            self.digest = digest
+        if file_digests is not None:
+            self.file_digests = file_digests
         self.framework = framework
         self.memory = memory
+        if priority is not None:
+            self.priority = priority
         self.storage_uri = storage_uri
 
+    @property
+    def digest(self):
+        """Gets the digest of this V1alpha1ModelSpec.  # noqa: E501
+
+        Expected digest of the model as it is downloaded from the storage URI, in the form \"sha256:<hex>\". This is the digest of the archive for archived models, or of the single model file.  # noqa: E501
+
+        :return: The digest of this V1alpha1ModelSpec.  # noqa: E501
+        :rtype: str
+        """
+        return self._digest
+
+    @digest.setter
+    def digest(self, digest):
+        """Sets the digest of this V1alpha1ModelSpec.
+
+        Expected digest of the model as it is downloaded from the storage URI, in the form \"sha256:<hex>\". This is the digest of the archive for archived models, or of the single model file.  # noqa: E501
+
+        :param digest: The digest of this V1alpha1ModelSpec.  # noqa: E501
+        :type: str
+        """
+
+        self._digest = digest
+
+    @property
+    def file_digests(self):
+        """Gets the file_digests of this V1alpha1ModelSpec.  # noqa: E501
+
+        Expected digests of the downloaded model files in the form \"sha256:<hex>\", keyed by the file path relative to the model directory.  # noqa: E501
+
+        :return: The file_digests of this V1alpha1ModelSpec.  # noqa: E501
+        :rtype: dict(str, str)
+        """
+        return self._file_digests
+
+    @file_digests.setter
+    def file_digests(self, file_digests):
+        """Sets the file_digests of this V1alpha1ModelSpec.
+
+        Expected digests of the downloaded model files in the form \"sha256:<hex>\", keyed by the file path relative to the model directory.  # noqa: E501
+
+        :param file_digests: The file_digests of this V1alpha1ModelSpec.  # noqa: E501
+        :type: dict(str, str)
+        """
+
+        self._file_digests = file_digests
+
     @property
     def framework(self):
         """Gets the framework of this V1alpha1ModelSpec.  # noqa: E501
@@ -121,6 +182,29 @@ class V1alpha1ModelSpec(object):
 
         self._memory = memory
 
+    @property
+    def priority(self):
+        """Gets the priority of this V1alpha1ModelSpec.  # noqa: E501
+
+        Priority of the model download when the model agent downloads the models in priority order, the models with the highest priority are downloaded first.  # noqa: E501
+
+        :return: The priority of this V1alpha1ModelSpec.  # noqa: E501
+        :rtype: int
+        """
+        return self._priority
+
+    @priority.setter
+    def priority(self, priority):
+        """Sets the priority of this V1alpha1ModelSpec.
+
+        Priority of the model download when the model agent downloads the models in priority order, the models with the highest priority are downloaded first.  # noqa: E501
+
+        :param priority: The priority of this V1alpha1ModelSpec.  # noqa: E501
+        :type: int
+        """
+
+        self._priority = priority
+
     @property
     def storage_uri(self):
         """Gets the storage_uri of this V1alpha1ModelSpec.  # noqa: E501
@@ -49,16 +49,18 @@ class V1beta1Batcher(object):
     openapi_types = {
         'max_batch_size': 'int',
         'max_latency': 'int',
+        'partial_failure': 'bool',
         'timeout': 'int'
     }
 
     attribute_map = {
         'max_batch_size': 'maxBatchSize',
         'max_latency': 'maxLatency',
+        'partial_failure': 'partialFailure',
         'timeout': 'timeout'
     }
 
-    def __init__(self, max_batch_size=None, max_latency=None, timeout=None, local_vars_configuration=None):  # noqa: E501
+    def __init__(self, max_batch_size=None, max_latency=None, partial_failure=None, timeout=None, local_vars_configuration=None):  # noqa: E501
         """V1beta1Batcher - a model defined in OpenAPI"""  # noqa: E501
         if local_vars_configuration is None:
             local_vars_configuration = Configuration()
@@ -66,6 +68,7 @@ class V1beta1Batcher(object):
 
         self._max_batch_size = None
         self._max_latency = None
+        self._partial_failure = None
         self._timeout = None
         self.discriminator = None
 
@@ -73,6 +76,8 @@ class V1beta1Batcher(object):
             self.max_batch_size = max_batch_size
         if max_latency is not None:
             self.max_latency = max_latency
+        if partial_failure is not None:
+            self.partial_failure = partial_failure
         if timeout is not None:
             self.timeout = timeout
 
@@ -122,6 +127,29 @@ class V1beta1Batcher(object):
 
         self._max_latency = max_latency
 
+    @property
+    def partial_failure(self):
+        """Gets the partial_failure of this V1beta1Batcher.  # noqa: E501
+
+        Specifies whether the errors of single instances are returned along with the predictions of the other instances of the batch, rather than failing the whole batch  # noqa: E501
+
+        :return: The partial_failure of this V1beta1Batcher.  # noqa: E501
+        :rtype: bool
+        """
+        return self._partial_failure
+
+    @partial_failure.setter
+    def partial_failure(self, partial_failure):
+        """Sets the partial_failure of this V1beta1Batcher.
+
+        Specifies whether the errors of single instances are returned along with the predictions of the other instances of the batch, rather than failing the whole batch  # noqa: E501
+
+        :param partial_failure: The partial_failure of this V1beta1Batcher.  # noqa: E501
+        :type: bool
+        """
+
+        self._partial_failure = partial_failure
+
     @property
     def timeout(self):
         """Gets the timeout of this V1beta1Batcher.  # noqa: E501
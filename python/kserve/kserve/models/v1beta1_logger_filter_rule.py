@@ -0,0 +1,246 @@
+# Copyright 2023 The KServe Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#    http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# coding: utf-8
+
+"""
+    KServe
+
+    Python SDK for KServe  # noqa: E501
+
+    The version of the OpenAPI document: v0.1
+    Generated by: https://openapi-generator.tech
+"""
+
+
+import pprint
+import re  # noqa: F401
+
+import six
+
+from kserve.configuration import Configuration
+
+
+class V1beta1LoggerFilterRule(object):
+    """NOTE: This class is auto generated by OpenAPI Generator.
+    Ref: https://openapi-generator.tech
+
+    Do not edit the class manually.
+    """
+
+    """
+    Attributes:
+      openapi_types (dict): The key is attribute name
+                            and the value is attribute type.
+      attribute_map (dict): The key is attribute name
+                            and the value is json key in definition.
+    """
+    openapi_types = {
+        'headers': 'dict(str, str)',
+        'json_field': 'V1beta1LoggerJSONFieldPredicate',
+        'path': 'str',
+        'sampling_percentage': 'int',
+        'status_codes': 'list[str]'
+    }
+
+    attribute_map = {
+        'headers': 'headers',
+        'json_field': 'jsonField',
+        'path': 'path',
+        'sampling_percentage': 'samplingPercentage',
+        'status_codes': 'statusCodes'
+    }
+
+    def __init__(self, headers=None, json_field=None, path=None, sampling_percentage=None, status_codes=None, local_vars_configuration=None):  # noqa: E501
+        """V1beta1LoggerFilterRule - a model defined in OpenAPI"""  # noqa: E501
+        if local_vars_configuration is None:
+            local_vars_configuration = Configuration()
+        self.local_vars_configuration = local_vars_configuration
+
+        self._headers = None
+        self._json_field = None
+        self._path = None
+        self._sampling_percentage = None
+        self._status_codes = None
+        self.discriminator = None
+
+        if headers is not None:
+            self.headers = headers
+        if json_field is not None:
+            self.json_field = json_field
+        if path is not None:
+            self.path = path
+        if sampling_percentage is not None:
+            self.sampling_percentage = sampling_percentage
+        if status_codes is not None:
+            self.status_codes = status_codes
+
+    @property
+    def headers(self):
+        """Gets the headers of this V1beta1LoggerFilterRule.  # noqa: E501
+
+        Request headers which must be present, mapped to regular expressions their value must match.  # noqa: E501
+
+        :return: The headers of this V1beta1LoggerFilterRule.  # noqa: E501
+        :rtype: dict(str, str)
+        """
+        return self._headers
+
+    @headers.setter
+    def headers(self, headers):
+        """Sets the headers of this V1beta1LoggerFilterRule.
+
+        Request headers which must be present, mapped to regular expressions their value must match.  # noqa: E501
+
+        :param headers: The headers of this V1beta1LoggerFilterRule.  # noqa: E501
+        :type: dict(str, str)
+        """
+
+        self._headers = headers
+
+    @property
+    def json_field(self):
+        """Gets the json_field of this V1beta1LoggerFilterRule.  # noqa: E501
+
+
+        :return: The json_field of this V1beta1LoggerFilterRule.  # noqa: E501
+        :rtype: V1beta1LoggerJSONFieldPredicate
+        """
+        return self._json_field
+
+    @json_field.setter
+    def json_field(self, json_field):
+        """Sets the json_field of this V1beta1LoggerFilterRule.
+
+
+        :param json_field: The json_field of this V1beta1LoggerFilterRule.  # noqa: E501
+        :type: V1beta1LoggerJSONFieldPredicate
+        """
+
+        self._json_field = json_field
+
+    @property
+    def path(self):
+        """Gets the path of this V1beta1LoggerFilterRule.  # noqa: E501
+
+        Regular expression the request path must match.  # noqa: E501
+
+        :return: The path of this V1beta1LoggerFilterRule.  # noqa: E501
+        :rtype: str
+        """
+        return self._path
+
+    @path.setter
+    def path(self, path):
+        """Sets the path of this V1beta1LoggerFilterRule.
+
+        Regular expression the request path must match.  # noqa: E501
+
+        :param path: The path of this V1beta1LoggerFilterRule.  # noqa: E501
+        :type: str
+        """
+
+        self._path = path
+
+    @property
+    def sampling_percentage(self):
+        """Gets the sampling_percentage of this V1beta1LoggerFilterRule.  # noqa: E501
+
+        Percentage, from 0 to 100, of the matching requests to log. Defaults to 100.  # noqa: E501
+
+        :return: The sampling_percentage of this V1beta1LoggerFilterRule.  # noqa: E501
+        :rtype: int
+        """
+        return self._sampling_percentage
+
+    @sampling_percentage.setter
+    def sampling_percentage(self, sampling_percentage):
+        """Sets the sampling_percentage of this V1beta1LoggerFilterRule.
+
+        Percentage, from 0 to 100, of the matching requests to log. Defaults to 100.  # noqa: E501
+
+        :param sampling_percentage: The sampling_percentage of this V1beta1LoggerFilterRule.  # noqa: E501
+        :type: int
+        """
+
+        self._sampling_percentage = sampling_percentage
+
+    @property
+    def status_codes(self):
+        """Gets the status_codes of this V1beta1LoggerFilterRule.  # noqa: E501
+
+        Status codes the response must have, such as \"200\", or status code classes, such as \"5xx\". When set, the request is only logged once the response is received.  # noqa: E501
+
+        :return: The status_codes of this V1beta1LoggerFilterRule.  # noqa: E501
+        :rtype: list[str]
+        """
+        return self._status_codes
+
+    @status_codes.setter
+    def status_codes(self, status_codes):
+        """Sets the status_codes of this V1beta1LoggerFilterRule.
+
+        Status codes the response must have, such as \"200\", or status code classes, such as \"5xx\". When set, the request is only logged once the response is received.  # noqa: E501
+
+        :param status_codes: The status_codes of this V1beta1LoggerFilterRule.  # noqa: E501
+        :type: list[str]
+        """
+
+        self._status_codes = status_codes
+
+    def to_dict(self):
+        """Returns the model properties as a dict"""
+        result = {}
+
+        for attr, _ in six.iteritems(self.openapi_types):
+            value = getattr(self, attr)
+            if isinstance(value, list):
+                result[attr] = list(map(
+                    lambda x: x.to_dict() if hasattr(x, "to_dict") else x,
+                    value
+                ))
+            elif hasattr(value, "to_dict"):
+                result[attr] = value.to_dict()
+            elif isinstance(value, dict):
+                result[attr] = dict(map(
+                    lambda item: (item[0], item[1].to_dict())
+                    if hasattr(item[1], "to_dict") else item,
+                    value.items()
+                ))
+            else:
+                result[attr] = value
+
+        return result
+
+    def to_str(self):
+        """Returns the string representation of the model"""
+        return pprint.pformat(self.to_dict())
+
+    def __repr__(self):
+        """For `print` and `pprint`"""
+        return self.to_str()
+
+    def __eq__(self, other):
+        """Returns true if both objects are equal"""
+        if not isinstance(other, V1beta1LoggerFilterRule):
+            return False
+
+        return self.to_dict() == other.to_dict()
+
+    def __ne__(self, other):
+        """Returns true if both objects are not equal"""
+        if not isinstance(other, V1beta1LoggerFilterRule):
+            return True
+
+        return self.to_dict() != other.to_dict()
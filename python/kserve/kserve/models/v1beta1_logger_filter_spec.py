@@ -0,0 +1,164 @@
+# Copyright 2023 The KServe Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#    http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# coding: utf-8
+
+"""
+    KServe
+
+    Python SDK for KServe  # noqa: E501
+
+    The version of the OpenAPI document: v0.1
+    Generated by: https://openapi-generator.tech
+"""
+
+
+import pprint
+import re  # noqa: F401
+
+import six
+
+from kserve.configuration import Configuration
+
+
+class V1beta1LoggerFilterSpec(object):
+    """NOTE: This class is auto generated by OpenAPI Generator.
+    Ref: https://openapi-generator.tech
+
+    Do not edit the class manually.
+    """
+
+    """
+    Attributes:
+      openapi_types (dict): The key is attribute name
+                            and the value is attribute type.
+      attribute_map (dict): The key is attribute name
+                            and the value is json key in definition.
+    """
+    openapi_types = {
+        'rules': 'list[V1beta1LoggerFilterRule]',
+        'sampling_percentage': 'int'
+    }
+
+    attribute_map = {
+        'rules': 'rules',
+        'sampling_percentage': 'samplingPercentage'
+    }
+
+    def __init__(self, rules=None, sampling_percentage=None, local_vars_configuration=None):  # noqa: E501
+        """V1beta1LoggerFilterSpec - a model defined in OpenAPI"""  # noqa: E501
+        if local_vars_configuration is None:
+            local_vars_configuration = Configuration()
+        self.local_vars_configuration = local_vars_configuration
+
+        self._rules = None
+        self._sampling_percentage = None
+        self.discriminator = None
+
+        if rules is not None:
+            self.rules = rules
+        if sampling_percentage is not None:
+            self.sampling_percentage = sampling_percentage
+
+    @property
+    def rules(self):
+        """Gets the rules of this V1beta1LoggerFilterSpec.  # noqa: E501
+
+        Rules selecting requests to log with their own sampling percentage.  # noqa: E501
+
+        :return: The rules of this V1beta1LoggerFilterSpec.  # noqa: E501
+        :rtype: list[V1beta1LoggerFilterRule]
+        """
+        return self._rules
+
+    @rules.setter
+    def rules(self, rules):
+        """Sets the rules of this V1beta1LoggerFilterSpec.
+
+        Rules selecting requests to log with their own sampling percentage.  # noqa: E501
+
+        :param rules: The rules of this V1beta1LoggerFilterSpec.  # noqa: E501
+        :type: list[V1beta1LoggerFilterRule]
+        """
+
+        self._rules = rules
+
+    @property
+    def sampling_percentage(self):
+        """Gets the sampling_percentage of this V1beta1LoggerFilterSpec.  # noqa: E501
+
+        Percentage, from 0 to 100, of the requests matching no rule to log. Defaults to 100.  # noqa: E501
+
+        :return: The sampling_percentage of this V1beta1LoggerFilterSpec.  # noqa: E501
+        :rtype: int
+        """
+        return self._sampling_percentage
+
+    @sampling_percentage.setter
+    def sampling_percentage(self, sampling_percentage):
+        """Sets the sampling_percentage of this V1beta1LoggerFilterSpec.
+
+        Percentage, from 0 to 100, of the requests matching no rule to log. Defaults to 100.  # noqa: E501
+
+        :param sampling_percentage: The sampling_percentage of this V1beta1LoggerFilterSpec.  # noqa: E501
+        :type: int
+        """
+
+        self._sampling_percentage = sampling_percentage
+
+    def to_dict(self):
+        """Returns the model properties as a dict"""
+        result = {}
+
+        for attr, _ in six.iteritems(self.openapi_types):
+            value = getattr(self, attr)
+            if isinstance(value, list):
+                result[attr] = list(map(
+                    lambda x: x.to_dict() if hasattr(x, "to_dict") else x,
+                    value
+                ))
+            elif hasattr(value, "to_dict"):
+                result[attr] = value.to_dict()
+            elif isinstance(value, dict):
+                result[attr] = dict(map(
+                    lambda item: (item[0], item[1].to_dict())
+                    if hasattr(item[1], "to_dict") else item,
+                    value.items()
+                ))
+            else:
+                result[attr] = value
+
+        return result
+
+    def to_str(self):
+        """Returns the string representation of the model"""
+        return pprint.pformat(self.to_dict())
+
+    def __repr__(self):
+        """For `print` and `pprint`"""
+        return self.to_str()
+
+    def __eq__(self, other):
+        """Returns true if both objects are equal"""
+        if not isinstance(other, V1beta1LoggerFilterSpec):
+            return False
+
+        return self.to_dict() == other.to_dict()
+
+    def __ne__(self, other):
+        """Returns true if both objects are not equal"""
+        if not isinstance(other, V1beta1LoggerFilterSpec):
+            return True
+
+        return self.to_dict() != other.to_dict()
@@ -0,0 +1,165 @@
+# Copyright 2023 The KServe Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#    http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# coding: utf-8
+
+"""
+    KServe
+
+    Python SDK for KServe  # noqa: E501
+
+    The version of the OpenAPI document: v0.1
+    Generated by: https://openapi-generator.tech
+"""
+
+
+import pprint
+import re  # noqa: F401
+
+import six
+
+from kserve.configuration import Configuration
+
+
+class V1beta1LoggerJSONFieldPredicate(object):
+    """NOTE: This class is auto generated by OpenAPI Generator.
+    Ref: https://openapi-generator.tech
+
+    Do not edit the class manually.
+    """
+
+    """
+    Attributes:
+      openapi_types (dict): The key is attribute name
+                            and the value is attribute type.
+      attribute_map (dict): The key is attribute name
+                            and the value is json key in definition.
+    """
+    openapi_types = {
+        'path': 'str',
+        'value': 'str'
+    }
+
+    attribute_map = {
+        'path': 'path',
+        'value': 'value'
+    }
+
+    def __init__(self, path='', value=None, local_vars_configuration=None):  # noqa: E501
+        """V1beta1LoggerJSONFieldPredicate - a model defined in OpenAPI"""  # noqa: E501
+        if local_vars_configuration is None:
+            local_vars_configuration = Configuration()
+        self.local_vars_configuration = local_vars_configuration
+
+        self._path = None
+        self._value = None
+        self.discriminator = None
+
+        self.path = path
+        if value is not None:
+            self.value = value
+
+    @property
+    def path(self):
+        """Gets the path of this V1beta1LoggerJSONFieldPredicate.  # noqa: E501
+
+        Dot separated path to the field, array elements are selected by their index, e.g. \"parameters.user_id\" or \"instances.0.country\".  # noqa: E501
+
+        :return: The path of this V1beta1LoggerJSONFieldPredicate.  # noqa: E501
+        :rtype: str
+        """
+        return self._path
+
+    @path.setter
+    def path(self, path):
+        """Sets the path of this V1beta1LoggerJSONFieldPredicate.
+
+        Dot separated path to the field, array elements are selected by their index, e.g. \"parameters.user_id\" or \"instances.0.country\".  # noqa: E501
+
+        :param path: The path of this V1beta1LoggerJSONFieldPredicate.  # noqa: E501
+        :type: str
+        """
+        if self.local_vars_configuration.client_side_validation and path is None:  # noqa: E501
+            raise ValueError("Invalid value for `path`, must not be `None`")  # noqa: E501
+
+        self._path = path
+
+    @property
+    def value(self):
+        """Gets the value of this V1beta1LoggerJSONFieldPredicate.  # noqa: E501
+
+        Regular expression the field value must match. Values which are not strings are matched against their JSON encoding. The field only has to be present if not set.  # noqa: E501
+
+        :return: The value of this V1beta1LoggerJSONFieldPredicate.  # noqa: E501
+        :rtype: str
+        """
+        return self._value
+
+    @value.setter
+    def value(self, value):
+        """Sets the value of this V1beta1LoggerJSONFieldPredicate.
+
+        Regular expression the field value must match. Values which are not strings are matched against their JSON encoding. The field only has to be present if not set.  # noqa: E501
+
+        :param value: The value of this V1beta1LoggerJSONFieldPredicate.  # noqa: E501
+        :type: str
+        """
+
+        self._value = value
+
+    def to_dict(self):
+        """Returns the model properties as a dict"""
+        result = {}
+
+        for attr, _ in six.iteritems(self.openapi_types):
+            value = getattr(self, attr)
+            if isinstance(value, list):
+                result[attr] = list(map(
+                    lambda x: x.to_dict() if hasattr(x, "to_dict") else x,
+                    value
+                ))
+            elif hasattr(value, "to_dict"):
+                result[attr] = value.to_dict()
+            elif isinstance(value, dict):
+                result[attr] = dict(map(
+                    lambda item: (item[0], item[1].to_dict())
+                    if hasattr(item[1], "to_dict") else item,
+                    value.items()
+                ))
+            else:
+                result[attr] = value
+
+        return result
+
+    def to_str(self):
+        """Returns the string representation of the model"""
+        return pprint.pformat(self.to_dict())
+
+    def __repr__(self):
+        """For `print` and `pprint`"""
+        return self.to_str()
+
+    def __eq__(self, other):
+        """Returns true if both objects are equal"""
+        if not isinstance(other, V1beta1LoggerJSONFieldPredicate):
+            return False
+
+        return self.to_dict() == other.to_dict()
+
+    def __ne__(self, other):
+        """Returns true if both objects are not equal"""
+        if not isinstance(other, V1beta1LoggerJSONFieldPredicate):
+            return True
+
+        return self.to_dict() != other.to_dict()
@@ -0,0 +1,192 @@
+# Copyright 2023 The KServe Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#    http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# coding: utf-8
+
+"""
+    KServe
+
+    Python SDK for KServe  # noqa: E501
+
+    The version of the OpenAPI document: v0.1
+    Generated by: https://openapi-generator.tech
+"""
+
+
+import pprint
+import re  # noqa: F401
+
+import six
+
+from kserve.configuration import Configuration
+
+
+class V1beta1LoggerRedactionSpec(object):
+    """NOTE: This class is auto generated by OpenAPI Generator.
+    Ref: https://openapi-generator.tech
+
+    Do not edit the class manually.
+    """
+
+    """
+    Attributes:
+      openapi_types (dict): The key is attribute name
+                            and the value is attribute type.
+      attribute_map (dict): The key is attribute name
+                            and the value is json key in definition.
+    """
+    openapi_types = {
+        'drop': 'list[str]',
+        'hash': 'list[str]',
+        'masks': 'list[V1beta1LoggerRedactionMask]'
+    }
+
+    attribute_map = {
+        'drop': 'drop',
+        'hash': 'hash',
+        'masks': 'masks'
+    }
+
+    def __init__(self, drop=None, hash=None, masks=None, local_vars_configuration=None):  # noqa: E501
+        """V1beta1LoggerRedactionSpec - a model defined in OpenAPI"""  # noqa: E501
+        if local_vars_configuration is None:
+            local_vars_configuration = Configuration()
+        self.local_vars_configuration = local_vars_configuration
+
+        self._drop = None
+        self._hash = None
+        self._masks = None
+        self.discriminator = None
+
+        if drop is not None:
+            self.drop = drop
+        if hash is not None:
+            self.hash = hash
+        if masks is not None:
+            self.masks = masks
+
+    @property
+    def drop(self):
+        """Gets the drop of this V1beta1LoggerRedactionSpec.  # noqa: E501
+
+        Paths of the fields to remove, e.g. \"instances.*.notes\".  # noqa: E501
+
+        :return: The drop of this V1beta1LoggerRedactionSpec.  # noqa: E501
+        :rtype: list[str]
+        """
+        return self._drop
+
+    @drop.setter
+    def drop(self, drop):
+        """Sets the drop of this V1beta1LoggerRedactionSpec.
+
+        Paths of the fields to remove, e.g. \"instances.*.notes\".  # noqa: E501
+
+        :param drop: The drop of this V1beta1LoggerRedactionSpec.  # noqa: E501
+        :type: list[str]
+        """
+
+        self._drop = drop
+
+    @property
+    def hash(self):
+        """Gets the hash of this V1beta1LoggerRedactionSpec.  # noqa: E501
+
+        Paths of the fields to replace with the hex encoded SHA-256 hash of their value. The data of a selected v2 tensor is hashed element by element.  # noqa: E501
+
+        :return: The hash of this V1beta1LoggerRedactionSpec.  # noqa: E501
+        :rtype: list[str]
+        """
+        return self._hash
+
+    @hash.setter
+    def hash(self, hash):
+        """Sets the hash of this V1beta1LoggerRedactionSpec.
+
+        Paths of the fields to replace with the hex encoded SHA-256 hash of their value. The data of a selected v2 tensor is hashed element by element.  # noqa: E501
+
+        :param hash: The hash of this V1beta1LoggerRedactionSpec.  # noqa: E501
+        :type: list[str]
+        """
+
+        self._hash = hash
+
+    @property
+    def masks(self):
+        """Gets the masks of this V1beta1LoggerRedactionSpec.  # noqa: E501
+
+        Patterns masked in every string of the payload, or in the whole payload if it is not JSON.  # noqa: E501
+
+        :return: The masks of this V1beta1LoggerRedactionSpec.  # noqa: E501
+        :rtype: list[V1beta1LoggerRedactionMask]
+        """
+        return self._masks
+
+    @masks.setter
+    def masks(self, masks):
+        """Sets the masks of this V1beta1LoggerRedactionSpec.
+
+        Patterns masked in every string of the payload, or in the whole payload if it is not JSON.  # noqa: E501
+
+        :param masks: The masks of this V1beta1LoggerRedactionSpec.  # noqa: E501
+        :type: list[V1beta1LoggerRedactionMask]
+        """
+
+        self._masks = masks
+
+    def to_dict(self):
+        """Returns the model properties as a dict"""
+        result = {}
+
+        for attr, _ in six.iteritems(self.openapi_types):
+            value = getattr(self, attr)
+            if isinstance(value, list):
+                result[attr] = list(map(
+                    lambda x: x.to_dict() if hasattr(x, "to_dict") else x,
+                    value
+                ))
+            elif hasattr(value, "to_dict"):
+                result[attr] = value.to_dict()
+            elif isinstance(value, dict):
+                result[attr] = dict(map(
+                    lambda item: (item[0], item[1].to_dict())
+                    if hasattr(item[1], "to_dict") else item,
+                    value.items()
+                ))
+            else:
+                result[attr] = value
+
+        return result
+
+    def to_str(self):
+        """Returns the string representation of the model"""
+        return pprint.pformat(self.to_dict())
+
+    def __repr__(self):
+        """For `print` and `pprint`"""
+        return self.to_str()
+
+    def __eq__(self, other):
+        """Returns true if both objects are equal"""
+        if not isinstance(other, V1beta1LoggerRedactionSpec):
+            return False
+
+        return self.to_dict() == other.to_dict()
+
+    def __ne__(self, other):
+        """Returns true if both objects are not equal"""
+        if not isinstance(other, V1beta1LoggerRedactionSpec):
+            return True
+
+        return self.to_dict() != other.to_dict()
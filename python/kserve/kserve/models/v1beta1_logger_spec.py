@@ -47,35 +47,71 @@ class V1beta1LoggerSpec(object):
                             and the value is json key in definition.
     """
     openapi_types = {
+        'filter': 'V1beta1LoggerFilterSpec',
         'mode': 'str',
+        'redaction': 'V1beta1LoggerRedactionSpec',
+        'sink': 'str',
         'url': 'str'
     }
 
     attribute_map = {
+        'filter': 'filter',
         'mode': 'mode',
+        'redaction': 'redaction',
+        'sink': 'sink',
         'url': 'url'
     }
 
-    def __init__(self, mode=None, url=None, local_vars_configuration=None):  # noqa: E501
+    def __init__(self, filter=None, mode=None, redaction=None, sink=None, url=None, local_vars_configuration=None):  # noqa: E501
         """V1beta1LoggerSpec - a model defined in OpenAPI"""  # noqa: E501
         if local_vars_configuration is None:
             local_vars_configuration = Configuration()
         self.local_vars_configuration = local_vars_configuration
 
+        self._filter = None
         self._mode = None
+        self._redaction = None
+        self._sink = None
         self._url = None
         self.discriminator = None
 
+        if filter is not None:
+            self.filter = filter
         if mode is not None:
             self.mode = mode
+        if redaction is not None:
+            self.redaction = redaction
+        if sink is not None:
+            self.sink = sink
         if url is not None:
             self.url = url
 
+    @property
+    def filter(self):
+        """Gets the filter of this V1beta1LoggerSpec.  # noqa: E501
+
+
+        :return: The filter of this V1beta1LoggerSpec.  # noqa: E501
+        :rtype: V1beta1LoggerFilterSpec
+        """
+        return self._filter
+
+    @filter.setter
+    def filter(self, filter):
+        """Sets the filter of this V1beta1LoggerSpec.
+
+
+        :param filter: The filter of this V1beta1LoggerSpec.  # noqa: E501
+        :type: V1beta1LoggerFilterSpec
+        """
+
+        self._filter = filter
+
     @property
     def mode(self):
         """Gets the mode of this V1beta1LoggerSpec.  # noqa: E501
 
-        Specifies the scope of the loggers. <br /> Valid values are: <br /> - \"all\" (default): log both request and response; <br /> - \"request\": log only request; <br /> - \"response\": log only response <br />  # noqa: E501
+        Specifies the scope of the loggers. <br /> Valid values are: <br /> - \"all\" (default): log both request and response; <br /> - \"request\": log only request; <br /> - \"response\": log only response; <br /> - \"combined\": log the request and response together in a single event <br />  # noqa: E501
 
         :return: The mode of this V1beta1LoggerSpec.  # noqa: E501
         :rtype: str
@@ -86,7 +122,7 @@ class V1beta1LoggerSpec(object):
     def mode(self, mode):
         """Sets the mode of this V1beta1LoggerSpec.
 
-        Specifies the scope of the loggers. <br /> Valid values are: <br /> - \"all\" (default): log both request and response; <br /> - \"request\": log only request; <br /> - \"response\": log only response <br />  # noqa: E501
+        Specifies the scope of the loggers. <br /> Valid values are: <br /> - \"all\" (default): log both request and response; <br /> - \"request\": log only request; <br /> - \"response\": log only response; <br /> - \"combined\": log the request and response together in a single event <br />  # noqa: E501
 
         :param mode: The mode of this V1beta1LoggerSpec.  # noqa: E501
         :type: str
@@ -94,11 +130,55 @@ class V1beta1LoggerSpec(object):
 
         self._mode = mode
 
+    @property
+    def redaction(self):
+        """Gets the redaction of this V1beta1LoggerSpec.  # noqa: E501
+
+
+        :return: The redaction of this V1beta1LoggerSpec.  # noqa: E501
+        :rtype: V1beta1LoggerRedactionSpec
+        """
+        return self._redaction
+
+    @redaction.setter
+    def redaction(self, redaction):
+        """Sets the redaction of this V1beta1LoggerSpec.
+
+
+        :param redaction: The redaction of this V1beta1LoggerSpec.  # noqa: E501
+        :type: V1beta1LoggerRedactionSpec
+        """
+
+        self._redaction = redaction
+
+    @property
+    def sink(self):
+        """Gets the sink of this V1beta1LoggerSpec.  # noqa: E501
+
+        Specifies the sink the logging events are delivered to. <br /> Valid values are: <br /> - \"cloudevents\" (default): binary mode CloudEvents posted over HTTP; <br /> - \"cloudevents-structured\": structured mode CloudEvents posted over HTTP; <br /> - \"http-batch\": arrays of structured mode CloudEvents posted over HTTP; <br /> - \"kafka\": binary mode CloudEvents produced to a Kafka topic; <br /> - \"file\": structured mode CloudEvents appended to a rotated JSON lines file <br />  # noqa: E501
+
+        :return: The sink of this V1beta1LoggerSpec.  # noqa: E501
+        :rtype: str
+        """
+        return self._sink
+
+    @sink.setter
+    def sink(self, sink):
+        """Sets the sink of this V1beta1LoggerSpec.
+
+        Specifies the sink the logging events are delivered to. <br /> Valid values are: <br /> - \"cloudevents\" (default): binary mode CloudEvents posted over HTTP; <br /> - \"cloudevents-structured\": structured mode CloudEvents posted over HTTP; <br /> - \"http-batch\": arrays of structured mode CloudEvents posted over HTTP; <br /> - \"kafka\": binary mode CloudEvents produced to a Kafka topic; <br /> - \"file\": structured mode CloudEvents appended to a rotated JSON lines file <br />  # noqa: E501
+
+        :param sink: The sink of this V1beta1LoggerSpec.  # noqa: E501
+        :type: str
+        """
+
+        self._sink = sink
+
     @property
     def url(self):
         """Gets the url of this V1beta1LoggerSpec.  # noqa: E501
 
-        URL to send logging events  # noqa: E501
+        URL to send logging events. For the kafka sink the URL is of the form kafka://<broker>[,<broker>]/<topic>, for the file sink it is of the form file:///<path>  # noqa: E501
 
         :return: The url of this V1beta1LoggerSpec.  # noqa: E501
         :rtype: str
@@ -109,7 +189,7 @@ class V1beta1LoggerSpec(object):
     def url(self, url):
         """Sets the url of this V1beta1LoggerSpec.
 
-        URL to send logging events  # noqa: E501
+        URL to send logging events. For the kafka sink the URL is of the form kafka://<broker>[,<broker>]/<topic>, for the file sink it is of the form file:///<path>  # noqa: E501
 
         :param url: The url of this V1beta1LoggerSpec.  # noqa: E501
         :type: str
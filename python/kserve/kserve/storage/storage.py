@@ -51,6 +51,10 @@ _URI_RE = "https?://(.+)/(.+)"
 _HTTP_PREFIX = "http(s)://"
 _HEADERS_SUFFIX = "-headers"
 _PVC_PREFIX = "/mnt/pvc"
+_MLFLOW_MODELS_PREFIX = "models:/"
+_MLFLOW_PREFIX = "mlflow://"
+_WASBS_RE = "^wasbs?://([^@/]+)@([a-z0-9]+\\.blob\\.core\\.windows\\.net)/?(.*)$"
+_TERMINATION_LOG = "/dev/termination-log"
 
 _HDFS_SECRET_DIRECTORY = "/var/secrets/kserve-hdfscreds"
 _HDFS_FILE_SECRETS = ["KERBEROS_KEYTAB", "TLS_CERT", "TLS_KEY", "TLS_CA"]
@@ -60,6 +64,8 @@ class Storage(object):  # pylint: disable=too-few-public-methods
     @staticmethod
     def download(uri: str, out_dir: str = None) -> str:
         Storage._update_with_storage_spec()
+        if uri.startswith(_MLFLOW_MODELS_PREFIX) or uri.startswith(_MLFLOW_PREFIX):
+            uri = Storage._resolve_mlflow(uri)
         logging.info("Copying contents of %s to local", uri)
 
         if uri.startswith(_PVC_PREFIX) and not os.path.exists(uri):
@@ -106,6 +112,108 @@ class Storage(object):  # pylint: disable=too-few-public-methods
         logging.info("Successfully copied %s to %s", uri, out_dir)
         return out_dir
 
+    @staticmethod
+    def _resolve_mlflow(uri: str) -> str:
+        """Resolves a model registry uri, models:/{name}/{version|stage},
+        models:/{name}@{alias} or mlflow://{name}/{version|stage}, to the storage uri of
+        the artifacts of the model version through the MLflow tracking server of
+        MLFLOW_TRACKING_URI."""
+        tracking_uri = os.getenv("MLFLOW_TRACKING_URI", "")
+        if tracking_uri == "":
+            raise RuntimeError(
+                "Unable to resolve %s, MLFLOW_TRACKING_URI is not set" % uri
+            )
+        api_url = tracking_uri.rstrip("/") + "/api/2.0/mlflow/"
+        headers = {}
+        auth = None
+        if os.getenv("MLFLOW_TRACKING_TOKEN"):
+            headers["Authorization"] = "Bearer " + os.getenv("MLFLOW_TRACKING_TOKEN")
+        elif os.getenv("MLFLOW_TRACKING_USERNAME"):
+            auth = (
+                os.getenv("MLFLOW_TRACKING_USERNAME"),
+                os.getenv("MLFLOW_TRACKING_PASSWORD", ""),
+            )
+
+        def call(method, endpoint, **kwargs):
+            response = requests.request(
+                method, api_url + endpoint, headers=headers, auth=auth, **kwargs
+            )
+            if response.status_code != 200:
+                raise RuntimeError(
+                    "MLflow tracking server returned a %s response code for %s: %s"
+                    % (response.status_code, uri, response.text)
+                )
+            return response.json()
+
+        path = re.sub("^(%s|%s)" % (_MLFLOW_MODELS_PREFIX, _MLFLOW_PREFIX), "", uri)
+        name, alias, stage, version = path, "", "", ""
+        if "@" in path:
+            name, alias = path.rsplit("@", 1)
+        elif "/" in path:
+            name, version = path.rsplit("/", 1)
+            if not version.isdigit():
+                stage, version = version, ""
+        if name == "" or not (alias or stage or version):
+            raise ValueError(
+                "Invalid model registry uri %s, expected "
+                "%s{name}/{version|stage} or %s{name}@{alias}"
+                % (uri, _MLFLOW_MODELS_PREFIX, _MLFLOW_MODELS_PREFIX)
+            )
+
+        if alias:
+            version = call(
+                "GET",
+                "registered-models/alias",
+                params={"name": name, "alias": alias},
+            )["model_version"]["version"]
+        elif stage:
+            request = {"name": name}
+            if stage.lower() != "latest":
+                request["stages"] = [stage]
+            versions = call(
+                "POST", "registered-models/get-latest-versions", json=request
+            ).get("model_versions", [])
+            if not versions:
+                raise RuntimeError(
+                    "Model %s has no version in stage %s" % (name, stage)
+                )
+            version = max((v["version"] for v in versions), key=int)
+
+        artifact_uri = call(
+            "GET",
+            "model-versions/get-download-uri",
+            params={"name": name, "version": version},
+        ).get("artifact_uri", "")
+        if artifact_uri.startswith(("mlflow-artifacts:", "runs:", "dbfs:")):
+            raise RuntimeError(
+                "The artifact uri %s of version %s of model %s is served by the "
+                "tracking server, the model artifacts must be in a storage the "
+                "models are downloaded from"
+                % (artifact_uri, version, name)
+            )
+        wasbs = re.search(_WASBS_RE, artifact_uri)
+        if wasbs:
+            artifact_uri = "https://%s/%s/%s" % (
+                wasbs.group(2),
+                wasbs.group(1),
+                wasbs.group(3),
+            )
+        logging.info("Resolved %s to version %s at %s", uri, version, artifact_uri)
+        Storage._write_termination_message(
+            {"resolvedStorageUri": artifact_uri, "resolvedVersion": version}
+        )
+        return artifact_uri
+
+    @staticmethod
+    def _write_termination_message(message: Dict):
+        # The termination message of the storage initializer is kept in the pod status,
+        # so that the model version a deployment runs is traceable.
+        try:
+            with open(_TERMINATION_LOG, "w") as f:
+                json.dump(message, f)
+        except OSError:
+            logging.debug("Unable to write the termination message", exc_info=True)
+
     @staticmethod
     def _update_with_storage_spec():
         storage_secret_json = json.loads(os.environ.get("STORAGE_CONFIG", "{}"))
@@ -13,6 +13,7 @@
 # limitations under the License.
 
 import io
+import json
 import os
 import tempfile
 import binascii
@@ -286,3 +287,69 @@ def test_unpack_zip_file():
     Storage._unpack_archive_file(tar_file, mimetype, out_dir)
     assert os.path.exists(os.path.join(out_dir, "model.pth"))
     os.remove(os.path.join(out_dir, "model.pth"))
+
+
+def mock_mlflow_request(method, url, **kwargs):
+    endpoint = url.split("/api/2.0/mlflow/", 1)[1]
+    response = mock.MagicMock(status_code=200)
+    if endpoint == "registered-models/get-latest-versions":
+        response.json.return_value = {
+            "model_versions": [{"version": "2"}, {"version": "10"}]
+            if "stages" not in kwargs["json"]
+            else [{"version": "2"}]
+        }
+    elif endpoint == "registered-models/alias":
+        response.json.return_value = {"model_version": {"version": "2"}}
+    elif endpoint == "model-versions/get-download-uri":
+        response.json.return_value = {
+            "artifact_uri": "s3://mlflow/1/run%s/artifacts/model"
+            % kwargs["params"]["version"]
+        }
+    else:
+        response.status_code = 404
+    return response
+
+
+@pytest.mark.parametrize(
+    "uri,expected_uri,expected_version",
+    [
+        ("models:/fraud-detector/3", "s3://mlflow/1/run3/artifacts/model", "3"),
+        (
+            "models:/fraud-detector/Production",
+            "s3://mlflow/1/run2/artifacts/model",
+            "2",
+        ),
+        ("models:/fraud-detector/latest", "s3://mlflow/1/run10/artifacts/model", "10"),
+        ("mlflow://fraud-detector@champion", "s3://mlflow/1/run2/artifacts/model", "2"),
+    ],
+)
+@mock.patch(STORAGE_MODULE + ".Storage._download_s3")
+@mock.patch("requests.request", side_effect=mock_mlflow_request)
+def test_mlflow_uri(
+    mock_request, mock_download_s3, uri, expected_uri, expected_version
+):
+    with tempfile.TemporaryDirectory() as out_dir:
+        termination_log = os.path.join(out_dir, "termination-log")
+        with mock.patch.dict(
+            os.environ,
+            {
+                "MLFLOW_TRACKING_URI": "https://mlflow.example.com",
+                "MLFLOW_TRACKING_TOKEN": "token",
+            },
+        ), mock.patch(STORAGE_MODULE + "._TERMINATION_LOG", termination_log):
+            assert Storage.download(uri, out_dir=out_dir) == out_dir
+        mock_download_s3.assert_called_with(expected_uri, out_dir)
+        assert mock_request.call_args.kwargs["headers"] == {
+            "Authorization": "Bearer token"
+        }
+        with open(termination_log) as f:
+            assert json.load(f) == {
+                "resolvedStorageUri": expected_uri,
+                "resolvedVersion": expected_version,
+            }
+
+
+def test_mlflow_uri_without_tracking_server():
+    with mock.patch.dict(os.environ, {"MLFLOW_TRACKING_URI": ""}):
+        with pytest.raises(RuntimeError):
+            Storage.download("models:/fraud-detector/Production")
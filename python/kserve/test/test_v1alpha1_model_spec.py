@@ -50,7 +50,14 @@ class TestV1alpha1ModelSpec(unittest.TestCase):
         optional params are included"""
         # model = kserve.models.v1alpha1_model_spec.V1alpha1ModelSpec()  # noqa: E501
         if include_optional:
-            return V1alpha1ModelSpec(framework="0", memory="0", storage_uri="0")
+            return V1alpha1ModelSpec(
+                digest="0",
+                file_digests={"key": "0"},
+                framework="0",
+                memory="0",
+                priority=56,
+                storage_uri="0",
+            )
         else:
             return V1alpha1ModelSpec(
                 framework="0",
@@ -50,7 +50,9 @@ class TestV1beta1Batcher(unittest.TestCase):
         optional params are included"""
         # model = kserve.models.v1beta1_batcher.V1beta1Batcher()  # noqa: E501
         if include_optional:
-            return V1beta1Batcher(max_batch_size=56, max_latency=56, timeout=56)
+            return V1beta1Batcher(
+                max_batch_size=56, max_latency=56, partial_failure=True, timeout=56
+            )
         else:
             return V1beta1Batcher()
 
@@ -56,6 +56,7 @@ class TestV1beta1ComponentExtensionSpec(unittest.TestCase):
                 batcher=kserve.models.v1beta1_batcher.V1beta1Batcher(
                     max_batch_size=56,
                     max_latency=56,
+                    partial_failure=True,
                     timeout=56,
                 ),
                 canary_traffic_percent=56,
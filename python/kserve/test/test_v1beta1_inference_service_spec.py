@@ -80,6 +80,7 @@ class TestV1beta1InferenceServiceSpec(unittest.TestCase):
                     batcher=kserve.models.v1beta1_batcher.V1beta1Batcher(
                         max_batch_size=56,
                         max_latency=56,
+                        partial_failure=True,
                         timeout=56,
                     ),
                     canary_traffic_percent=56,
@@ -130,6 +131,7 @@ class TestV1beta1InferenceServiceSpec(unittest.TestCase):
                     batcher=kserve.models.v1beta1_batcher.V1beta1Batcher(
                         max_batch_size=56,
                         max_latency=56,
+                        partial_failure=True,
                         timeout=56,
                     ),
                     canary_traffic_percent=56,
@@ -301,6 +303,7 @@ class TestV1beta1InferenceServiceSpec(unittest.TestCase):
                     batcher=kserve.models.v1beta1_batcher.V1beta1Batcher(
                         max_batch_size=56,
                         max_latency=56,
+                        partial_failure=True,
                         timeout=56,
                     ),
                     canary_traffic_percent=56,
@@ -354,6 +357,7 @@ class TestV1beta1InferenceServiceSpec(unittest.TestCase):
                     batcher=kserve.models.v1beta1_batcher.V1beta1Batcher(
                         max_batch_size=56,
                         max_latency=56,
+                        partial_failure=True,
                         timeout=56,
                     ),
                     canary_traffic_percent=56,
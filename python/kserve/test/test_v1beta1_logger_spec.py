@@ -50,7 +50,36 @@ class TestV1beta1LoggerSpec(unittest.TestCase):
         optional params are included"""
         # model = kserve.models.v1beta1_logger_spec.V1beta1LoggerSpec()  # noqa: E501
         if include_optional:
-            return V1beta1LoggerSpec(mode="0", url="0")
+            return V1beta1LoggerSpec(
+                filter=kserve.models.v1beta1_logger_filter_spec.V1beta1LoggerFilterSpec(
+                    rules=[
+                        kserve.models.v1beta1_logger_filter_rule.V1beta1LoggerFilterRule(
+                            headers={"key": "0"},
+                            json_field=kserve.models.v1beta1_logger_json_field_predicate.V1beta1LoggerJSONFieldPredicate(
+                                path="0",
+                                value="0",
+                            ),
+                            path="0",
+                            sampling_percentage=56,
+                            status_codes=["0"],
+                        )
+                    ],
+                    sampling_percentage=56,
+                ),
+                mode="0",
+                redaction=kserve.models.v1beta1_logger_redaction_spec.V1beta1LoggerRedactionSpec(
+                    drop=["0"],
+                    hash=["0"],
+                    masks=[
+                        kserve.models.v1beta1_logger_redaction_mask.V1beta1LoggerRedactionMask(
+                            pattern="0",
+                            replacement="0",
+                        )
+                    ],
+                ),
+                sink="0",
+                url="0",
+            )
         else:
             return V1beta1LoggerSpec()
 